@@ -4,6 +4,8 @@ import (
 	"github.com/Cacsjep/goxis/pkg/acapapp"
 	"github.com/Cacsjep/goxis/pkg/axlarod"
 	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
 )
 
 var (
@@ -43,18 +45,21 @@ func main() {
 			}
 
 			// Retrieve the prediction result
-			if lea.prediction_result, err = lea.InferenceOutputRead(lea.infer_result.OutputData.(*InferenceResult)); err != nil {
+			if lea.prediction_result, err = lea.InferenceOutputRead(lea.infer_result.OutputData.([]postprocess.Classification)); err != nil {
 				lea.app.Syslog.Errorf("Failed to convert prediction result: %s", err.Error())
 				return
 			}
 
-			lea.app.Syslog.Infof("Frame: %d, PP time: %.fms, Infer time: %.fms, Persons: %.1f%%, Car: %.1f%%",
+			lea.app.Syslog.Infof("Frame: %d, PP time: %.fms, Infer time: %.fms, Top %d: %s",
 				frame.SequenceNbr,
 				lea.pp_result.ExecutionTime,
 				lea.infer_result.ExecutionTime,
-				lea.prediction_result.Persons,
-				lea.prediction_result.Car,
+				lea.topK,
+				lea.prediction_result,
 			)
+
+			// Toggle the classification event of the rules
+			lea.UpdateRules(lea.classifier.Scores())
 		}
 	}
 }
@@ -62,23 +67,36 @@ func main() {
 // larodExampleApplication struct defines the structure for this example.
 // It includes configuration for application, models, video stream, and other operational parameters.
 type larodExampleApplication struct {
-	app               *acapapp.AcapApplication       // app represents the acap application
-	PPModel           *axlarod.LarodModel            // PPModel is the preprocessing model.
-	DetectionModel    *axlarod.LarodModel            // DetectionModel is the model used for detecting objects in video frames.
-	streamWidth       int                            // streamWidth specifies the width of the video stream.
-	streamHeight      int                            // streamHeight specifies the height of the video stream.
-	fps               int                            // fps represents the frame rate of the video stream.
-	sconfig           *axvdo.VideoSteamConfiguration // sconfig holds the configuration for the video stream.
-	pp_result         *axlarod.JobResult             // pp_result holds the result of the preprocessing model job.
-	infer_result      *axlarod.JobResult             // infer_result holds the result of the detection model job.
-	prediction_result *PredictionResult              // prediction_result stores the output of the inference process.
+	app                   *acapapp.AcapApplication       // app represents the acap application
+	PPModel               *axlarod.LarodModel            // PPModel is the preprocessing model.
+	DetectionModel        *axlarod.LarodModel            // DetectionModel is the model used for detecting objects in video frames.
+	streamWidth           int                            // streamWidth specifies the width of the video stream.
+	streamHeight          int                            // streamHeight specifies the height of the video stream.
+	fps                   int                            // fps represents the frame rate of the video stream.
+	sconfig               *axvdo.VideoSteamConfiguration // sconfig holds the configuration for the video stream.
+	pp_result             *axlarod.JobResult             // pp_result holds the result of the preprocessing model job.
+	infer_result          *axlarod.JobResult             // infer_result holds the result of the detection model job.
+	prediction_result     PredictionResult               // prediction_result stores the smoothed top K predictions.
+	postProcessorName     string                         // postProcessorName selects the decoding of the model outputs, see postprocess.Names.
+	postProcessor         postprocess.PostProcessor      // postProcessor converts the output tensors into classifications.
+	classifier            *postprocess.Classifier        // classifier is the post processor, it provides the scores of all classes for the rules.
+	outputs               [][]byte                       // outputs holds the memory mapped output tensors of the detection model.
+	labels                postprocess.Labels             // labels holds the class names of the model.
+	topK                  int                            // topK is the number of reported classes.
+	threshold             float32                        // threshold is the minimum smoothed score of a reported class.
+	softmax               bool                           // softmax converts the scores into probabilities, for models which output logits.
+	smoothing             float32                        // smoothing is the weight of the newest frame in the moving average of the scores.
+	rules                 []*analytics.ClassRule         // rules turn the smoothed scores into the state of the classification event.
+	ruleClasses           []int                          // ruleClasses holds the class index of each rule.
+	classificationEvent   *acapapp.CameraPlatformEvent   // classificationEvent is the declaration of the classification event.
+	classificationEventId int                            // classificationEventId is the declaration id of the classification event.
 }
 
 // Initialize prepares and initializes all necessary components for the application.
 // It sets up models, video streaming and processing configurations.
 // Returns a configured instance of larodExampleApplication or an error if initialization fails.
 func Initalize() (*larodExampleApplication, error) {
-	lea := &larodExampleApplication{streamWidth: 480, streamHeight: 270, fps: 5}
+	lea := &larodExampleApplication{streamWidth: 480, streamHeight: 270, fps: 5, topK: 3, threshold: 0.05, smoothing: 0.3, postProcessorName: postprocess.CLASSIFIER_POSTPROCESS}
 
 	// Initialize a new ACAP application instance.
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
@@ -104,6 +122,11 @@ func Initalize() (*larodExampleApplication, error) {
 		return nil, err
 	}
 
+	// Parse the classification rules and declare the classification event
+	if err = lea.InitRules(); err != nil {
+		return nil, err
+	}
+
 	// Initialize and start the video stream
 	if err = lea.InitalizeAndStartVdo(); err != nil {
 		return nil, err
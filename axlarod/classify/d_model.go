@@ -1,54 +1,96 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/larodmodel"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
 )
 
-// PredictionResult holds the probabilities of detecting specific objects (e.g., persons, cars)
-type PredictionResult struct {
-	Persons float32
-	Car     float32
+// Prediction is the score of a class with its label.
+type Prediction struct {
+	Label string
+	Score float32 // Smoothed probability of the class
+}
+
+// PredictionResult holds the top K predictions, sorted by score.
+type PredictionResult []Prediction
+
+// String formats the predictions for logging, e.g. "person 83.1%, car 4.2%".
+func (r PredictionResult) String() string {
+	if len(r) == 0 {
+		return "none"
+	}
+	predictions := make([]string, len(r))
+	for i, p := range r {
+		predictions[i] = fmt.Sprintf("%s %.1f%%", p.Label, p.Score*100)
+	}
+	return strings.Join(predictions, ", ")
 }
 
 // InitializeDetectionModel configures a detection model with the given model file and hardware chip.
-// It sets up memory-mapped file configurations for input and output tensors.
-// Returns an error if model initialization fails.
+// The memory-mapped files for the tensors are sized from the tflite model metadata.
+// The outputs are decoded by the post processor registered under lea.postProcessorName, which dequantizes
+// the full score vector with the quantization of the model. The labels are loaded from the .txt file next to the model.
+// Returns an error if the model does not match the expected tensors, the labels do not match the classes of the model
+// or if model initialization fails.
 func (lea *larodExampleApplication) InitalizeDetectionModel(modelFilePath string, chipString string) error {
-	model_defs := axlarod.MemMapConfiguration{
-		InputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: lea.PPModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
-		},
-		OutputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: {Size: 1}, // Output Tensor 1, since is not for ambarella-cvflow chips we could use Size: 1
-			1: {Size: 1}, // Output Tensor 2, since is not for ambarella-cvflow chips we could use Size: 1
+	inputs := map[int]*axlarod.MemMapFile{
+		0: lea.PPModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
+	}
+	expect := larodmodel.Expectation{
+		Inputs: []tflite.TensorSpec{
+			{Types: []tflite.TensorType{tflite.Uint8}, Shape: []int{1, lea.streamHeight, lea.streamWidth, 3}},
 		},
 	}
 
-	if lea.DetectionModel, err = lea.app.Larod.NewInferModel(modelFilePath, chipString, model_defs, nil); err != nil {
+	// Output sizes are taken from the model, so they also fit for ambarella-cvflow chips
+	var meta *tflite.Model
+	if lea.DetectionModel, meta, err = larodmodel.NewInferModel(lea.app.Larod, modelFilePath, chipString, inputs, expect); err != nil {
 		return err
 	}
-
 	lea.app.AddModelCleaner(lea.DetectionModel)
-	return nil
-}
 
-type InferenceResult struct {
-	Person int
-	Car    int
+	if lea.postProcessor, err = postprocess.New(lea.postProcessorName, postprocess.Config{
+		Outputs:   meta.Outputs,
+		Threshold: lea.threshold,
+		TopK:      lea.topK,
+		Softmax:   lea.softmax,
+		Smoothing: lea.smoothing,
+	}); err != nil {
+		return err
+	}
+
+	var ok bool
+	if lea.classifier, ok = lea.postProcessor.(*postprocess.Classifier); !ok {
+		return fmt.Errorf("post processor %s does not provide the scores of all classes for the rules", lea.postProcessor)
+	}
+
+	if lea.labels, err = postprocess.LoadLabels(postprocess.LabelsPath(modelFilePath)); err != nil {
+		return err
+	}
+	if err = lea.labels.Check(lea.classifier.NumClasses()); err != nil {
+		return fmt.Errorf("%s: %w", postprocess.LabelsPath(modelFilePath), err)
+	}
+
+	lea.app.Syslog.Infof("Detection model post processing: %s", lea.postProcessor)
+	return nil
 }
 
-// getDResult retrieves the detection results from the model's output tensors.
-// Returns the raw byte output and an error if retrieval fails.
-func (lea *larodExampleApplication) getDResult() (*InferenceResult, error) {
-	person, err := lea.DetectionModel.Outputs[0].GetDataAsInt()
-	if err != nil {
+// getDResult decodes the class scores directly from the memory mapped output tensors.
+// The tensors are not copied, and the post processor reuses its buffers across frames.
+func (lea *larodExampleApplication) getDResult() ([]postprocess.Classification, error) {
+	if lea.outputs, err = larodmodel.Outputs(lea.DetectionModel, lea.outputs); err != nil {
 		return nil, err
 	}
-	car, err := lea.DetectionModel.Outputs[1].GetDataAsInt()
-	if err != nil {
+	var result postprocess.Result
+	if result, err = lea.postProcessor.Process(lea.outputs); err != nil {
 		return nil, err
 	}
-	return &InferenceResult{Person: person, Car: car}, nil
+	return result.Classifications, nil
 }
 
 // Inference executes the model and retrieves the processed results.
@@ -73,9 +115,11 @@ func (lea *larodExampleApplication) Inference() (*axlarod.JobResult, error) {
 	return result, nil
 }
 
-// InferenceOutputRead converts raw model output data into structured prediction results.
-//
-// https://github.com/AxisCommunications/acap-native-sdk-examples/blob/7bff215e7673e4a72630bb89f04c2f7b64cf319c/vdo-larod/app/vdo_larod.c#L486C33-L486C50
-func (lea *larodExampleApplication) InferenceOutputRead(result *InferenceResult) (*PredictionResult, error) {
-	return &PredictionResult{Persons: float32(result.Person) / 255.0 * 100, Car: float32(result.Car) / 255.0 * 100}, nil
+// InferenceOutputRead converts the classifications into labeled predictions.
+func (lea *larodExampleApplication) InferenceOutputRead(classes []postprocess.Classification) (PredictionResult, error) {
+	result := make(PredictionResult, len(classes))
+	for i, c := range classes {
+		result[i] = Prediction{Label: lea.labels.Name(c.Class), Score: c.Score}
+	}
+	return result, nil
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/acapapp"
+	"github.com/Cacsjep/goxis/pkg/axevent"
+	"github.com/Cacsjep/goxis/pkg/utils"
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+)
+
+var (
+	CLASSIFY_RULES  = []string{"person > 70% for 2s", "car > 70% for 2s"} // Rules which toggle the classification event, see analytics.ParseClassRule
+	RULE_HYSTERESIS = float32(0.2)                                        // A rule deactivates when the score drops this far below its threshold
+	RULE_OFF_DELAY  = 1 * time.Second                                     // How long the score must stay below the off threshold
+	RULE_MIN_HOLD   = 5 * time.Second                                     // Minimum active time, so short activations still trigger action rules
+)
+
+// InitRules parses the classification rules and declares the classification event.
+// The event is stateful, it is active as long as its rule is active, so action rules and VMS can react on it.
+// Returns an error if a rule is invalid or uses a label which the model does not have.
+func (lea *larodExampleApplication) InitRules() error {
+	for _, s := range CLASSIFY_RULES {
+		rule, err := analytics.ParseClassRule(s)
+		if err != nil {
+			return err
+		}
+		class := slices.Index(lea.labels, rule.Label)
+		if class < 0 {
+			return fmt.Errorf("rule %q: model has no label %q", s, rule.Label)
+		}
+		rule.OffThreshold = max(0, rule.OnThreshold-RULE_HYSTERESIS)
+		rule.OffDelay = RULE_OFF_DELAY
+		rule.MinHold = RULE_MIN_HOLD
+		lea.rules = append(lea.rules, rule)
+		lea.ruleClasses = append(lea.ruleClasses, class)
+		lea.app.Syslog.Infof("Classification rule: %s, off below %.0f%%", rule, rule.OffThreshold*100)
+	}
+
+	lea.classificationEvent = &acapapp.CameraPlatformEvent{
+		Name:     "classification",
+		NiceName: utils.StrPtr("Classification"),
+		Entries: []*acapapp.EventEntry{
+			{Key: "rule", ValueType: axevent.AXValueTypeString, IsSource: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Rule")},
+			{Key: "active", ValueType: axevent.AXValueTypeBool, IsData: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Active"), Value: false},
+			{Key: "label", ValueType: axevent.AXValueTypeString, KeyNiceName: utils.StrPtr("Label"), Value: ""},
+			{Key: "score", ValueType: axevent.AXValueTypeDouble, KeyNiceName: utils.StrPtr("Score"), Value: 0.0},
+		},
+		Stateless: false,
+	}
+	if lea.classificationEventId, err = lea.app.AddCameraPlatformEvent(lea.classificationEvent); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateRules feeds the smoothed scores of all classes into the rules and sends the classification event when a rule
+// changes its state. The scores are not cut by the threshold and top K of the reported predictions, so a rule does
+// not switch off because its class drops out of the top K.
+func (lea *larodExampleApplication) UpdateRules(scores []float32) {
+	now := time.Now()
+	for i, rule := range lea.rules {
+		score := scores[lea.ruleClasses[i]]
+		if !rule.Update(score, now) {
+			continue
+		}
+		lea.app.Syslog.Infof("Rule %s: active %t, score %.1f%%", rule, rule.Active(), score*100)
+
+		if err := lea.app.SendPlatformEvent(lea.classificationEventId, func() (*axevent.AXEvent, error) {
+			return lea.classificationEvent.NewEvent(acapapp.KeyValueMap{
+				"rule":   rule.String(),
+				"active": rule.Active(),
+				"label":  rule.Label,
+				"score":  float64(score),
+			})
+		}); err != nil {
+			lea.app.Syslog.Errorf("Error sending classification event: %s", err.Error())
+		}
+	}
+}
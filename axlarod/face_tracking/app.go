@@ -1,10 +1,18 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/Cacsjep/goxis/pkg/acapapp"
-	"github.com/Cacsjep/goxis/pkg/axlarod"
-	"github.com/Cacsjep/goxis/pkg/axoverlay"
 	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/metrics"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
 )
 
 var (
@@ -26,6 +34,11 @@ func main() {
 	// Defer the cleanup of the application to ensure all resources are released when the application exits in the below for loop.
 	defer lea.app.Close()
 
+	// Stop the pipeline before the models are destroyed, queued frames are still processed.
+	lea.pipeline.Start()
+	defer lea.pipeline.Stop()
+
+	// Capture: the frames are handed to the pipeline, which drops frames if the inference is behind.
 	for {
 		select {
 		case frame := <-lea.app.FrameProvider.FrameStreamChannel:
@@ -33,38 +46,10 @@ func main() {
 				lea.app.Syslog.Errorf("Unexpected Vdo Error: %s", frame.Error.Error())
 				continue
 			}
-
-			// Execute the prepossessing model job
-			if lea.pp_result, err = lea.PreProcess(frame); err != nil {
-				lea.app.Syslog.Errorf("Failed to execute PPModel: %s", err.Error())
-				return
-			}
-
-			// Execute the detection model job
-			if lea.infer_result, err = lea.Inference(); err != nil {
-				lea.app.Syslog.Errorf("Failed to execute Detection Model: %s", err.Error())
-				return
-			}
-
-			// Retrieve the prediction result
-			if lea.prediction_result, err = lea.InferenceOutputRead(lea.infer_result.OutputData.(*mobileNetFaceResult)); err != nil {
-				lea.app.Syslog.Errorf("Failed to convert prediction result: %s", err.Error())
-				return
-			}
-
-			// Draw overlay
-			if err = lea.overlayProvider.Redraw(); err != nil {
-				lea.app.Syslog.Errorf("Failed to redraw overlay: %s", err.Error())
+			if age := time.Since(frame.Timestamp); age >= 0 && !frame.Timestamp.IsZero() {
+				lea.metrics.Capture.Observe(age)
 			}
-
-			lea.app.Syslog.Infof("Frame: %d, PreProcess time: %.fms, Inference time: %.fms, Overall Time: %.fms, Detections: %d",
-				frame.SequenceNbr,
-				lea.pp_result.ExecutionTime,
-				lea.infer_result.ExecutionTime,
-				lea.pp_result.ExecutionTime+lea.infer_result.ExecutionTime,
-				len(lea.detections),
-			)
-
+			lea.pipeline.Push(&frameJob{frame: frame})
 		}
 	}
 }
@@ -72,22 +57,26 @@ func main() {
 // larodExampleApplication struct defines the structure for this example.
 // It includes configuration for application, models, video stream, and other operational parameters.
 type larodExampleApplication struct {
-	app                      *acapapp.AcapApplication       // app represents the acap application
-	PPModel                  *axlarod.LarodModel            // PPModel is the preprocessing model.
-	DetectionModel           *axlarod.LarodModel            // DetectionModel is the model used for detecting objects in video frames.
-	streamWidth              int                            // streamWidth specifies the width of the video stream.
-	streamHeight             int                            // streamHeight specifies the height of the video stream.
-	mobileNetFaceInputWidth  int                            // mobileNetFaceInputWidth specifies the width of the input tensor for the detection model.
-	mobileNetFaceInputHeight int                            // mobileNetFaceInptHeight specifies the height of the input tensor for the detection model.
-	fps                      int                            // fps represents the frame rate of the video stream.
-	sconfig                  *axvdo.VideoSteamConfiguration // sconfig holds the configuration for the video stream.
-	pp_result                *axlarod.JobResult             // pp_result holds the result of the preprocessing model job.
-	infer_result             *axlarod.JobResult             // infer_result holds the result of the detection model job.
-	prediction_result        *PredictionResult              // prediction_result stores the output of the inference process.
-	threshold                float32                        // threshold is the minimum score required for an object to be considered detected.
-	overlayProvider          *axoverlay.OverlayProvider     // overlayProvider is used to draw overlay on the video stream.
-	detections               []Detection                    // detections stores the detected objects.
-	sortTracker              *SORT                          // sortTracker is used to track objects in the video stream.
+	app                      *acapapp.AcapApplication                   // app represents the acap application
+	postProcessorName        string                                     // postProcessorName selects the decoding of the model outputs, see postprocess.Names.
+	postProcessor            postprocess.PostProcessor                  // postProcessor converts the output tensors into detections.
+	pipeline                 *pipeline.Inference[*frameJob, *inferLane] // pipeline runs the stages from capture to publish.
+	dropPolicy               pipeline.DropPolicy                        // dropPolicy decides which frames are dropped when the inference is behind.
+	metrics                  *metrics.Pipeline                          // metrics holds the latency histograms and frame counters served on /metrics.
+	streamWidth              int                                        // streamWidth specifies the width of the video stream.
+	streamHeight             int                                        // streamHeight specifies the height of the video stream.
+	mobileNetFaceInputWidth  int                                        // mobileNetFaceInputWidth specifies the width of the input tensor for the detection model.
+	mobileNetFaceInputHeight int                                        // mobileNetFaceInptHeight specifies the height of the input tensor for the detection model.
+	fps                      int                                        // fps represents the frame rate of the video stream.
+	sconfig                  *axvdo.VideoSteamConfiguration             // sconfig holds the configuration for the video stream.
+	threshold                float32                                    // threshold is the minimum score required for an object to be considered detected.
+	overlay                  *overlay.Overlay                           // overlay draws the scene on the video streams.
+	scene                    *scene.Scene                               // scene holds the tracking score and the tracked faces drawn by the overlay.
+	detections               []Detection                                // detections stores the detected objects.
+	sortTracker              *tracker.SORT[Detection]                   // sortTracker is used to track objects in the video stream.
+	embedder                 tracker.Embedder                           // embedder creates appearance embeddings for re-identification, nil if disabled.
+	resizeMode               projection.Mode                            // resizeMode selects how the stream is fitted into the model input.
+	projection               projection.Projection                      // projection maps the model boxes back into stream coordinates.
 }
 
 // Initialize prepares and initializes all necessary components for the application.
@@ -100,36 +89,51 @@ func Initalize() (*larodExampleApplication, error) {
 		threshold:                0.1,
 		mobileNetFaceInputWidth:  320,
 		mobileNetFaceInputHeight: 320,
+		postProcessorName:        postprocess.SSD_POSTPROCESS,
+		dropPolicy:               pipeline.DropOldest,
 		detections:               []Detection{},
-		sortTracker:              NewSORT(5, 0.2, 0.3),
+		resizeMode:               projection.Letterbox,
+		sortTracker:              tracker.NewSORT[Detection](5, 3, 0.2, 0.3),
 	}
 
 	// Initialize a new ACAP application instance.
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	lea.app = acapapp.NewAcapApplication()
 
-	// TODO: Handle proper dimensions handling, currently we are cropped for some reason even
-	// we had for example same width we currently cant detect on left or right edge.
+	// Report faces entering and leaving the scene
+	lea.sortTracker.OnTrackStateChange = lea.onTrackStateChange
+
+	// The 16:9 stream is letterboxed into the square model input, a center crop would cut off the left and right edge.
 	lea.streamWidth = 320
 	lea.streamHeight = 180
+	lea.projection = projection.New(lea.resizeMode, lea.streamWidth, lea.streamHeight, lea.mobileNetFaceInputWidth, lea.mobileNetFaceInputHeight)
 
 	// Initialize/Connecting Larod
 	if err = lea.app.InitalizeLarod(); err != nil {
 		return nil, err
 	}
 
-	// Initialize the preprocessing model
-	if err = lea.InitalizePPModel(axlarod.PreProccessOutputFormatRgbInterleaved); err != nil {
-		return nil, err
-	}
-
 	// Print the available devices
 	for _, d := range lea.app.Larod.Devices {
 		lea.app.Syslog.Infof("Device: %s", d.Name)
 	}
 
-	// Initialize the detection model
-	if err = lea.InitalizeDetectionModel("ssd_mobilenet_v2_face_quant_postprocess.tflite", "axis-a8-dlpu-tflite"); err != nil {
+	// Initialize the preprocessing and detection models of the pipeline
+	if err = lea.InitalizePipeline("ssd_mobilenet_v2_face_quant_postprocess.tflite", "axis-a8-dlpu-tflite"); err != nil {
+		return nil, err
+	}
+
+	// Initialize the optional embedding model used to re-identify faces after longer occlusions
+	if _, err = os.Stat(EMBEDDING_MODEL_FILE); err == nil {
+		if err = lea.InitalizeEmbeddingModel(EMBEDDING_MODEL_FILE, "axis-a8-dlpu-tflite"); err != nil {
+			return nil, err
+		}
+	} else {
+		lea.app.Syslog.Infof("No %s found, appearance re-identification is disabled", EMBEDDING_MODEL_FILE)
+	}
+
+	// Serve the pipeline metrics for Prometheus
+	if err = lea.InitMetrics(); err != nil {
 		return nil, err
 	}
 
@@ -138,9 +142,20 @@ func Initalize() (*larodExampleApplication, error) {
 		return nil, err
 	}
 
-	// Initialize the overlay provider
+	// Initialize the overlay
 	if err = lea.InitOverlay(); err != nil {
 		return nil, err
 	}
 	return lea, nil
 }
+
+// onTrackStateChange logs when a confirmed face enters or leaves the scene.
+// Tentative tracks that are dropped before confirmation are ignored.
+func (lea *larodExampleApplication) onTrackStateChange(track *tracker.Track, previous tracker.TrackState) {
+	switch {
+	case track.State == tracker.TrackConfirmed && previous == tracker.TrackTentative:
+		lea.app.Syslog.Infof("Face ID-%d entered", track.ID)
+	case track.State == tracker.TrackRemoved && previous != tracker.TrackTentative:
+		lea.app.Syslog.Infof("Face ID-%d left after %d sec", track.ID, int(track.ActiveTime().Seconds()))
+	}
+}
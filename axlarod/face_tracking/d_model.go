@@ -2,128 +2,128 @@ package main
 
 import (
 	"fmt"
-	"time"
+	"unsafe"
 
 	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/larodmodel"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
 )
 
-var (
-	FLOAT_SIZE    = uint(4)
-	TENSOR_1_SIZE = 1 * 50 * 4 * FLOAT_SIZE // Locations: [1, 50, 4]
-	TENSOR_2_SIZE = 1 * 50 * FLOAT_SIZE     // Classes: [1, 50]
-	TENSOR_3_SIZE = 1 * 50 * FLOAT_SIZE     // Scores: [1, 50]
-	TENSOR_4_SIZE = 1 * FLOAT_SIZE          // Detections: [1]
-	BBOX_SIZE     = 4                       // Each box has 4 coordinates
-)
+// SSD_INPUT_TYPES are the input types of the quantized ssd models.
+var SSD_INPUT_TYPES = []tflite.TensorType{tflite.Uint8}
 
-// PredictionResult holds the probabilities of detecting specific objects (e.g., persons, cars)
+// PredictionResult holds the tracked faces of the current frame
 type PredictionResult struct {
-	Detections []Detection
+	Detections []tracker.TrackedObject[Detection]
 }
 
 // InitializeDetectionModel configures a detection model with the given model file and hardware chip.
-// It sets up memory-mapped file configurations for input and output tensors.
-// Returns an error if model initialization fails.
-func (lea *larodExampleApplication) InitalizeDetectionModel(modelFilePath string, chipString string) error {
-	model_defs := axlarod.MemMapConfiguration{
-		InputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: lea.PPModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
-		},
-		OutputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: {Size: TENSOR_1_SIZE}, // BoundingBox locations
-			1: {Size: TENSOR_2_SIZE}, // Classes
-			2: {Size: TENSOR_3_SIZE}, // Scores
-			3: {Size: TENSOR_4_SIZE}, // Number of detections
-		},
+// The memory mapped tensors are sized from the tflite metadata, and a model with unexpected tensors fails here.
+// In letterbox mode the model gets its own padded input, otherwise the ppmodel output is used directly.
+// Returns the model with its tflite metadata or an error if model initialization fails.
+func (lea *larodExampleApplication) InitalizeDetectionModel(ppModel *axlarod.LarodModel, modelFilePath string, chipString string) (*axlarod.LarodModel, *tflite.Model, error) {
+	inputs := map[int]*axlarod.MemMapFile{
+		0: ppModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
+	}
+	if lea.resizeMode == projection.Letterbox {
+		inputs = nil // Padded copy of the ppmodel output
+	}
+
+	detectionModel, meta, err := larodmodel.NewInferModel(lea.app.Larod, modelFilePath, chipString, inputs, larodmodel.Expectation{
+		Inputs: []tflite.TensorSpec{{Types: SSD_INPUT_TYPES, Shape: []int{1, lea.mobileNetFaceInputHeight, lea.mobileNetFaceInputWidth, 3}}},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create detection model: %w", err)
+	}
+	lea.app.AddModelCleaner(detectionModel)
+
+	// The padding is written once, each frame only overwrites the content area
+	if lea.resizeMode == projection.Letterbox {
+		input := detectionModel.Inputs[0].MemMapFile
+		lea.projection.Pad(unsafe.Slice((*byte)(input.MemoryAddress), input.Size))
 	}
+	return detectionModel, meta, nil
+}
 
-	if lea.DetectionModel, err = lea.app.Larod.NewInferModel(modelFilePath, chipString, model_defs, nil); err != nil {
+// InitalizePostProcessor creates the post processor registered under lea.postProcessorName for the model outputs.
+// Returns an error if the outputs do not fit the post processor.
+func (lea *larodExampleApplication) InitalizePostProcessor(meta *tflite.Model) error {
+	if lea.postProcessor, err = postprocess.New(lea.postProcessorName, postprocess.Config{
+		Outputs:   meta.Outputs,
+		Threshold: lea.threshold,
+	}); err != nil {
 		return fmt.Errorf("failed to create detection model: %w", err)
 	}
-	lea.app.AddModelCleaner(lea.DetectionModel)
+	lea.app.Syslog.Infof("Detection model post processing: %s", lea.postProcessor)
 	return nil
 }
 
-// getDResult retrieves the detection results from the model's output tensors.
-// Returns the raw byte output and an error if retrieval fails.
-func (lea *larodExampleApplication) getDResult() (*mobileNetFaceResult, error) {
-	t1, err := lea.DetectionModel.Outputs[0].GetDataAsFloat32Slice(int(TENSOR_1_SIZE))
-	if err != nil {
-		return nil, err
-	}
-	t2, err := lea.DetectionModel.Outputs[1].GetDataAsFloat32Slice(int(TENSOR_2_SIZE))
-	if err != nil {
-		return nil, err
+// FillModelInput copies the preprocessed frame into the content area of the letterboxed model input of the lane.
+// In center crop mode the ppmodel output is the model input, so nothing is copied.
+func (lea *larodExampleApplication) FillModelInput(lane *inferLane, rgb []byte) error {
+	if lea.resizeMode != projection.Letterbox {
+		return nil
 	}
-	t3, err := lea.DetectionModel.Outputs[2].GetDataAsFloat32Slice(int(TENSOR_3_SIZE))
-	if err != nil {
-		return nil, err
+	mmf := lane.DetectionModel.Inputs[0].MemMapFile
+	if !lea.projection.Fill(unsafe.Slice((*byte)(mmf.MemoryAddress), mmf.Size), rgb) {
+		return fmt.Errorf("frame of %d bytes does not fit the model input", len(rgb))
 	}
-	t4, err := lea.DetectionModel.Outputs[3].GetDataAsFloat32()
-	if err != nil {
-		return nil, err
-	}
-	return &mobileNetFaceResult{locations: t1, classes: t2, scores: t3, detections: int(t4)}, nil
+	return nil
 }
 
-// Inference executes the model and retrieves the processed results.
+// Inference executes the detection model of the lane, the outputs are decoded later by Decode.
 // It ensures the model's file pointers are correctly positioned before execution.
-// Returns a JobResult containing the inference results or an error if the inference process fails.
-func (lea *larodExampleApplication) Inference() (*axlarod.JobResult, error) {
+// Returns a JobResult with the execution time or an error if the inference process fails.
+func (lea *larodExampleApplication) Inference(lane *inferLane) (*axlarod.JobResult, error) {
 
 	// Rewind all output files position before each job.
-	if err = lea.DetectionModel.RewindAllOutputsMemMapFiles(); err != nil {
+	if err := lane.DetectionModel.RewindAllOutputsMemMapFiles(); err != nil {
 		return nil, err
 	}
 
-	var result *axlarod.JobResult
-	if result, err = lea.app.Larod.ExecuteJob(lea.DetectionModel, func() error {
+	return lea.app.Larod.ExecuteJob(lane.DetectionModel, func() error {
 		return nil // is feeded via memmap
 	}, func() (any, error) {
-		return lea.getDResult()
-	}); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+		return nil, nil // is read via memmap by Decode
+	})
 }
 
-type mobileNetFaceResult struct {
-	locations  []float32
-	classes    []float32
-	scores     []float32
-	detections int
+// Decode decodes the detection results directly from the memory mapped output tensors of the lane.
+func (lea *larodExampleApplication) Decode(lane *inferLane) (postprocess.Result, error) {
+	var err error
+	if lane.outputs, err = larodmodel.Outputs(lane.DetectionModel, lane.outputs); err != nil {
+		return postprocess.Result{}, err
+	}
+	return lea.postProcessor.Process(lane.outputs)
 }
 
+// Detection is a detected face with its appearance embedding.
 type Detection struct {
-	Score, Class  float32
-	Box           axlarod.BoundingBox
-	ID            int
-	IsNew         bool // Indicates if this detection created a new track
-	Age           int  // Number of frames since the track was last updated
-	TrackingSince time.Duration
+	postprocess.Detection
+	Embedding []float32 // Appearance embedding, nil if no embedder is configured
 }
 
-// InferenceOutputRead converts raw model output data into structured prediction results.
+// GetEmbedding implements tracker.AppearanceDetection, the other methods are promoted from postprocess.Detection
+func (d Detection) GetEmbedding() []float32 { return d.Embedding }
+
+// InferenceOutputRead maps the decoded detections into stream coordinates, embeds and tracks them.
+// The preprocessed RGB frame is used to embed the detections.
 // Returns a PredictionResult or an error if data conversion fails.
-func (lea *larodExampleApplication) InferenceOutputRead(result *mobileNetFaceResult) (*PredictionResult, error) {
-	var detections []Detection
-	for i := 0; i < result.detections; i++ {
-		score := result.scores[i]
-		if score >= lea.threshold {
-			if BBOX_SIZE*i+3 < len(result.locations) { // Ensure within bounds
-				detections = append(detections, Detection{
-					Box: axlarod.BoundingBox{
-						Top:    result.locations[BBOX_SIZE*i],
-						Left:   result.locations[BBOX_SIZE*i+1],
-						Bottom: result.locations[BBOX_SIZE*i+2],
-						Right:  result.locations[BBOX_SIZE*i+3],
-					},
-					Score: score,
-					Class: result.classes[i],
-				})
-			}
-		}
+func (lea *larodExampleApplication) InferenceOutputRead(result postprocess.Result, rgb []byte) (*PredictionResult, error) {
+	detections := make([]Detection, 0, len(result.Detections))
+	for _, det := range result.Detections {
+		// Map the box back into stream coordinates for tracking and drawing
+		det.Box = lea.projection.ToStream(det.Box)
+		detections = append(detections, Detection{Detection: det})
+	}
+
+	// Add appearance embeddings for re-identification if an embedding model is available
+	if lea.embedder != nil {
+		lea.EmbedDetections(rgb, detections)
 	}
+	lea.detections = detections
 	return &PredictionResult{Detections: lea.sortTracker.Update(detections)}, nil
 }
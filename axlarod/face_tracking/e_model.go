@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/larodmodel"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+var (
+	EMBEDDING_MODEL_FILE   = "face_embedding.tflite" // Optional appearance model, re-identification is disabled if missing
+	EMBEDDING_INPUT_WIDTH  = 112                     // Input: [1, 112, 112, 3] RGB
+	EMBEDDING_INPUT_HEIGHT = 112
+	EMBEDDING_SIZE         = 128 // Output: [1, 128] float32
+)
+
+// LarodEmbedder implements the tracker.Embedder interface with a larod model.
+// The model takes a RGB crop of a detection and outputs a float32 embedding vector.
+type LarodEmbedder struct {
+	Model       *axlarod.LarodModel // Model is the embedding model
+	InputWidth  int                 // InputWidth of the model input tensor
+	InputHeight int                 // InputHeight of the model input tensor
+	Size        int                 // Size is the number of values in the embedding
+	larod       *axlarod.Larod
+}
+
+// NewLarodEmbedder loads an embedding model with the given model file and hardware chip.
+// Returns an error if model initialization fails.
+func NewLarodEmbedder(larod *axlarod.Larod, modelFilePath string, chipString string, inputWidth, inputHeight, size int) (*LarodEmbedder, error) {
+	model, _, err := larodmodel.NewInferModel(larod, modelFilePath, chipString, nil, larodmodel.Expectation{
+		Inputs:  []tflite.TensorSpec{{Types: []tflite.TensorType{tflite.Uint8}, Shape: []int{1, inputHeight, inputWidth, 3}}}, // RGB crop
+		Outputs: []tflite.TensorSpec{{Types: []tflite.TensorType{tflite.Float32}, Shape: []int{1, size}}},                     // Embedding
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding model: %w", err)
+	}
+	return &LarodEmbedder{Model: model, InputWidth: inputWidth, InputHeight: inputHeight, Size: size, larod: larod}, nil
+}
+
+// Embed crops the box from the RGB image, runs the embedding model and returns the normalized embedding.
+func (e *LarodEmbedder) Embed(rgb []byte, width, height int, box tracker.Box) ([]float32, error) {
+	crop := tracker.CropRGB(rgb, width, height, box, e.InputWidth, e.InputHeight)
+
+	if err := e.Model.RewindAllOutputsMemMapFiles(); err != nil {
+		return nil, err
+	}
+
+	result, err := e.larod.ExecuteJob(e.Model, func() error {
+		return e.Model.Inputs[0].CopyDataInto(crop)
+	}, func() (any, error) {
+		return e.Model.Outputs[0].GetDataAsFloat32Slice(e.Size * tflite.Float32.Size())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	embedding := result.OutputData.([]float32)
+	tracker.NormalizeEmbedding(embedding)
+	return embedding, nil
+}
+
+// InitalizeEmbeddingModel configures the appearance embedding model used to re-identify faces.
+// Returns an error if model initialization fails.
+func (lea *larodExampleApplication) InitalizeEmbeddingModel(modelFilePath string, chipString string) error {
+	embedder, err := NewLarodEmbedder(lea.app.Larod, modelFilePath, chipString, EMBEDDING_INPUT_WIDTH, EMBEDDING_INPUT_HEIGHT, EMBEDDING_SIZE)
+	if err != nil {
+		return err
+	}
+	lea.app.AddModelCleaner(embedder.Model)
+	lea.embedder = embedder
+	return nil
+}
+
+// EmbedDetections adds the appearance embedding to each detection, using the preprocessed RGB frame.
+// Detections that fail to embed are tracked by IOU only.
+func (lea *larodExampleApplication) EmbedDetections(rgb []byte, detections []Detection) {
+	resizeWidth, resizeHeight := lea.projection.ResizeResolution()
+	for i := range detections {
+		// The preprocessed image shows the crop area of the stream without padding
+		embedding, err := lea.embedder.Embed(rgb, resizeWidth, resizeHeight, lea.projection.ToCrop(tracker.Box(detections[i].Box)))
+		if err != nil {
+			lea.app.Syslog.Errorf("Failed to embed detection: %s", err.Error())
+			continue
+		}
+		detections[i].Embedding = embedding
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net"
+
+	"github.com/Cacsjep/goxis_examples/pkg/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	METRICS_LISTEN_ADDRESS = "127.0.0.1:2003"                       // Target of the reverse proxy in the manifest
+	METRICS_LABELS         = metrics.Labels{"app": "face_tracking"} // Labels added to all metrics
+	FPS_WINDOW             = 50                                     // Number of published frames used for the effective fps
+)
+
+// InitMetrics registers the metrics and serves them on /metrics of a fiber webserver behind the reverse proxy,
+// e.g. https://<camera>/local/axlarodfacetexample/goxis/metrics.
+// It has to be called after InitalizePipeline, the pipeline counters are read on each scrape.
+func (lea *larodExampleApplication) InitMetrics() error {
+	lea.metrics = metrics.NewPipeline(METRICS_LABELS, FPS_WINDOW)
+	lea.metrics.WatchStages(lea.pipeline.Stats)
+
+	baseUri, err := lea.app.AcapWebBaseUri()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", METRICS_LISTEN_ADDRESS)
+	if err != nil {
+		return err
+	}
+	fapp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	fapp.Get(baseUri+"/metrics", lea.metrics.Registry.Handler())
+	go func() {
+		if err := fapp.Listener(listener); err != nil {
+			lea.app.Syslog.Errorf("Metrics webserver stopped: %s", err.Error())
+		}
+	}()
+	lea.app.AddCloseCleanFunc(func() { fapp.Shutdown() })
+	lea.app.Syslog.Infof("Metrics are served on %s/metrics", baseUri)
+	return nil
+}
@@ -5,49 +5,82 @@ import (
 	"image/color"
 
 	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
 )
 
-// Initialize the overlay provider
+// Initialize the overlay, it redraws whenever the publish stage changes the scene
 func (lea *larodExampleApplication) InitOverlay() error {
-	if lea.overlayProvider, err = axoverlay.NewOverlayProvider(renderCallback, nil, nil); err != nil {
+	lea.scene = scene.New()
+	lea.scene.Reference = scene.DEFAULT_REFERENCE
+	if lea.overlay, err = overlay.New(lea.scene); err != nil {
 		return err
 	}
-	lea.app.AddCloseCleanFunc(lea.overlayProvider.Cleanup)
-	if _, err = lea.overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, lea)); err != nil {
-		return err
+	lea.overlay.OnError = func(err error) {
+		lea.app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
+	lea.app.AddCloseCleanFunc(lea.overlay.Close)
+	lea.overlay.Start()
 	return nil
 }
 
-// renderCallback is used to draw bounding boxes from the detections via axoverlay
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	lea := renderEvent.Userdata.(*larodExampleApplication)
-	renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-
+// OverlayNode builds the tracking score and the tracked faces of a frame.
+// It is called in the postprocess stage right after the tracker is updated, the publish stage puts the node
+// into the scene, so the next frame can already update the tracker while this frame is published and drawn.
+func (lea *larodExampleApplication) OverlayNode(prediction *PredictionResult) scene.Node {
 	// Draw the sort tracker average score
-	renderEvent.CairoCtx.DrawText(fmt.Sprintf("Tracking score: %d%%", int(lea.sortTracker.GetAverageSortScore()*100)), 10, 10, 32.0, "serif", axoverlay.ColorBlack)
-
-	for _, obj := range lea.prediction_result.Detections {
-		scaled_box := obj.Box.Scale(renderEvent.Stream.Width, renderEvent.Stream.Height)
-		cords := scaled_box.ToCords64()
-		DrawBoundingBox(
-			renderEvent.CairoCtx,
-			cords.X,
-			cords.Y,
-			cords.W,
-			cords.H,
+	score := scene.Text{
+		Text:      fmt.Sprintf("Tracking score: %d%%", int(lea.sortTracker.GetAverageSortScore()*100)),
+		TextStyle: scene.TextStyle{Size: 32, Font: "serif", Color: axoverlay.ColorBlack, Padding: 10},
+	}
+
+	faces := scene.Group{}
+	for _, obj := range prediction.Detections {
+		// Draw the trail of past positions behind the face
+		faces.Nodes = append(faces.Nodes, TrailNode(obj.Trajectory, axoverlay.ColorMaterialCyan, 3))
+
+		// Draw the box predicted by the motion model of the track
+		pos, size := boxToScene(obj.PredictedBox)
+		faces.Nodes = append(faces.Nodes, scene.Rect{Pos: pos, Size: size, Style: scene.Style{Stroke: axoverlay.ColorMaterialAmber, LineWidth: 1}})
+
+		// Coasting tracks have no detection in this frame, so only the prediction is drawn
+		if obj.Coasting {
+			continue
+		}
+
+		pos, size = boxToScene(obj.Box)
+		faces.Nodes = append(faces.Nodes, scene.Box(
+			pos,
+			size,
 			axoverlay.ColorBlack,
-			fmt.Sprintf("ID-%d %d%%, %d sec", obj.ID, int(obj.Score*100), int(obj.TrackingSince.Seconds())),
-			axoverlay.ColorWite,
-			13,
-			"sans",
-			10,
-		)
+			fmt.Sprintf("ID-%d %d%%, %d sec", obj.ID, int(obj.Detection.Score*100), int(obj.TrackingSince.Seconds())),
+			scene.TextStyle{Size: 13, Font: "sans", Color: axoverlay.ColorWite, Padding: 3},
+		))
+	}
+	return scene.Group{Nodes: []scene.Node{score, faces}}
+}
+
+// TrailNode returns the trajectory of a track as line segments that fade out towards the oldest point.
+func TrailNode(points []tracker.TrajectoryPoint, trailColor color.RGBA, lineWidth float64) scene.Node {
+	trail := scene.Group{}
+	for i := 1; i < len(points); i++ {
+		segmentColor := trailColor
+		segmentColor.A = uint8(float64(trailColor.A) * float64(i) / float64(len(points)-1))
+		trail.Nodes = append(trail.Nodes, scene.Polyline{
+			Points: []scene.Point{trajectoryPointToScene(points[i-1]), trajectoryPointToScene(points[i])},
+			Style:  scene.Style{Stroke: segmentColor, LineWidth: lineWidth},
+		})
 	}
+	return trail
+}
+
+// boxToScene returns the position and size of a normalized stream box.
+func boxToScene(b tracker.Box) (scene.Point, scene.Point) {
+	return scene.Point{X: float64(b.Left), Y: float64(b.Top)}, scene.Point{X: float64(b.Right - b.Left), Y: float64(b.Bottom - b.Top)}
 }
 
-func DrawBoundingBox(ctx *axoverlay.CairoContext, x float64, y float64, width float64, height float64, rectColor color.RGBA, label string, labelColor color.RGBA, labelSize float64, labelFont string, minBoxSizeRenderW int) {
-	rectLinewidth := float64(3)
-	ctx.DrawBoundingBoxRect(x, y, width, height, rectColor, rectLinewidth, 0.3)
-	ctx.DrawBoundingBoxLabel(label, x-(rectLinewidth/2), y-(rectLinewidth/2), 7, labelSize, labelFont, labelColor, rectColor)
+// trajectoryPointToScene converts a normalized trajectory point.
+func trajectoryPointToScene(p tracker.TrajectoryPoint) scene.Point {
+	return scene.Point{X: float64(p.X), Y: float64(p.Y)}
 }
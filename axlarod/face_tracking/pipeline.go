@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+var (
+	INFER_LANES       = 2                     // Number of preprocessing and detection model pairs, 2 double buffers the memmap tensors
+	STAGE_RETRIES     = 2                     // Retries of a failed larod job before the frame is dropped
+	STAGE_RETRY_DELAY = 10 * time.Millisecond // Wait time between the retries
+	STATS_INTERVAL    = 100                   // Log the pipeline statistics every n published frames
+)
+
+// inferLane is a preprocessing and detection model pair with its own memory mapped tensors.
+// A frame holds a lane from the preprocessing until its outputs are decoded, so the next frame can be
+// preprocessed and inferred on the other lane without overwriting tensors which are still in use.
+type inferLane struct {
+	PPModel        *axlarod.LarodModel // PPModel is the preprocessing model, its output is the detection model input.
+	DetectionModel *axlarod.LarodModel // DetectionModel is the detection model of the lane.
+	outputs        [][]byte            // outputs holds the memory mapped output tensors of the detection model.
+}
+
+// frameJob is a frame on its way through the pipeline.
+type frameJob struct {
+	pipeline.Job[*inferLane]
+	frame        *axvdo.VideoFrame  // frame is the captured video frame.
+	pp_result    *axlarod.JobResult // pp_result holds the result of the preprocessing model job, a copy of the preprocessed RGB frame.
+	infer_result *axlarod.JobResult // infer_result holds the result of the detection model job.
+	result       postprocess.Result // result holds the decoded detections.
+	overlay      scene.Node         // overlay holds the scene nodes of the frame, built with the tracker and analytics state of the frame.
+}
+
+// InitalizePipeline creates the inference lanes and the pipeline capture → preprocess → infer → postprocess → publish.
+// Returns an error if a model of a lane fails to initialize.
+func (lea *larodExampleApplication) InitalizePipeline(modelFilePath string, chipString string) error {
+	lanes, err := pipeline.NewLanes(INFER_LANES, func(int) (*inferLane, error) {
+		ppModel, err := lea.InitalizePPModel(axlarod.PreProccessOutputFormatRgbInterleaved)
+		if err != nil {
+			return nil, err
+		}
+		detectionModel, meta, err := lea.InitalizeDetectionModel(ppModel, modelFilePath, chipString)
+		if err != nil {
+			return nil, err
+		}
+		if lea.postProcessor == nil {
+			if err = lea.InitalizePostProcessor(meta); err != nil {
+				return nil, err
+			}
+		}
+		return &inferLane{PPModel: ppModel, DetectionModel: detectionModel}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	lea.pipeline = pipeline.NewInference(lanes, pipeline.InferenceConfig{Policy: lea.dropPolicy, Retries: STAGE_RETRIES, RetryDelay: STAGE_RETRY_DELAY}, pipeline.InferenceStages[*frameJob]{
+		Preprocess: lea.preprocessStage,
+		Infer:      lea.inferStage,
+		Decode:     lea.decodeStage,
+		Track:      lea.trackStage,
+		Publish:    lea.publishStage,
+	})
+	lea.pipeline.OnError = func(stage string, job *frameJob, err error) {
+		lea.app.Syslog.Errorf("Frame %d dropped, %s failed: %s", job.frame.SequenceNbr, stage, err.Error())
+	}
+	lea.app.Syslog.Infof("Pipeline with %d inference lanes, frame drop policy: %s", INFER_LANES, lea.dropPolicy)
+	return nil
+}
+
+// preprocessStage runs the preprocessing model of the lane, the pipeline waits for a free lane before.
+func (lea *larodExampleApplication) preprocessStage(job *frameJob) error {
+	defer lea.metrics.Preprocess.Since(time.Now())
+	var err error
+	job.pp_result, err = lea.PreProcess(job.Lane(), job.frame)
+	return err
+}
+
+// inferStage copies the preprocessed frame into the letterboxed model input and runs the detection model of the lane,
+// the outputs stay in the memmap files of the lane.
+func (lea *larodExampleApplication) inferStage(job *frameJob) error {
+	defer lea.metrics.Inference.Since(time.Now())
+	if err := lea.FillModelInput(job.Lane(), job.pp_result.OutputData.([]byte)); err != nil {
+		return fmt.Errorf("failed to fill model input: %w", err)
+	}
+	var err error
+	job.infer_result, err = lea.Inference(job.Lane())
+	return err
+}
+
+// decodeStage decodes the outputs of the lane.
+// The decoded detections do not reference the memmap tensors, so the pipeline releases the lane afterwards.
+func (lea *larodExampleApplication) decodeStage(job *frameJob) error {
+	defer lea.metrics.Decode.Since(time.Now())
+	var err error
+	job.result, err = lea.Decode(job.Lane())
+	return err
+}
+
+// trackStage embeds and tracks the detections and builds the overlay of the frame.
+func (lea *larodExampleApplication) trackStage(job *frameJob) error {
+	defer lea.metrics.Tracking.Since(time.Now())
+	prediction, err := lea.InferenceOutputRead(job.result, job.pp_result.OutputData.([]byte))
+	if err != nil {
+		return fmt.Errorf("failed to convert prediction result: %w", err)
+	}
+	job.overlay = lea.OverlayNode(prediction)
+	return nil
+}
+
+// publishStage updates the overlay, counts and logs the frame.
+func (lea *larodExampleApplication) publishStage(job *frameJob) error {
+	start := time.Now()
+	lea.scene.Set("frame", job.overlay)
+	lea.metrics.Overlay.Since(start)
+	lea.metrics.Published.Inc()
+	lea.metrics.FPS.Mark()
+
+	lea.app.Syslog.Infof("Frame: %d, PreProcess time: %.fms, Inference time: %.fms, Overall Time: %.fms, Detections: %d",
+		job.frame.SequenceNbr,
+		job.pp_result.ExecutionTime,
+		job.infer_result.ExecutionTime,
+		job.pp_result.ExecutionTime+job.infer_result.ExecutionTime,
+		len(job.result.Detections),
+	)
+
+	if lea.metrics.Published.Value()%uint64(STATS_INTERVAL) == 0 {
+		for _, stats := range lea.pipeline.Stats() {
+			lea.app.Syslog.Infof("Pipeline %s", stats)
+		}
+		lea.app.Syslog.Infof("Latency %s", lea.metrics)
+	}
+	return nil
+}
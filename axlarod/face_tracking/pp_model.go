@@ -6,37 +6,36 @@ import (
 )
 
 // InitializePPModel initializes a preprocessing model tailored for video processing.
-// It sets the model to operate in the specified RGB mode and resolution based on the current application settings.
+// It sets the model to operate in the specified RGB mode, the output resolution is the resize resolution of the projection.
 // An error is returned if the model fails to initialize or if any issues occur during setup.
-func (lea *larodExampleApplication) InitalizePPModel(rgbMode axlarod.PreProccessOutputFormat) error {
-	cropMap, err := axlarod.CreateCropMap(lea.mobileNetFaceInputWidth, lea.mobileNetFaceInputHeight, lea.streamWidth, lea.streamHeight)
+func (lea *larodExampleApplication) InitalizePPModel(rgbMode axlarod.PreProccessOutputFormat) (*axlarod.LarodModel, error) {
+	resizeWidth, resizeHeight := lea.projection.ResizeResolution()
+	cropMap, err := axlarod.CreateCropMap(resizeWidth, resizeHeight, lea.streamWidth, lea.streamHeight)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if lea.PPModel, err = lea.app.Larod.NewPreProccessModel(
+	ppModel, err := lea.app.Larod.NewPreProccessModel(
 		"axis-a8-gpu-proc",
 		axlarod.LarodResolution{Width: lea.streamWidth, Height: lea.streamHeight},
-		axlarod.LarodResolution{Width: lea.mobileNetFaceInputWidth, Height: lea.mobileNetFaceInputHeight},
+		axlarod.LarodResolution{Width: resizeWidth, Height: resizeHeight},
 		rgbMode,
 		cropMap,
-	); err != nil {
-		return err
+	)
+	if err != nil {
+		return nil, err
 	}
-	lea.app.AddModelCleaner(lea.PPModel)
-	return err
+	lea.app.AddModelCleaner(ppModel)
+	return ppModel, nil
 }
 
-// PreProcess handles the preprocessing of video frames using the initialized preprocessing model.
-// It manages the flow of data into the model and retrieves the processed output.
+// PreProcess handles the preprocessing of video frames using the preprocessing model of the lane.
+// It manages the flow of data into the model and retrieves a copy of the processed output.
 // Returns a JobResult containing the processed data or an error if preprocessing fails.
-func (lea *larodExampleApplication) PreProcess(frame *axvdo.VideoFrame) (*axlarod.JobResult, error) {
-	var result *axlarod.JobResult
-	if result, err = lea.app.Larod.ExecuteJob(lea.PPModel, func() error {
-		return lea.PPModel.Inputs[0].CopyDataInto(frame.Data)
+func (lea *larodExampleApplication) PreProcess(lane *inferLane, frame *axvdo.VideoFrame) (*axlarod.JobResult, error) {
+	return lea.app.Larod.ExecuteJob(lane.PPModel, func() error {
+		return lane.PPModel.Inputs[0].CopyDataInto(frame.Data)
 	}, func() (any, error) {
-		return lea.PPModel.Outputs[0].GetData(lea.streamWidth * lea.streamHeight * 3)
-	}); err != nil {
-		return nil, err
-	}
-	return result, nil
+		resizeWidth, resizeHeight := lea.projection.ResizeResolution()
+		return lane.PPModel.Outputs[0].GetData(resizeWidth * resizeHeight * 3)
+	})
 }
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"time"
+
 	"github.com/Cacsjep/goxis/pkg/acapapp"
-	"github.com/Cacsjep/goxis/pkg/axlarod"
-	"github.com/Cacsjep/goxis/pkg/axoverlay"
 	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/axlarod/object_detection/detect"
+	"github.com/Cacsjep/goxis_examples/pkg/metrics"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/replay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
 )
 
 var (
@@ -28,6 +36,17 @@ func main() {
 	// Defer the cleanup of the application to ensure all resources are released when the application exits in the below for loop.
 	defer lea.app.Close()
 
+	// Stop the pipeline before the models are destroyed, queued frames are still processed.
+	lea.pipeline.Start()
+	defer lea.pipeline.Stop()
+
+	// Replay the recorded frames instead of the camera stream
+	if lea.replay != nil {
+		lea.Replay()
+		return
+	}
+
+	// Capture: the frames are handed to the pipeline, which drops frames if the inference is behind.
 	for {
 		select {
 		case frame := <-lea.app.FrameProvider.FrameStreamChannel:
@@ -35,37 +54,10 @@ func main() {
 				lea.app.Syslog.Errorf("Unexpected Vdo Error: %s", frame.Error.Error())
 				continue
 			}
-
-			// Execute the prepossessing model job
-			if lea.pp_result, err = lea.PreProcess(frame); err != nil {
-				lea.app.Syslog.Errorf("Failed to execute PPModel: %s", err.Error())
-				return
-			}
-
-			// Execute the detection model job
-			if lea.infer_result, err = lea.Inference(); err != nil {
-				lea.app.Syslog.Errorf("Failed to execute Detection Model: %s", err.Error())
-				return
-			}
-
-			// Retrieve the prediction result
-			if lea.prediction_result, err = lea.InferenceOutputRead(lea.infer_result.OutputData.(*CocoResult)); err != nil {
-				lea.app.Syslog.Errorf("Failed to convert prediction result: %s", err.Error())
-				return
-			}
-
-			// Draw overlay
-			if err = lea.overlayProvider.Redraw(); err != nil {
-				lea.app.Syslog.Errorf("Failed to redraw overlay: %s", err.Error())
+			if age := time.Since(frame.Timestamp); age >= 0 && !frame.Timestamp.IsZero() {
+				lea.metrics.Capture.Observe(age)
 			}
-
-			lea.app.Syslog.Infof("Frame: %d, PP time: %.fms, Infer. exec time: %.fms, Detections: %d",
-				frame.SequenceNbr,
-				lea.pp_result.ExecutionTime,
-				lea.infer_result.ExecutionTime,
-				len(lea.detections),
-			)
-
+			lea.pipeline.Push(&frameJob{frame: frame})
 		}
 	}
 }
@@ -73,21 +65,29 @@ func main() {
 // larodExampleApplication struct defines the structure for this example.
 // It includes configuration for application, models, video stream, and other operational parameters.
 type larodExampleApplication struct {
-	app               *acapapp.AcapApplication       // app represents the acap application
-	PPModel           *axlarod.LarodModel            // PPModel is the preprocessing model.
-	DetectionModel    *axlarod.LarodModel            // DetectionModel is the model used for detecting objects in video frames.
-	streamWidth       int                            // streamWidth specifies the width of the video stream.
-	streamHeight      int                            // streamHeight specifies the height of the video stream.
-	cocoInputWidth    int                            // cocoInputWidth specifies the width of the input tensor for the detection model.
-	cocoInputHeight   int                            // cocoInptHeight specifies the height of the input tensor for the detection model.
-	fps               int                            // fps represents the frame rate of the video stream.
-	sconfig           *axvdo.VideoSteamConfiguration // sconfig holds the configuration for the video stream.
-	pp_result         *axlarod.JobResult             // pp_result holds the result of the preprocessing model job.
-	infer_result      *axlarod.JobResult             // infer_result holds the result of the detection model job.
-	prediction_result *PredictionResult              // prediction_result stores the output of the inference process.
-	threshold         float32                        // threshold is the minimum score required for an object to be considered detected.
-	overlayProvider   *axoverlay.OverlayProvider     // overlayProvider is used to draw overlay on the video stream.
-	detections        []Detection                    // detections stores the detected objects.
+	app                 *acapapp.AcapApplication                   // app represents the acap application
+	postProcessorName   string                                     // postProcessorName selects the decoding of the model outputs, see postprocess.Names.
+	detector            *detect.Processor                          // detector decodes, tracks and builds the overlay of the frames without cgo.
+	pipeline            *pipeline.Inference[*frameJob, *inferLane] // pipeline runs the stages from capture to publish.
+	dropPolicy          pipeline.DropPolicy                        // dropPolicy decides which frames are dropped when the inference is behind.
+	metrics             *metrics.Pipeline                          // metrics holds the latency histograms and frame counters served on /metrics.
+	replay              *replay.Source                             // replay replays recorded frames instead of the camera stream, nil for the camera stream.
+	replayOutputs       replay.Backend                             // replayOutputs returns the recorded model outputs of the replayed frames.
+	recorder            *replay.Recorder                           // recorder records frames and model outputs for a replay, nil if disabled.
+	streamWidth         int                                        // streamWidth specifies the width of the video stream.
+	streamHeight        int                                        // streamHeight specifies the height of the video stream.
+	cocoInputWidth      int                                        // cocoInputWidth specifies the width of the input tensor for the detection model.
+	cocoInputHeight     int                                        // cocoInptHeight specifies the height of the input tensor for the detection model.
+	fps                 int                                        // fps represents the frame rate of the video stream.
+	sconfig             *axvdo.VideoSteamConfiguration             // sconfig holds the configuration for the video stream.
+	threshold           float32                                    // threshold is the minimum score required for an object to be considered detected.
+	overlay             *overlay.Overlay                           // overlay draws the scene on the video streams.
+	scene               *scene.Scene                               // scene holds the zones, counting lines and tracked objects drawn by the overlay.
+	lineCrossingEvent   *acapapp.CameraPlatformEvent               // lineCrossingEvent is the declaration of the line crossing event.
+	lineCrossingEventId int                                        // lineCrossingEventId is the declaration id of the line crossing event.
+	zoneLoitering       map[string]bool                            // zoneLoitering holds the last sent loitering state per zone.
+	loiteringEvent      *acapapp.CameraPlatformEvent               // loiteringEvent is the declaration of the loitering event.
+	loiteringEventId    int                                        // loiteringEventId is the declaration id of the loitering event.
 }
 
 // Initialize prepares and initializes all necessary components for the application.
@@ -95,24 +95,38 @@ type larodExampleApplication struct {
 // Returns a configured instance of larodExampleApplication or an error if initialization fails.
 func Initalize() (*larodExampleApplication, error) {
 
-	lea := &larodExampleApplication{fps: 12, threshold: 0.4, cocoInputWidth: 300, cocoInputHeight: 300, detections: []Detection{}}
+	lea := &larodExampleApplication{fps: 12, threshold: 0.4, cocoInputWidth: 300, cocoInputHeight: 300, postProcessorName: postprocess.SSD_POSTPROCESS, dropPolicy: pipeline.DropOldest}
+	lea.detector = detect.NewProcessor(tracker.NewSORT[postprocess.Detection](5, 2, lea.threshold, 0.3), coco_labels)
 
 	// Initialize a new ACAP application instance.
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	lea.app = acapapp.NewAcapApplication()
 
+	// Initialize the counting lines and the line crossing event
+	if err = lea.InitLineCounting(); err != nil {
+		return nil, err
+	}
+
+	// Initialize the zones and the loitering event
+	if err = lea.InitZones(); err != nil {
+		return nil, err
+	}
+
 	// Determine the stream resolution
 	if err := lea.SetupStreamResolution(); err != nil {
 		return nil, err
 	}
 
-	// Initialize/Connecting Larod
-	if err = lea.app.InitalizeLarod(); err != nil {
+	// Replay recorded frames if the replay directory exists, or record frames for a replay
+	if err = lea.InitReplay(); err != nil {
+		return nil, err
+	}
+	if err = lea.InitRecorder(); err != nil {
 		return nil, err
 	}
 
-	// Initialize the preprocessing model
-	if err = lea.InitalizePPModel(axlarod.PreProccessOutputFormatRgbInterleaved); err != nil {
+	// Initialize/Connecting Larod
+	if err = lea.app.InitalizeLarod(); err != nil {
 		return nil, err
 	}
 
@@ -121,17 +135,24 @@ func Initalize() (*larodExampleApplication, error) {
 		lea.app.Syslog.Infof("Device: %s", d.Name)
 	}
 
-	// Initialize the detection model
-	if err = lea.InitalizeDetectionModel("ssd_mobilenet_v2_coco_quant_postprocess.tflite", "axis-a8-dlpu-tflite"); err != nil {
+	// Initialize the preprocessing and detection models of the pipeline
+	if err = lea.InitalizePipeline("ssd_mobilenet_v2_coco_quant_postprocess.tflite", "axis-a8-dlpu-tflite"); err != nil {
 		return nil, err
 	}
 
-	// Initialize and start the video stream
-	if err = lea.InitalizeAndStartVdo(); err != nil {
+	// Serve the pipeline metrics for Prometheus
+	if err = lea.InitMetrics(); err != nil {
 		return nil, err
 	}
 
-	// Initialize the overlay provider
+	// Initialize and start the video stream, unless frames are replayed
+	if lea.replay == nil {
+		if err = lea.InitalizeAndStartVdo(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialize the overlay
 	if err = lea.InitOverlay(); err != nil {
 		return nil, err
 	}
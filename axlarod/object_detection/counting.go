@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/Cacsjep/goxis/pkg/acapapp"
+	"github.com/Cacsjep/goxis/pkg/axevent"
+	"github.com/Cacsjep/goxis/pkg/utils"
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+)
+
+// COCO_PERSON_CLASS is the class index of persons in the coco labels.
+const COCO_PERSON_CLASS = 0
+
+var (
+	LINE_DEAD_BAND float32 = 0.02 // Distance a person has to move past a line before it is counted, filters jitter on the line
+)
+
+// InitLineCounting sets up the virtual counting lines and declares the line crossing event.
+// The line crossing event is stateless, it is sent once for each crossing.
+func (lea *larodExampleApplication) InitLineCounting() error {
+	// Vertical line in the middle of the stream, persons walking from right to left are counted as in.
+	// Lines are in normalized stream coordinates, the tracked boxes are mapped into the stream before counting,
+	// so the line is counted and drawn where it is configured, also outside of the center crop of the model.
+	lea.detector.Lines = analytics.NewLineCounter(
+		&analytics.CountingLine{
+			Name:     "center",
+			A:        analytics.Point{X: 0.5, Y: 0},
+			B:        analytics.Point{X: 0.5, Y: 1},
+			Classes:  []int{COCO_PERSON_CLASS},
+			DeadBand: LINE_DEAD_BAND,
+		},
+	)
+
+	lea.lineCrossingEvent = &acapapp.CameraPlatformEvent{
+		Name:     "linecrossing",
+		NiceName: utils.StrPtr("Line Crossing"),
+		Entries: []*acapapp.EventEntry{
+			{Key: "line", ValueType: axevent.AXValueTypeString, IsSource: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Line")},
+			{Key: "direction", ValueType: axevent.AXValueTypeString, IsData: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Direction")},
+			{Key: "track_id", ValueType: axevent.AXValueTypeInt, KeyNiceName: utils.StrPtr("Track ID")},
+			{Key: "in", ValueType: axevent.AXValueTypeInt, KeyNiceName: utils.StrPtr("In Count")},
+			{Key: "out", ValueType: axevent.AXValueTypeInt, KeyNiceName: utils.StrPtr("Out Count")},
+		},
+		Stateless: true,
+	}
+	if lea.lineCrossingEventId, err = lea.app.AddCameraPlatformEvent(lea.lineCrossingEvent); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sendLineCrossings logs the line crossings of a frame and sends an event for each crossing.
+func (lea *larodExampleApplication) sendLineCrossings(crossings []analytics.LineCrossing) {
+	for _, crossing := range crossings {
+		lea.app.Syslog.Infof("ID-%d crossed line %s (%s), in: %d, out: %d", crossing.TrackID, crossing.Line.Name, crossing.Direction, crossing.Line.In, crossing.Line.Out)
+		if err := lea.app.SendPlatformEvent(lea.lineCrossingEventId, func() (*axevent.AXEvent, error) {
+			return lea.lineCrossingEvent.NewEvent(acapapp.KeyValueMap{
+				"line":      crossing.Line.Name,
+				"direction": crossing.Direction.String(),
+				"track_id":  crossing.TrackID,
+				"in":        crossing.Line.In,
+				"out":       crossing.Line.Out,
+			})
+		}); err != nil {
+			lea.app.Syslog.Errorf("Error sending line crossing event: %s", err.Error())
+		}
+	}
+}
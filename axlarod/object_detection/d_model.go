@@ -2,124 +2,65 @@ package main
 
 import (
 	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/larodmodel"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
 )
 
-var (
-	FLOAT_SIZE    = uint(4)
-	TENSOR_1_SIZE = 80 * FLOAT_SIZE
-	TENSOR_2_SIZE = 20 * FLOAT_SIZE
-	TENSOR_3_SIZE = 20 * FLOAT_SIZE
-	TENSOR_4_SIZE = 1 * FLOAT_SIZE
-	BBOX_SIZE     = 4
-)
-
-// PredictionResult holds the probabilities of detecting specific objects (e.g., persons, cars)
-type PredictionResult struct {
-	Detections []Detection
-}
+// SSD_INPUT_TYPES are the input types of the quantized ssd models.
+var SSD_INPUT_TYPES = []tflite.TensorType{tflite.Uint8}
 
-// InitializeDetectionModel configures a detection model with the given model file and hardware chip.
-// It sets up memory-mapped file configurations for input and output tensors.
-// Returns an error if model initialization fails.
-func (lea *larodExampleApplication) InitalizeDetectionModel(modelFilePath string, chipString string) error {
-	model_defs := axlarod.MemMapConfiguration{
-		InputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: lea.PPModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
-		},
-		OutputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: {Size: TENSOR_1_SIZE}, // Output Tensor 1
-			1: {Size: TENSOR_2_SIZE}, // Output Tensor 2
-			2: {Size: TENSOR_3_SIZE}, // Output Tensor 3
-			3: {Size: TENSOR_4_SIZE}, // Output Tensor 4
-		},
+// InitializeDetectionModel configures a detection model with the given model file and hardware chip,
+// the output of the preprocessing model is used as its input.
+// The memory mapped tensors are sized from the tflite metadata, and a model with unexpected tensors fails here.
+// Returns the model with its tflite metadata or an error if model initialization fails.
+func (lea *larodExampleApplication) InitalizeDetectionModel(ppModel *axlarod.LarodModel, modelFilePath string, chipString string) (*axlarod.LarodModel, *tflite.Model, error) {
+	inputs := map[int]*axlarod.MemMapFile{
+		0: ppModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
 	}
-
-	if lea.DetectionModel, err = lea.app.Larod.NewInferModel(modelFilePath, chipString, model_defs, nil); err != nil {
-		return err
+	detectionModel, meta, err := larodmodel.NewInferModel(lea.app.Larod, modelFilePath, chipString, inputs, larodmodel.Expectation{
+		Inputs: []tflite.TensorSpec{{Types: SSD_INPUT_TYPES, Shape: []int{1, lea.cocoInputHeight, lea.cocoInputWidth, 3}}},
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	lea.app.AddModelCleaner(lea.DetectionModel)
-	return nil
+	lea.app.AddModelCleaner(detectionModel)
+	return detectionModel, meta, nil
 }
 
-// getDResult retrieves the detection results from the model's output tensors.
-// Returns the raw byte output and an error if retrieval fails.
-func (lea *larodExampleApplication) getDResult() (*CocoResult, error) {
-	t1, err := lea.DetectionModel.Outputs[0].GetDataAsFloat32Slice(int(TENSOR_1_SIZE))
-	if err != nil {
-		return nil, err
-	}
-	t2, err := lea.DetectionModel.Outputs[1].GetDataAsFloat32Slice(int(TENSOR_2_SIZE))
-	if err != nil {
-		return nil, err
-	}
-	t3, err := lea.DetectionModel.Outputs[2].GetDataAsFloat32Slice(int(TENSOR_3_SIZE))
-	if err != nil {
-		return nil, err
-	}
-	t4, err := lea.DetectionModel.Outputs[3].GetDataAsFloat32()
-	if err != nil {
-		return nil, err
+// InitalizePostProcessor creates the post processor registered under lea.postProcessorName for the model outputs.
+// Returns an error if the outputs do not fit the post processor.
+func (lea *larodExampleApplication) InitalizePostProcessor(meta *tflite.Model) error {
+	if lea.detector.PostProcessor, err = postprocess.New(lea.postProcessorName, postprocess.Config{
+		Outputs:   meta.Outputs,
+		Threshold: lea.threshold,
+	}); err != nil {
+		return err
 	}
-	return &CocoResult{locations: t1, classes: t2, scores: t3, detections: int(t4)}, nil
+	lea.app.Syslog.Infof("Detection model post processing: %s", lea.detector.PostProcessor)
+	return nil
 }
 
-// Inference executes the model and retrieves the processed results.
+// Inference executes the detection model of the lane, the outputs are decoded later by the decode stage.
 // It ensures the model's file pointers are correctly positioned before execution.
-// Returns a JobResult containing the inference results or an error if the inference process fails.
-func (lea *larodExampleApplication) Inference() (*axlarod.JobResult, error) {
+// Returns a JobResult with the execution time or an error if the inference process fails.
+func (lea *larodExampleApplication) Inference(lane *inferLane) (*axlarod.JobResult, error) {
 
 	// Rewind all output files position before each job.
-	if err = lea.DetectionModel.RewindAllOutputsMemMapFiles(); err != nil {
+	if err := lane.DetectionModel.RewindAllOutputsMemMapFiles(); err != nil {
 		return nil, err
 	}
 
-	var result *axlarod.JobResult
-	if result, err = lea.app.Larod.ExecuteJob(lea.DetectionModel, func() error {
+	return lea.app.Larod.ExecuteJob(lane.DetectionModel, func() error {
 		return nil // is feeded via memmap
 	}, func() (any, error) {
-		return lea.getDResult()
-	}); err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-type CocoResult struct {
-	locations  []float32
-	classes    []float32
-	scores     []float32
-	detections int
+		return nil, nil // is read via memmap by Decode
+	})
 }
 
-type Detection struct {
-	Score, Class float32
-	Box          axlarod.BoundingBox
-}
-
-// InferenceOutputRead converts raw model output data into structured prediction results.
-// Returns a PredictionResult or an error if data conversion fails.
-//
-// https://github.com/AxisCommunications/acap-native-sdk-examples/blob/7bff215e7673e4a72630bb89f04c2f7b64cf319c/object-detection/app/object_detection.c#L942
-func (lea *larodExampleApplication) InferenceOutputRead(result *CocoResult) (*PredictionResult, error) {
-	var detections []Detection
-	for i := 0; i < int(result.detections); i++ {
-		score := result.scores[i]
-		if score >= lea.threshold {
-			if BBOX_SIZE*i+3 < len(result.locations) { // Ensure we do not go out of index bounds
-				detections = append(detections, Detection{
-					Box: axlarod.BoundingBox{
-						Top:    result.locations[BBOX_SIZE*i],
-						Left:   result.locations[BBOX_SIZE*i+1],
-						Bottom: result.locations[BBOX_SIZE*i+2],
-						Right:  result.locations[BBOX_SIZE*i+3],
-					},
-					Score: score,
-					Class: result.classes[i],
-				})
-			}
-		}
-	}
-	lea.detections = detections
-	return &PredictionResult{Detections: detections}, nil
+// LaneOutputs returns the memory mapped output tensors of the lane, they are not copied.
+func (lea *larodExampleApplication) LaneOutputs(lane *inferLane) ([][]byte, error) {
+	var err error
+	lane.outputs, err = larodmodel.Outputs(lane.DetectionModel, lane.outputs)
+	return lane.outputs, err
 }
@@ -0,0 +1,207 @@
+// Package detect is the cgo free part of the object detection example.
+//
+// A Processor decodes the model outputs of a frame, maps the boxes back into normalized stream coordinates,
+// tracks the detections, updates the counting lines and zones and builds the overlay scene node of the frame. The example runs it in its pipeline stages and sends the
+// events, and a go test drives it with replayed frames and recorded model outputs without camera, larod and axoverlay.
+package detect
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+var (
+	BOX_COLOR       = color.RGBA{R: 33, G: 150, B: 243, A: 255}  // Color of the tracked objects, axoverlay.ColorMaterialBlue
+	LINE_COLOR      = color.RGBA{R: 255, G: 193, B: 7, A: 255}   // Color of the counting lines, axoverlay.ColorMaterialAmber
+	ZONE_COLOR      = color.RGBA{R: 76, G: 175, B: 80, A: 255}   // Color of the zones, axoverlay.ColorMaterialGreen
+	LOITERING_COLOR = color.RGBA{R: 244, G: 67, B: 54, A: 255}   // Color of zones with a loitering object, axoverlay.ColorMaterialRed
+	LABEL_COLOR     = color.RGBA{R: 255, G: 255, B: 255, A: 255} // Text color of the box labels, axoverlay.ColorWite
+	ZONE_FILL_ALPHA = uint8(70)                                  // Alpha of the translucent zone fill
+)
+
+// Frame is the result of a processed frame.
+// Fields:
+//   - Result: The decoded detections in normalized stream coordinates.
+//   - Tracked: The tracked objects, including coasting tracks without a detection in this frame.
+//   - Crossings: The line crossings of this frame.
+//   - ZoneEvents: The zone changes of this frame, including the exits of removed tracks.
+//   - Node: The zones, counting lines and tracked objects of the frame for the overlay scene.
+type Frame struct {
+	Result     postprocess.Result
+	Tracked    []tracker.TrackedObject[postprocess.Detection]
+	Crossings  []analytics.LineCrossing
+	ZoneEvents []analytics.ZoneEvent
+	Node       scene.Node
+}
+
+// Processor turns the model outputs of a frame into tracked objects, analytics and the overlay node.
+// Fields:
+//   - PostProcessor: Decodes the model outputs.
+//   - Tracker: Tracks the detections across frames, per class.
+//   - Lines: Counts the tracked objects crossing the lines, the lines are in normalized stream coordinates.
+//   - Zones: Keeps track of the objects inside the zones, the zones are in normalized stream coordinates.
+//   - Projection: Maps the boxes of the model input back into the stream before tracking.
+//   - Labels: Class names shown in the box labels.
+//
+// A Processor is not safe for concurrent use, Decode and Track may run in different goroutines one after another.
+type Processor struct {
+	PostProcessor postprocess.PostProcessor
+	Tracker       *tracker.SORT[postprocess.Detection]
+	Lines         *analytics.LineCounter
+	Zones         *analytics.ZoneMonitor
+	Projection    projection.Projection
+	Labels        postprocess.Labels
+	now           time.Time             // now is the time of the frame in Track, used for removed tracks
+	removed       []analytics.ZoneEvent // removed holds the zone exits of tracks removed during the tracker update
+}
+
+// NewProcessor creates a processor with empty lines and zones, tracks removed by the tracker are removed from the analytics.
+func NewProcessor(sortTracker *tracker.SORT[postprocess.Detection], labels postprocess.Labels) *Processor {
+	p := &Processor{Tracker: sortTracker, Labels: labels, Lines: analytics.NewLineCounter(), Zones: analytics.NewZoneMonitor()}
+	sortTracker.OnTrackStateChange = p.onTrackStateChange
+	return p
+}
+
+// onTrackStateChange removes tracks which are removed from the tracker from the analytics.
+func (p *Processor) onTrackStateChange(track *tracker.Track, previous tracker.TrackState) {
+	if track.State != tracker.TrackRemoved || previous == tracker.TrackTentative {
+		return
+	}
+	p.Lines.Remove(track.ID)
+	p.removed = append(p.removed, p.Zones.Remove(track.ID, p.now)...)
+}
+
+// Decode decodes the output tensors, the memory mapped tensors of a lane or recorded outputs.
+// The boxes are mapped back into normalized stream coordinates, so tracking, analytics and the overlay
+// use the coordinates the lines and zones are configured in.
+func (p *Processor) Decode(outputs [][]byte) (postprocess.Result, error) {
+	result, err := p.PostProcessor.Process(outputs)
+	if err != nil {
+		return result, err
+	}
+	for i := range result.Detections {
+		result.Detections[i].Box = p.Projection.ToStream(result.Detections[i].Box)
+	}
+	return result, nil
+}
+
+// Track tracks the decoded detections of a frame, updates the lines and zones and builds the overlay node.
+// Coasting tracks are not checked against the lines and zones, they are checked once they are observed again.
+func (p *Processor) Track(result postprocess.Result, now time.Time) *Frame {
+	p.now, p.removed = now, p.removed[:0]
+	tracked := p.Tracker.Update(result.Detections)
+
+	points := make([]analytics.TrackPoint, 0, len(tracked))
+	for _, obj := range tracked {
+		if obj.Coasting {
+			continue
+		}
+		x, y := obj.Box.Center()
+		points = append(points, analytics.TrackPoint{ID: obj.ID, Class: obj.Class, X: x, Y: y, Time: now})
+	}
+	frame := &Frame{
+		Result:     result,
+		Tracked:    tracked,
+		Crossings:  p.Lines.Update(points),
+		ZoneEvents: append(append([]analytics.ZoneEvent(nil), p.removed...), p.Zones.Update(points)...),
+	}
+	frame.Node = p.node(frame)
+	return frame
+}
+
+// Process decodes and tracks the outputs of a frame, e.g. for a replayed frame.
+func (p *Processor) Process(outputs [][]byte, now time.Time) (*Frame, error) {
+	result, err := p.Decode(outputs)
+	if err != nil {
+		return nil, err
+	}
+	return p.Track(result, now), nil
+}
+
+// node builds the zones, counting lines and tracked objects of a frame.
+// It is built with the analytics state of the frame, so the next frame can already update the analytics
+// while this frame is published and drawn.
+func (p *Processor) node(frame *Frame) scene.Node {
+	zones := scene.Group{}
+	for _, zone := range p.Zones.Zones {
+		zones.Nodes = append(zones.Nodes, p.zoneNode(zone))
+	}
+
+	lines := scene.Group{}
+	for _, line := range p.Lines.Lines {
+		lines.Nodes = append(lines.Nodes, p.countingLineNode(line))
+	}
+
+	objects := scene.Group{}
+	for _, obj := range frame.Tracked {
+		// Coasting tracks have no detection in this frame
+		if obj.Coasting {
+			continue
+		}
+		pos, size := boxToScene(obj.Box)
+		objects.Nodes = append(objects.Nodes, scene.Box(
+			pos,
+			size,
+			BOX_COLOR,
+			fmt.Sprintf("ID-%d %s %d%%", obj.ID, strings.ToUpper(p.Labels.Name(obj.Class)), int(obj.Detection.Score*100)),
+			scene.TextStyle{Size: 17, Font: "sans", Color: LABEL_COLOR, Padding: 3},
+		))
+	}
+	return scene.Group{Nodes: []scene.Node{zones, lines, objects}}
+}
+
+// countingLineNode returns a virtual counting line with its name and in/out counters at the start point.
+func (p *Processor) countingLineNode(line *analytics.CountingLine) scene.Node {
+	a, b := pointToScene(line.A), pointToScene(line.B)
+	return scene.Group{Nodes: []scene.Node{
+		scene.Polyline{Points: []scene.Point{a, b}, Style: scene.Style{Stroke: LINE_COLOR, LineWidth: 4}},
+		scene.Text{
+			Pos:       a,
+			Text:      fmt.Sprintf("%s in: %d out: %d", line.Name, line.In, line.Out),
+			TextStyle: scene.TextStyle{Size: 24, Font: "sans", Color: LINE_COLOR, Padding: 10},
+		},
+	}}
+}
+
+// zoneNode returns a zone as translucent polygon with its name and occupancy.
+// Zones with a loitering object are drawn in LOITERING_COLOR.
+func (p *Processor) zoneNode(zone *analytics.Zone) scene.Node {
+	zoneColor := ZONE_COLOR
+	if zone.Loitering() {
+		zoneColor = LOITERING_COLOR
+	}
+	fillColor := zoneColor
+	fillColor.A = ZONE_FILL_ALPHA
+
+	points := make([]scene.Point, len(zone.Polygon))
+	for i, pt := range zone.Polygon {
+		points[i] = pointToScene(pt)
+	}
+	nodes := []scene.Node{scene.Polygon{Points: points, Style: scene.Style{Stroke: zoneColor, Fill: fillColor, LineWidth: 2}}}
+	if len(points) > 0 {
+		nodes = append(nodes, scene.Text{
+			Pos:       points[0],
+			Text:      fmt.Sprintf("%s: %d", zone.Name, zone.Occupancy()),
+			TextStyle: scene.TextStyle{Size: 24, Font: "sans", Color: zoneColor, Padding: 10},
+		})
+	}
+	return scene.Group{Nodes: nodes}
+}
+
+// boxToScene returns the position and size of a box in stream coordinates for the scene.
+func boxToScene(b tracker.Box) (scene.Point, scene.Point) {
+	return scene.Point{X: float64(b.Left), Y: float64(b.Top)}, scene.Point{X: float64(b.Right - b.Left), Y: float64(b.Bottom - b.Top)}
+}
+
+// pointToScene converts a point of the analytics for the scene.
+func pointToScene(pt analytics.Point) scene.Point {
+	return scene.Point{X: float64(pt.X), Y: float64(pt.Y)}
+}
@@ -0,0 +1,168 @@
+package detect
+
+import (
+	"flag"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/raster"
+	"github.com/Cacsjep/goxis_examples/pkg/replay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+const (
+	streamWidth  = 320
+	streamHeight = 180
+	modelSize    = 300
+)
+
+// ssdOutputs are the float outputs of the recorded SSD model with 10 boxes.
+var ssdOutputs = []tflite.Tensor{
+	{Name: "boxes", Type: tflite.Float32, Shape: []int{1, 10, 4}},
+	{Name: "classes", Type: tflite.Float32, Shape: []int{1, 10}},
+	{Name: "scores", Type: tflite.Float32, Shape: []int{1, 10}},
+	{Name: "count", Type: tflite.Float32, Shape: []int{1}},
+}
+
+// newProcessor creates a processor like the example, with a shorter loitering threshold.
+//
+// The recording in testdata/replay has 10 frames, one per second, the model sees the 180x180 center crop.
+// A person walks from the right to the left through the center line into the entrance zone, a car stands still
+// in the upper left and a bicycle is detected below the threshold. The person reaches the zone, which is in
+// stream coordinates, only in frame 8, as the center crop starts at x 0.22 of the stream.
+func newProcessor(t *testing.T) *Processor {
+	t.Helper()
+	post, err := postprocess.New(postprocess.SSD_POSTPROCESS, postprocess.Config{Outputs: ssdOutputs, Threshold: 0.4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProcessor(tracker.NewSORT[postprocess.Detection](5, 2, 0.4, 0.3), postprocess.Labels{"person", "bicycle", "car"})
+	p.PostProcessor = post
+	p.Projection = projection.New(projection.CenterCrop, streamWidth, streamHeight, modelSize, modelSize)
+	p.Lines = analytics.NewLineCounter(&analytics.CountingLine{
+		Name:     "center",
+		A:        analytics.Point{X: 0.5, Y: 0},
+		B:        analytics.Point{X: 0.5, Y: 1},
+		Classes:  []int{0},
+		DeadBand: 0.02,
+	})
+	p.Zones = analytics.NewZoneMonitor(&analytics.Zone{
+		Name:            "entrance",
+		Polygon:         []analytics.Point{{X: 0.05, Y: 0.5}, {X: 0.45, Y: 0.5}, {X: 0.45, Y: 0.95}, {X: 0.05, Y: 0.95}},
+		Classes:         []int{0},
+		LoiterThreshold: time.Second,
+	})
+	return p
+}
+
+func TestReplay(t *testing.T) {
+	src, err := replay.NewSource("testdata/replay", replay.Config{Width: streamWidth, Height: streamHeight, Format: replay.RGB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newProcessor(t)
+	s := scene.New()
+	s.Reference = scene.DEFAULT_REFERENCE
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		frames    int
+		crossings []analytics.LineCrossing
+		events    []analytics.ZoneEvent
+		persons   = map[int]bool{}
+	)
+	if err := replay.Run(src, replay.Recorded{Dir: "testdata/replay"}, func(f *replay.Frame, outputs [][]byte) error {
+		if len(f.Data) != replay.RGB.Size(streamWidth, streamHeight) {
+			t.Fatalf("frame %s has %d bytes", f.Name, len(f.Data))
+		}
+		frame, err := p.Process(outputs, start.Add(time.Duration(f.SequenceNbr)*time.Second))
+		if err != nil {
+			return err
+		}
+		for _, det := range frame.Result.Detections {
+			if det.Class == 1 {
+				t.Errorf("frame %s: detection below the threshold %+v", f.Name, det)
+			}
+			// The boxes are in the stream, inside the center crop
+			if det.Box.Left < 70.0/streamWidth || det.Box.Right > 250.0/streamWidth {
+				t.Errorf("frame %s: box %+v is not in stream coordinates", f.Name, det.Box)
+			}
+		}
+		for _, obj := range frame.Tracked {
+			if obj.Class == 0 {
+				persons[obj.ID] = true
+			}
+		}
+		crossings = append(crossings, frame.Crossings...)
+		events = append(events, frame.ZoneEvents...)
+		s.Set("objects", frame.Node)
+		frames++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if frames != 10 {
+		t.Fatalf("replayed %d frames, want 10", frames)
+	}
+	if len(persons) != 1 {
+		t.Errorf("person tracked with %d IDs, want 1", len(persons))
+	}
+	if len(crossings) != 1 || crossings[0].Direction != analytics.DirectionIn || !persons[crossings[0].TrackID] {
+		t.Errorf("got crossings %+v, want one in crossing of the person", crossings)
+	}
+	if line := p.Lines.Lines[0]; line.In != 1 || line.Out != 0 {
+		t.Errorf("line counted in: %d out: %d, want in: 1 out: 0", line.In, line.Out)
+	}
+	var types []analytics.ZoneEventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if len(types) != 2 || types[0] != analytics.ZoneEnter || types[1] != analytics.ZoneLoiterStart {
+		t.Errorf("got zone events %v, want enter and loiter start", types)
+	}
+	if zone := p.Zones.Zones[0]; zone.Occupancy() != 1 || !zone.Loitering() {
+		t.Errorf("zone occupancy %d, loitering %t, want 1 and loitering", zone.Occupancy(), zone.Loitering())
+	}
+
+	// The overlay of the last frame with the loitering zone, the counted line and both tracked objects
+	canvas := raster.New(streamWidth, streamHeight)
+	if err := s.Render(canvas, streamWidth, streamHeight); err != nil {
+		t.Fatal(err)
+	}
+	if err := canvas.MatchGolden("testdata/replay.png", 1, *update); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOverlayCoordinates(t *testing.T) {
+	p := newProcessor(t)
+	frame := p.Track(postprocess.Result{}, time.Now())
+
+	// The lines are drawn in the stream coordinates they are configured in, not in the center crop
+	lines := frame.Node.(scene.Group).Nodes[1].(scene.Group).Nodes
+	line := lines[0].(scene.Group).Nodes[0].(scene.Polyline)
+	want := []scene.Point{{X: 0.5, Y: 0}, {X: 0.5, Y: 1}}
+	if len(lines) != 1 || !slices.Equal(line.Points, want) {
+		t.Errorf("got line %v, want %v", line.Points, want)
+	}
+
+	// The same for the zones, x 0.05 of the stream is outside of the center crop
+	zones := frame.Node.(scene.Group).Nodes[0].(scene.Group).Nodes
+	zone := zones[0].(scene.Group).Nodes[0].(scene.Polygon)
+	want = []scene.Point{{X: 0.05, Y: 0.5}, {X: 0.45, Y: 0.5}, {X: 0.45, Y: 0.95}, {X: 0.05, Y: 0.95}}
+	if len(zones) != 1 || !slices.EqualFunc(zone.Points, want, func(a, b scene.Point) bool {
+		return math.Abs(a.X-b.X) < 1e-6 && math.Abs(a.Y-b.Y) < 1e-6
+	}) {
+		t.Errorf("got zone %v, want %v", zone.Points, want)
+	}
+}
@@ -1,44 +1,24 @@
 package main
 
 import (
-	"fmt"
-	"strings"
-
-	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
-// Initialize the overlay provider
+// Initialize the overlay, it redraws whenever the publish stage changes the scene
 func (lea *larodExampleApplication) InitOverlay() error {
-	if lea.overlayProvider, err = axoverlay.NewOverlayProvider(renderCallback, nil, nil); err != nil {
+	// The model sees the center crop of the stream, the boxes are mapped back into the stream before tracking
+	lea.detector.Projection = projection.New(projection.CenterCrop, lea.streamWidth, lea.streamHeight, lea.cocoInputWidth, lea.cocoInputHeight)
+	lea.scene = scene.New()
+	lea.scene.Reference = scene.DEFAULT_REFERENCE
+	if lea.overlay, err = overlay.New(lea.scene); err != nil {
 		return err
 	}
-	lea.app.AddCloseCleanFunc(lea.overlayProvider.Cleanup)
-	if _, err = lea.overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, lea)); err != nil {
-		return err
+	lea.overlay.OnError = func(err error) {
+		lea.app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
+	lea.app.AddCloseCleanFunc(lea.overlay.Close)
+	lea.overlay.Start()
 	return nil
 }
-
-// renderCallback is used to draw bounding boxes from the detections via axoverlay
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	lea := renderEvent.Userdata.(*larodExampleApplication)
-	renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-	for _, obj := range lea.prediction_result.Detections {
-		scaled_box := obj.Box.Scale(renderEvent.Stream.Width, renderEvent.Stream.Height)
-		cords := scaled_box.ToCords64()
-		renderEvent.CairoCtx.DrawBoundingBox(
-			cords.X,
-			cords.Y,
-			cords.W,
-			cords.H,
-			axoverlay.ColorMaterialBlue,
-			fmt.Sprintf("%s %d%%", strings.ToUpper(coco_labels[int(obj.Class)]), int(obj.Score*100)),
-			axoverlay.ColorWite,
-			17,
-			"sans",
-			170,
-		)
-		lea.app.Syslog.Infof("Render overlay for object: %s, score %d%%", coco_labels[int(obj.Class)], int(obj.Score*100))
-	}
-
-}
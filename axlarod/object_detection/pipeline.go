@@ -0,0 +1,146 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+var (
+	INFER_LANES       = 2                     // Number of preprocessing and detection model pairs, 2 double buffers the memmap tensors
+	STAGE_RETRIES     = 2                     // Retries of a failed larod job before the frame is dropped
+	STAGE_RETRY_DELAY = 10 * time.Millisecond // Wait time between the retries
+	STATS_INTERVAL    = 100                   // Log the pipeline statistics every n published frames
+)
+
+// inferLane is a preprocessing and detection model pair with its own memory mapped tensors.
+// A frame holds a lane from the preprocessing until its outputs are decoded, so the next frame can be
+// preprocessed and inferred on the other lane without overwriting tensors which are still in use.
+type inferLane struct {
+	PPModel        *axlarod.LarodModel // PPModel is the preprocessing model, its output is the detection model input.
+	DetectionModel *axlarod.LarodModel // DetectionModel is the detection model of the lane.
+	outputs        [][]byte            // outputs holds the memory mapped output tensors of the detection model.
+}
+
+// frameJob is a frame on its way through the pipeline.
+type frameJob struct {
+	pipeline.Job[*inferLane]
+	frame        *axvdo.VideoFrame  // frame is the captured video frame.
+	pp_result    *axlarod.JobResult // pp_result holds the result of the preprocessing model job.
+	infer_result *axlarod.JobResult // infer_result holds the result of the detection model job.
+	result       postprocess.Result // result holds the decoded detections.
+	overlay      scene.Node         // overlay holds the scene nodes of the frame, built with the tracker and analytics state of the frame.
+	outputs      [][]byte           // outputs holds the recorded model outputs of a replayed frame, the larod jobs are skipped if set.
+}
+
+// InitalizePipeline creates the inference lanes and the pipeline capture → preprocess → infer → postprocess → publish.
+// Returns an error if a model of a lane fails to initialize.
+func (lea *larodExampleApplication) InitalizePipeline(modelFilePath string, chipString string) error {
+	lanes, err := pipeline.NewLanes(INFER_LANES, func(int) (*inferLane, error) {
+		ppModel, err := lea.InitalizePPModel(axlarod.PreProccessOutputFormatRgbInterleaved)
+		if err != nil {
+			return nil, err
+		}
+		detectionModel, meta, err := lea.InitalizeDetectionModel(ppModel, modelFilePath, chipString)
+		if err != nil {
+			return nil, err
+		}
+		if lea.detector.PostProcessor == nil {
+			if err = lea.InitalizePostProcessor(meta); err != nil {
+				return nil, err
+			}
+		}
+		return &inferLane{PPModel: ppModel, DetectionModel: detectionModel}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	lea.pipeline = pipeline.NewInference(lanes, pipeline.InferenceConfig{Policy: lea.dropPolicy, Retries: STAGE_RETRIES, RetryDelay: STAGE_RETRY_DELAY}, pipeline.InferenceStages[*frameJob]{
+		Preprocess: lea.preprocessStage,
+		Infer:      lea.inferStage,
+		Decode:     lea.decodeStage,
+		Track:      lea.trackStage,
+		Publish:    lea.publishStage,
+		Skip:       func(job *frameJob) bool { return job.outputs != nil }, // Replayed outputs, no lane needed
+	})
+	lea.pipeline.OnError = func(stage string, job *frameJob, err error) {
+		lea.app.Syslog.Errorf("Frame %d dropped, %s failed: %s", job.frame.SequenceNbr, stage, err.Error())
+	}
+	lea.app.Syslog.Infof("Pipeline with %d inference lanes, frame drop policy: %s", INFER_LANES, lea.dropPolicy)
+	return nil
+}
+
+// preprocessStage runs the preprocessing model of the lane, the pipeline waits for a free lane before.
+func (lea *larodExampleApplication) preprocessStage(job *frameJob) error {
+	defer lea.metrics.Preprocess.Since(time.Now())
+	var err error
+	job.pp_result, err = lea.PreProcess(job.Lane(), job.frame)
+	return err
+}
+
+// inferStage runs the detection model of the lane, the outputs stay in the memmap files of the lane.
+func (lea *larodExampleApplication) inferStage(job *frameJob) error {
+	defer lea.metrics.Inference.Since(time.Now())
+	var err error
+	job.infer_result, err = lea.Inference(job.Lane())
+	return err
+}
+
+// decodeStage decodes the outputs of the lane or the replayed outputs and records them if enabled.
+// The decoded detections do not reference the memmap tensors, so the pipeline releases the lane afterwards.
+func (lea *larodExampleApplication) decodeStage(job *frameJob) error {
+	defer lea.metrics.Decode.Since(time.Now())
+	var err error
+	outputs := job.outputs
+	if outputs == nil {
+		if outputs, err = lea.LaneOutputs(job.Lane()); err != nil {
+			return err
+		}
+		lea.record(job, outputs)
+	}
+	job.result, err = lea.detector.Decode(outputs)
+	return err
+}
+
+// trackStage updates the tracker and the analytics, sends the events and builds the overlay of the frame.
+func (lea *larodExampleApplication) trackStage(job *frameJob) error {
+	defer lea.metrics.Tracking.Since(time.Now())
+	frame := lea.detector.Track(job.result, time.Now())
+	lea.sendLineCrossings(frame.Crossings)
+	lea.handleZoneEvents(frame.ZoneEvents)
+	job.overlay = frame.Node
+	return nil
+}
+
+// publishStage updates the overlay, counts and logs the frame.
+func (lea *larodExampleApplication) publishStage(job *frameJob) error {
+	start := time.Now()
+	lea.scene.Set("frame", job.overlay)
+	lea.metrics.Overlay.Since(start)
+	lea.metrics.Published.Inc()
+	lea.metrics.FPS.Mark()
+
+	if job.outputs != nil {
+		lea.app.Syslog.Infof("Frame: %d, Replayed outputs, Detections: %d", job.frame.SequenceNbr, len(job.result.Detections))
+	} else {
+		lea.app.Syslog.Infof("Frame: %d, PP time: %.fms, Infer. exec time: %.fms, Detections: %d",
+			job.frame.SequenceNbr,
+			job.pp_result.ExecutionTime,
+			job.infer_result.ExecutionTime,
+			len(job.result.Detections),
+		)
+	}
+
+	if lea.metrics.Published.Value()%uint64(STATS_INTERVAL) == 0 {
+		for _, stats := range lea.pipeline.Stats() {
+			lea.app.Syslog.Infof("Pipeline %s", stats)
+		}
+		lea.app.Syslog.Infof("Latency %s", lea.metrics)
+	}
+	return nil
+}
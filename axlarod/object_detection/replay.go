@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/Cacsjep/goxis_examples/pkg/replay"
+)
+
+var (
+	REPLAY_DIR    = "replay"           // If this directory exists in the app directory, its frames are replayed instead of the camera stream
+	RECORD_DIR    = "localdata/record" // Directory of the recorded frames and model outputs
+	RECORD_FRAMES = 0                  // Record the first n frames with their model outputs for a replay, 0 disables the recording
+)
+
+// InitReplay opens the replay directory if it exists.
+// Frames with recorded outputs skip the larod jobs, the other frames run through the models like camera frames.
+// The replay does not drop frames, so every recorded frame is processed.
+func (lea *larodExampleApplication) InitReplay() error {
+	if _, err := os.Stat(REPLAY_DIR); err != nil {
+		return nil
+	}
+	if lea.replay, err = replay.NewSource(REPLAY_DIR, replay.Config{Width: lea.streamWidth, Height: lea.streamHeight, Format: replay.YUV, FPS: lea.fps}); err != nil {
+		return err
+	}
+	lea.replayOutputs = replay.Recorded{Dir: REPLAY_DIR}
+	lea.dropPolicy = pipeline.Block
+	lea.app.Syslog.Infof("Replaying %d frames from %s instead of the camera stream", lea.replay.Len(), REPLAY_DIR)
+	return nil
+}
+
+// InitRecorder creates the recorder if RECORD_FRAMES is set.
+func (lea *larodExampleApplication) InitRecorder() error {
+	if RECORD_FRAMES <= 0 {
+		return nil
+	}
+	if lea.recorder, err = replay.NewRecorder(RECORD_DIR, RECORD_FRAMES); err != nil {
+		return err
+	}
+	lea.app.Syslog.Infof("Recording %d frames into %s", RECORD_FRAMES, RECORD_DIR)
+	return nil
+}
+
+// Replay pushes the replayed frames with their recorded outputs into the pipeline.
+// Returns after the last frame.
+func (lea *larodExampleApplication) Replay() {
+	lea.replay.Start()
+	defer lea.replay.Stop()
+
+	for frame := range lea.replay.Frames {
+		if frame.Error != nil {
+			lea.app.Syslog.Errorf("Unexpected replay error: %s", frame.Error.Error())
+			continue
+		}
+		outputs, err := lea.replayOutputs.Outputs(frame)
+		if err != nil && !errors.Is(err, replay.ErrNoOutputs) {
+			lea.app.Syslog.Errorf("Failed to read recorded outputs: %s", err.Error())
+			continue
+		}
+		lea.pipeline.Push(&frameJob{
+			frame: &axvdo.VideoFrame{
+				SequenceNbr: frame.SequenceNbr,
+				Timestamp:   frame.Timestamp,
+				Size:        uint(len(frame.Data)),
+				Data:        frame.Data,
+				Type:        axvdo.VdoFrameTypeYUV,
+			},
+			outputs: outputs,
+		})
+	}
+	lea.app.Syslog.Infof("Replay of %s finished", REPLAY_DIR)
+}
+
+// record writes the frame and the model outputs of a job until RECORD_FRAMES are recorded.
+func (lea *larodExampleApplication) record(job *frameJob, outputs [][]byte) {
+	if lea.recorder == nil || lea.recorder.Done() {
+		return
+	}
+	frame := &replay.Frame{
+		SequenceNbr: job.frame.SequenceNbr,
+		Width:       lea.streamWidth,
+		Height:      lea.streamHeight,
+		Format:      replay.YUV,
+		Data:        job.frame.Data,
+	}
+	if err := lea.recorder.Record(frame, outputs); err != nil {
+		lea.app.Syslog.Errorf("Failed to record frame %d: %s", job.frame.SequenceNbr, err.Error())
+		return
+	}
+	if lea.recorder.Done() {
+		lea.app.Syslog.Infof("Recorded %d frames into %s", lea.recorder.Count(), RECORD_DIR)
+	}
+}
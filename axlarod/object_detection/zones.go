@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/acapapp"
+	"github.com/Cacsjep/goxis/pkg/axevent"
+	"github.com/Cacsjep/goxis/pkg/utils"
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+)
+
+// InitZones sets up the monitored polygon zones and declares the loitering event.
+// The loitering event is stateful, it is active as long as a person loiters in the zone.
+func (lea *larodExampleApplication) InitZones() error {
+	// Zone in the lower left part of the stream, persons staying longer than 30 seconds are loitering.
+	// Zones are in normalized stream coordinates like the counting lines.
+	lea.detector.Zones = analytics.NewZoneMonitor(
+		&analytics.Zone{
+			Name:            "entrance",
+			Polygon:         []analytics.Point{{X: 0.05, Y: 0.5}, {X: 0.45, Y: 0.5}, {X: 0.45, Y: 0.95}, {X: 0.05, Y: 0.95}},
+			Classes:         []int{COCO_PERSON_CLASS},
+			LoiterThreshold: 30 * time.Second,
+		},
+	)
+	lea.zoneLoitering = make(map[string]bool)
+
+	lea.loiteringEvent = &acapapp.CameraPlatformEvent{
+		Name:     "loitering",
+		NiceName: utils.StrPtr("Loitering"),
+		Entries: []*acapapp.EventEntry{
+			{Key: "zone", ValueType: axevent.AXValueTypeString, IsSource: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Zone")},
+			{Key: "active", ValueType: axevent.AXValueTypeBool, IsData: utils.BoolPtr(true), KeyNiceName: utils.StrPtr("Active"), Value: false},
+			{Key: "occupancy", ValueType: axevent.AXValueTypeInt, KeyNiceName: utils.StrPtr("Occupancy"), Value: 0},
+		},
+		Stateless: false,
+	}
+	if lea.loiteringEventId, err = lea.app.AddCameraPlatformEvent(lea.loiteringEvent); err != nil {
+		return err
+	}
+	return nil
+}
+
+// handleZoneEvents logs the zone events and sends the loitering event when the loitering state of a zone changes.
+func (lea *larodExampleApplication) handleZoneEvents(events []analytics.ZoneEvent) {
+	for _, event := range events {
+		lea.app.Syslog.Infof("ID-%d zone %s: %s after %d sec", event.TrackID, event.Zone.Name, event.Type, int(event.Dwell.Seconds()))
+	}
+
+	for _, zone := range lea.detector.Zones.Zones {
+		loitering := zone.Loitering()
+		if loitering == lea.zoneLoitering[zone.Name] {
+			continue
+		}
+		lea.zoneLoitering[zone.Name] = loitering
+
+		if err := lea.app.SendPlatformEvent(lea.loiteringEventId, func() (*axevent.AXEvent, error) {
+			return lea.loiteringEvent.NewEvent(acapapp.KeyValueMap{
+				"zone":      zone.Name,
+				"active":    loitering,
+				"occupancy": zone.Occupancy(),
+			})
+		}); err != nil {
+			lea.app.Syslog.Errorf("Error sending loitering event: %s", err.Error())
+		}
+	}
+}
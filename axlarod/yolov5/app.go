@@ -3,8 +3,12 @@ package main
 import (
 	"github.com/Cacsjep/goxis/pkg/acapapp"
 	"github.com/Cacsjep/goxis/pkg/axlarod"
-	"github.com/Cacsjep/goxis/pkg/axoverlay"
 	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
 )
 
 var (
@@ -34,16 +38,23 @@ func main() {
 				continue
 			}
 
+			// Copy the frame into the letterboxed model input
+			if err = lea.FillModelInput(frame.Data); err != nil {
+				lea.app.Syslog.Errorf("Failed to fill model input: %s", err.Error())
+				continue
+			}
+
 			// Execute the detection model job
 			if lea.infer_result, err = lea.Inference(); err != nil {
 				lea.app.Syslog.Errorf("Failed to execute Detection Model: %s", err.Error())
 				continue
 			}
 
-			// Draw overlay
-			if err = lea.overlayProvider.Redraw(); err != nil {
-				lea.app.Syslog.Errorf("Failed to redraw overlay: %s", err.Error())
-			}
+			// Track the detections across frames
+			lea.tracked = lea.sortTracker.Update(lea.detections)
+
+			// Update the overlay, it is redrawn if the boxes changed
+			lea.UpdateOverlay()
 
 			lea.app.Syslog.Infof("Frame: %d, Infer. exec time: %.fms, Detections: %d",
 				frame.SequenceNbr,
@@ -58,19 +69,29 @@ func main() {
 // larodExampleApplication struct defines the structure for this example.
 // It includes configuration for application, models, video stream, and other operational parameters.
 type larodExampleApplication struct {
-	app             *acapapp.AcapApplication       // app represents the acap application
-	DetectionModel  *axlarod.LarodModel            // DetectionModel is the model used for detecting objects in video frames.
-	streamWidth     int                            // streamWidth specifies the width of the video stream.
-	streamHeight    int                            // streamHeight specifies the height of the video stream.
-	yoloInputWidth  int                            // yoloInputWidth specifies the width of the input tensor for the detection model.
-	yoloInputHeight int                            // yoloInptHeight specifies the height of the input tensor for the detection model.
-	fps             int                            // fps represents the frame rate of the video stream.
-	sconfig         *axvdo.VideoSteamConfiguration // sconfig holds the configuration for the video stream.
-	infer_result    *axlarod.JobResult             // infer_result holds the result of the detection model job.
-	threshold       float32                        // threshold is the minimum score required for an object to be considered detected.
-	overlayProvider *axoverlay.OverlayProvider     // overlayProvider is used to draw overlay on the video stream.
-	detections      []Detection                    // detections stores the detected objects.
-	iouThreshold    float64                        // iouThreshold is the threshold for Intersection over Union (IoU) for non-maximum suppression.
+	app               *acapapp.AcapApplication                       // app represents the acap application
+	DetectionModel    *axlarod.LarodModel                            // DetectionModel is the model used for detecting objects in video frames.
+	streamWidth       int                                            // streamWidth specifies the width of the video stream.
+	streamHeight      int                                            // streamHeight specifies the height of the video stream.
+	yoloInputWidth    int                                            // yoloInputWidth specifies the width of the input tensor for the detection model.
+	yoloInputHeight   int                                            // yoloInptHeight specifies the height of the input tensor for the detection model.
+	fps               int                                            // fps represents the frame rate of the video stream.
+	sconfig           *axvdo.VideoSteamConfiguration                 // sconfig holds the configuration for the video stream.
+	infer_result      *axlarod.JobResult                             // infer_result holds the result of the detection model job.
+	threshold         float32                                        // threshold is the minimum score required for an object to be considered detected.
+	overlay           *overlay.Overlay                               // overlay draws the scene on the video streams.
+	scene             *scene.Scene                                   // scene holds the bounding boxes drawn by the overlay.
+	detections        []postprocess.Detection                        // detections stores the detected objects.
+	iouThreshold      float32                                        // iouThreshold is the threshold for Intersection over Union (IoU) for non-maximum suppression.
+	maxDetections     int                                            // maxDetections is the maximum number of detections kept per frame.
+	sortTracker       *tracker.SORT[postprocess.Detection]           // sortTracker is used to track objects across frames, per class.
+	tracked           []tracker.TrackedObject[postprocess.Detection] // tracked stores the tracked objects of the current frame.
+	postProcessorName string                                         // postProcessorName selects the decoding of the model outputs, see postprocess.Names.
+	postProcessor     postprocess.PostProcessor                      // postProcessor converts the output tensor into detections.
+	outputs           [][]byte                                       // outputs holds the memory mapped output tensors of the detection model.
+	labels            postprocess.Labels                             // labels holds the class names of the detection model.
+	resizeMode        projection.Mode                                // resizeMode selects how the stream is fitted into the model input.
+	projection        projection.Projection                          // projection maps the model boxes back into stream coordinates.
 }
 
 // Initialize prepares and initializes all necessary components for the application.
@@ -78,7 +99,8 @@ type larodExampleApplication struct {
 // Returns a configured instance of larodExampleApplication or an error if initialization fails.
 func Initalize() (*larodExampleApplication, error) {
 
-	lea := &larodExampleApplication{fps: 3, threshold: 0.6, yoloInputWidth: 640, yoloInputHeight: 640, detections: []Detection{}, iouThreshold: 0.5}
+	lea := &larodExampleApplication{fps: 3, threshold: 0.6, yoloInputWidth: 640, yoloInputHeight: 640, detections: []postprocess.Detection{}, iouThreshold: 0.5, maxDetections: 100, resizeMode: projection.Letterbox, postProcessorName: postprocess.YOLO_POSTPROCESS}
+	lea.sortTracker = tracker.NewSORT[postprocess.Detection](3, 1, lea.threshold, 0.2) // Low fps, so objects move a lot between frames
 
 	// Initialize a new ACAP application instance.
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
@@ -89,6 +111,12 @@ func Initalize() (*larodExampleApplication, error) {
 		return nil, err
 	}
 
+	// Letterbox keeps the aspect ratio, so objects at the left and right edge are detected too
+	lea.projection = projection.New(lea.resizeMode, lea.streamWidth, lea.streamHeight, lea.yoloInputWidth, lea.yoloInputHeight)
+	resizeWidth, resizeHeight := lea.projection.ResizeResolution()
+	lea.app.Syslog.Infof("Stream %dx%d is fitted into the model input %dx%d via %s, preprocessing output %dx%d",
+		lea.streamWidth, lea.streamHeight, lea.yoloInputWidth, lea.yoloInputHeight, lea.resizeMode, resizeWidth, resizeHeight)
+
 	// Initialize/Connecting Larod
 	if err = lea.app.InitalizeLarod(); err != nil {
 		return nil, err
@@ -103,7 +131,7 @@ func Initalize() (*larodExampleApplication, error) {
 	if err = lea.app.FrameProvider.SetLarodPostProccessor(
 		"cpu-proc",
 		axlarod.PreProccessOutputFormatRgbInterleaved,
-		&axvdo.VdoResolution{Width: lea.yoloInputWidth, Height: lea.yoloInputHeight},
+		&axvdo.VdoResolution{Width: resizeWidth, Height: resizeHeight},
 		func(b []byte) []byte { return b },
 	); err != nil {
 		return nil, err
@@ -114,7 +142,7 @@ func Initalize() (*larodExampleApplication, error) {
 		return nil, err
 	}
 
-	// Initialize the overlay provider
+	// Initialize the overlay
 	if err = lea.InitOverlay(); err != nil {
 		return nil, err
 	}
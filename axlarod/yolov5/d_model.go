@@ -1,147 +1,96 @@
 package main
 
 import (
-	"math"
-	"sort"
+	"fmt"
+	"unsafe"
 
 	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/larodmodel"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/projection"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
 )
 
-const (
-	BATCH_SIZE              = 1
-	NUM_PREDICTIONS         = 25200
-	ELEMENTS_PER_PREDICTION = 5 // x, y, w, h, confidence
-	CLASSES                 = 80
-	UINT_8_SIZE             = 1
-	TENSOR_OUT_1_SIZE       = uint(BATCH_SIZE * NUM_PREDICTIONS * (ELEMENTS_PER_PREDICTION + CLASSES) * UINT_8_SIZE)
-	BBOX_SIZE               = ELEMENTS_PER_PREDICTION + CLASSES
-	V5N_QUANTIZATION        = 0.004190513398498297 // yolov5n.tflite
-)
-
-type YoloResult struct {
-	Detections []Detection
-}
-
-type Detection struct {
-	Box          axlarod.BoundingBox
-	Confidence   float32
-	BestClassIdx int
-	BestScore    float32
-}
-
 // InitializeDetectionModel configures a detection model with the given model file and hardware chip.
-// It sets up memory-mapped file configurations for input and output tensors.
-// Returns an error if model initialization fails.
+// The memory mapped tensors are sized from the tflite metadata, and a model with unexpected tensors fails here.
+// In letterbox mode the model gets its own padded input, otherwise the ppmodel output is used directly.
+// The outputs are decoded by the post processor registered under lea.postProcessorName, the yolo post processor
+// is configured from the output tensor shape and quantization. The labels are loaded from the .txt file next to the model.
+// Returns an error if model initialization fails or the labels do not match the classes of the model.
 func (lea *larodExampleApplication) InitalizeDetectionModel(modelFilePath string, chipString string) error {
-	model_defs := axlarod.MemMapConfiguration{
-		InputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: lea.app.FrameProvider.PostProcessModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
-		},
-		OutputTmpMapFiles: map[int]*axlarod.MemMapFile{
-			0: {Size: TENSOR_OUT_1_SIZE}, // Output Tensor 1
-		},
+	inputs := map[int]*axlarod.MemMapFile{
+		0: lea.app.FrameProvider.PostProcessModel.Outputs[0].MemMapFile, // Using of ppmodel output as input for detection model
+	}
+	if lea.resizeMode == projection.Letterbox {
+		inputs = nil // Padded copy of the ppmodel output
 	}
 
-	if lea.DetectionModel, err = lea.app.Larod.NewInferModel(modelFilePath, chipString, model_defs, nil); err != nil {
+	var meta *tflite.Model
+	if lea.DetectionModel, meta, err = larodmodel.NewInferModel(lea.app.Larod, modelFilePath, chipString, inputs, larodmodel.Expectation{
+		Inputs: []tflite.TensorSpec{{Types: []tflite.TensorType{tflite.Uint8}, Shape: []int{1, lea.yoloInputHeight, lea.yoloInputWidth, 3}}},
+	}); err != nil {
 		return err
 	}
 	lea.app.AddModelCleaner(lea.DetectionModel)
-	return nil
-}
 
-// getDResult retrieves the detection results from the model's output tensors.
-func (lea *larodExampleApplication) getDResult() ([]Detection, error) {
-	t1, err := lea.DetectionModel.Outputs[0].GetData(int(TENSOR_OUT_1_SIZE))
-	if err != nil {
-		return nil, err
+	// The padding is written once, each frame only overwrites the content area
+	if lea.resizeMode == projection.Letterbox {
+		input := lea.DetectionModel.Inputs[0].MemMapFile
+		lea.projection.Pad(unsafe.Slice((*byte)(input.MemoryAddress), input.Size))
 	}
 
-	detections := make([]Detection, 0, NUM_PREDICTIONS)
-	for i := 0; i < NUM_PREDICTIONS; i++ {
-		offset := i * BBOX_SIZE
-		centerX := yolov5n_dequantize(t1[offset])
-		centerY := yolov5n_dequantize(t1[offset+1])
-		width := yolov5n_dequantize(t1[offset+2])
-		height := yolov5n_dequantize(t1[offset+3])
-		confidence := yolov5n_dequantize(t1[offset+4])
-
-		if confidence > lea.threshold {
-			top := centerY - height/2
-			left := centerX - width/2
-			bottom := centerY + height/2
-			right := centerX + width/2
-
-			det := Detection{
-				Box: axlarod.BoundingBox{
-					Top:    top,
-					Left:   left,
-					Bottom: bottom,
-					Right:  right,
-				},
-				Confidence: confidence,
-			}
+	if lea.postProcessor, err = postprocess.New(lea.postProcessorName, postprocess.Config{
+		Outputs:       meta.Outputs,
+		Threshold:     lea.threshold,
+		IOUThreshold:  lea.iouThreshold,
+		MaxDetections: lea.maxDetections,
+	}); err != nil {
+		return err
+	}
 
-			maxClassIdx := 0
-			maxScore := yolov5n_dequantize(t1[offset+5])
-			for j := 1; j < CLASSES; j++ {
-				score := yolov5n_dequantize(t1[offset+5+j])
-				if score > maxScore {
-					maxScore = score
-					maxClassIdx = j
-				}
-			}
-			det.BestClassIdx = maxClassIdx
-			det.BestScore = maxScore
-			detections = append(detections, det)
+	if lea.labels, err = postprocess.LoadLabels(postprocess.LabelsPath(modelFilePath)); err != nil {
+		return err
+	}
+	if y, ok := lea.postProcessor.(*postprocess.YOLO); ok {
+		if err = lea.labels.Check(y.NumClasses()); err != nil {
+			return fmt.Errorf("%s: %w", postprocess.LabelsPath(modelFilePath), err)
 		}
 	}
 
-	lea.detections = nonMaximumSuppression(detections)
-	return lea.detections, nil
+	lea.app.Syslog.Infof("Detection model post processing: %s", lea.postProcessor)
+	return nil
 }
 
-func nonMaximumSuppression(detections []Detection) []Detection {
-	sort.Slice(detections, func(i, j int) bool {
-		return detections[i].Confidence > detections[j].Confidence
-	})
-
-	var keep []Detection
-
-	for i := 0; i < len(detections); i++ {
-		suppressed := false
-		for j := 0; j < len(keep); j++ {
-			if computeIoU(detections[i].Box, keep[j].Box) > lea.iouThreshold {
-				suppressed = true
-				break
-			}
-		}
-		if !suppressed {
-			keep = append(keep, detections[i])
-		}
+// getDResult decodes the detection results directly from the memory mapped output tensor.
+// The tensor is not copied, and the post processor reuses its buffers across frames.
+func (lea *larodExampleApplication) getDResult() ([]postprocess.Detection, error) {
+	if lea.outputs, err = larodmodel.Outputs(lea.DetectionModel, lea.outputs); err != nil {
+		return nil, err
 	}
-	return keep
-}
-
-// computeIoU calculates the Intersection over Union (IoU) of two bounding boxes.
-func computeIoU(box1, box2 axlarod.BoundingBox) float64 {
-	x1 := math.Max(float64(box1.Left), float64(box2.Left))
-	y1 := math.Max(float64(box1.Top), float64(box2.Top))
-	x2 := math.Min(float64(box1.Right), float64(box2.Right))
-	y2 := math.Min(float64(box1.Bottom), float64(box2.Bottom))
-
-	interArea := math.Max(0, x2-x1) * math.Max(0, y2-y1)
-	if interArea == 0 {
-		return 0
+	var result postprocess.Result
+	if result, err = lea.postProcessor.Process(lea.outputs); err != nil {
+		return nil, err
 	}
+	lea.detections = result.Detections
 
-	box1Area := (box1.Right - box1.Left) * (box1.Bottom - box1.Top)
-	box2Area := (box2.Right - box2.Left) * (box2.Bottom - box2.Top)
-	return interArea / (float64(box1Area+box2Area) - interArea)
+	// Map the boxes back into stream coordinates for tracking and drawing
+	for i := range lea.detections {
+		lea.detections[i].Box = lea.projection.ToStream(lea.detections[i].Box)
+	}
+	return lea.detections, nil
 }
 
-// yolov5n_dequantize converts a quantized value to a float32 value.
-func yolov5n_dequantize(q uint8) float32 {
-	return V5N_QUANTIZATION * float32(q)
+// FillModelInput copies the preprocessed frame into the content area of the letterboxed model input.
+// In center crop mode the ppmodel output is the model input, so nothing is copied.
+func (lea *larodExampleApplication) FillModelInput(rgb []byte) error {
+	if lea.resizeMode != projection.Letterbox {
+		return nil
+	}
+	mmf := lea.DetectionModel.Inputs[0].MemMapFile
+	if !lea.projection.Fill(unsafe.Slice((*byte)(mmf.MemoryAddress), mmf.Size), rgb) {
+		return fmt.Errorf("frame of %d bytes does not fit the model input", len(rgb))
+	}
+	return nil
 }
 
 // Inference executes the model and retrieves the processed results.
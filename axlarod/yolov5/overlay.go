@@ -4,40 +4,38 @@ import (
 	"fmt"
 
 	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
-// Initialize the overlay provider
+// Initialize the overlay, it redraws whenever UpdateOverlay changes the scene
 func (lea *larodExampleApplication) InitOverlay() error {
-	if lea.overlayProvider, err = axoverlay.NewOverlayProvider(renderCallback, nil, nil); err != nil {
+	lea.scene = scene.New()
+	lea.scene.Reference = scene.DEFAULT_REFERENCE
+	if lea.overlay, err = overlay.New(lea.scene); err != nil {
 		return err
 	}
-	lea.app.AddCloseCleanFunc(lea.overlayProvider.Cleanup)
-	if _, err = lea.overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, lea)); err != nil {
-		return err
+	lea.overlay.OnError = func(err error) {
+		lea.app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
+	lea.app.AddCloseCleanFunc(lea.overlay.Close)
+	lea.overlay.Start()
 	return nil
 }
 
-// renderCallback is used to draw bounding boxes from the detections via axoverlay
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	lea := renderEvent.Userdata.(*larodExampleApplication)
-	renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-	for _, obj := range lea.detections {
-		if obj.Confidence > lea.threshold {
-			scaled_box := obj.Box.Scale(renderEvent.Stream.Width, renderEvent.Stream.Height)
-			cords := scaled_box.ToCords64()
-			renderEvent.CairoCtx.DrawBoundingBox(
-				cords.X,
-				cords.Y,
-				cords.W,
-				cords.H,
+// UpdateOverlay puts the bounding boxes of the tracked objects into the scene.
+func (lea *larodExampleApplication) UpdateOverlay() {
+	objects := scene.Group{}
+	for _, obj := range lea.tracked {
+		if !obj.Coasting && obj.Detection.Score > lea.threshold {
+			objects.Nodes = append(objects.Nodes, scene.Box(
+				scene.Point{X: float64(obj.Box.Left), Y: float64(obj.Box.Top)},
+				scene.Point{X: float64(obj.Box.Right - obj.Box.Left), Y: float64(obj.Box.Bottom - obj.Box.Top)},
 				axoverlay.ColorMaterialBlue,
-				fmt.Sprintf("%s %d%%", yolo_labels[obj.BestClassIdx], int(obj.Confidence*100)),
-				axoverlay.ColorWite,
-				17,
-				"sans",
-				170,
-			)
+				fmt.Sprintf("ID-%d %s %d%%", obj.ID, lea.labels.Name(obj.Class), int(obj.Detection.Score*100)),
+				scene.TextStyle{Size: 17, Font: "sans", Color: axoverlay.ColorWite, Padding: 3},
+			))
 		}
 	}
+	lea.scene.Set("objects", objects)
 }
@@ -24,7 +24,7 @@ func (l *larodExampleApplication) SetupStreamResolution() error {
 		return err
 	}
 
-	model_reso, err := vdo_channel.ChooseStreamResolution(l.yoloInputWidth, l.yoloInputWidth)
+	model_reso, err := vdo_channel.ChooseStreamResolution(l.yoloInputWidth, l.yoloInputHeight)
 	if err != nil {
 		return err
 	}
@@ -9,19 +9,21 @@ import (
 	"github.com/Cacsjep/goxis/pkg/acapapp"
 	"github.com/Cacsjep/goxis/pkg/axmdb"
 	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
 // MdbSceneOverlayApp represents an application that manages scene metadata overlays.
-// It contains references to an AcapApplication, an OverlayProvider, and an MDBProvider
-// for scene descriptions. It also maintains a list of observations, a channel for
+// It contains references to an AcapApplication, an Overlay, and an MDBProvider
+// for scene descriptions. It also maintains the scene with the observations, a channel for
 // signaling closure, and a wait group for synchronizing goroutines.
 type MdbSceneOverlayApp struct {
-	app             *acapapp.AcapApplication
-	overlayProvider *axoverlay.OverlayProvider
-	mdbProvider     *axmdb.MDBProvider[axmdb.SceneDescription]
-	mdbObservation  []axmdb.Observation
-	closeChan       chan struct{}
-	wg              sync.WaitGroup
+	app         *acapapp.AcapApplication
+	overlay     *overlay.Overlay
+	scene       *scene.Scene
+	mdbProvider *axmdb.MDBProvider[axmdb.SceneDescription]
+	closeChan   chan struct{}
+	wg          sync.WaitGroup
 }
 
 // newMdbSceneOverlayApp creates a new instance of MdbSceneOverlayApp.
@@ -36,11 +38,12 @@ func newMdbSceneOverlayApp() (*MdbSceneOverlayApp, error) {
 
 	// Create a new ACAP application instance
 	msoa := &MdbSceneOverlayApp{
-		app:            acapapp.NewAcapApplication(),
-		mdbObservation: []axmdb.Observation{},
-		closeChan:      make(chan struct{}),
+		app:       acapapp.NewAcapApplication(),
+		scene:     scene.New(),
+		closeChan: make(chan struct{}),
 	}
 
+	msoa.scene.Reference = scene.DEFAULT_REFERENCE
 	msoa.app.AddCloseCleanFunc(msoa.Close)
 
 	// Create the message broker provider
@@ -56,76 +59,62 @@ func newMdbSceneOverlayApp() (*MdbSceneOverlayApp, error) {
 		return nil, fmt.Errorf("Failed to create overlay provider: %s", err.Error())
 	}
 
-	// Add close clean function to cleanup the overlay provider
-	msoa.app.AddCloseCleanFunc(msoa.overlayProvider.Cleanup)
-
 	return msoa, nil
 }
 
-// SetupOverlay initializes the overlay provider for the MdbSceneOverlayApp instance.
-// It creates a new overlay provider, adds a cleanup function to the app, adds an overlay,
-// and performs an initial redraw.
+// SetupOverlay initializes the overlay for the MdbSceneOverlayApp instance.
+// It creates a new overlay which renders the scene, adds a cleanup function to the app,
+// and starts the redraws whenever the worker changes the scene.
 //
-// Returns an error if any of these steps fail.
+// Returns an error if the overlay provider or the overlay can not be created.
 func (msoa *MdbSceneOverlayApp) SetupOverlay() error {
 	var err error
 
-	if msoa.overlayProvider, err = axoverlay.NewOverlayProvider(msoa.renderCallback, nil, nil); err != nil {
-		return fmt.Errorf("Failed to create overlay provider: %s", err.Error())
+	if msoa.overlay, err = overlay.New(msoa.scene); err != nil {
+		return fmt.Errorf("Failed to create overlay: %s", err.Error())
+	}
+	msoa.overlay.OnError = func(err error) {
+		msoa.app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
 
 	// Add close clean function to cleanup the overlay provider
-	msoa.app.AddCloseCleanFunc(msoa.overlayProvider.Cleanup)
+	msoa.app.AddCloseCleanFunc(msoa.overlay.Close)
 
-	// we are here using no user data, because we can use golang structs to access the MdbSceneOverlayApp instance
-	if _, err := msoa.overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayCustomNormalized, nil)); err != nil {
-		return fmt.Errorf("Failed to add overlay: %s", err.Error())
-	}
-
-	// inital redraw
-	if err = msoa.overlayProvider.Redraw(); err != nil {
-		return fmt.Errorf("Failed todo a inital redraw overlay: %s", err.Error())
-	}
+	// inital redraw and redraws on scene changes
+	msoa.overlay.Start()
 
 	return nil
 }
 
-// renderCallback is a method of MdbSceneOverlayApp that handles the rendering of overlay events.
-// It draws transparent background and bounding boxes around observations with a class score above a threshold.
+// ObservationsNode builds the bounding boxes of observations with a class score above a threshold.
+// The worker puts the node into the scene, which hands it to the render callback as immutable snapshot,
+// so a new message never changes observations while they are drawn.
 //
 // Parameters:
-// - renderEvent: A pointer to axoverlay.OverlayRenderEvent which contains the rendering context and stream information.
+//   - observations: The observations of a scene description frame.
 //
-// The method performs the following steps:
-// 1. Draws a transparent background using the dimensions of the stream.
-// 2. Iterates over the observations in msoa.mdbObservation.
-// 3. Skips observations that do not have a class or have a class score below 0.1.
-// 4. Normalizes the bounding box coordinates based on the stream dimensions.
-// 5. Draws a bounding box around the observation with a label indicating the class type and score.
-func (msoa *MdbSceneOverlayApp) renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-	for _, obs := range msoa.mdbObservation {
+// Returns:
+//   - scene.Node: A group with a labeled bounding box for each observation.
+func ObservationsNode(observations []axmdb.Observation) scene.Node {
+	boxes := scene.Group{}
+	for _, obs := range observations {
 
 		if obs.Class == nil || obs.Class.Score < 0.1 {
 			// we are not interested in observations without class
 			continue
 		}
 
-		x, y, w, h := BoxNormalize(&obs.BoundingBox, float64(renderEvent.Stream.Width), float64(renderEvent.Stream.Height))
+		pos, size := BoxToScene(&obs.BoundingBox)
 
-		renderEvent.CairoCtx.DrawBoundingBox(
-			x,
-			y,
-			w,
-			h,
+		boxes.Nodes = append(boxes.Nodes, scene.Box(
+			pos,
+			size,
 			BoxColor(obs.Class.Type),
 			fmt.Sprintf("%s %d%%", strings.ToUpper(obs.Class.Type), int(obs.Class.Score*100)),
-			axoverlay.ColorWite,
-			17,
-			"sans",
-			0,
-		)
+			scene.TextStyle{Size: 17, Font: "sans", Color: axoverlay.ColorWite, Padding: 3},
+		))
 	}
+	return boxes
 }
 
 // MdbOnMetaDataWorker is a goroutine that listens for metadata updates and errors from the MDB provider.
@@ -167,15 +156,8 @@ func (msoa *MdbSceneOverlayApp) MdbOnMetaDataWorker() {
 				msoa.app.Syslog.Critf("Unknown error: %s", err.Err)
 			}
 		case msg := <-msoa.mdbProvider.MessageChan:
-
-			msoa.mdbObservation = []axmdb.Observation{}
-
-			for _, mdo := range msg.Frame.Observations {
-				msoa.mdbObservation = append(msoa.mdbObservation, mdo)
-			}
-			if err := msoa.overlayProvider.Redraw(); err != nil {
-				msoa.app.Syslog.Errorf("Failed to redraw overlay: %s", err.Error())
-			}
+			// Replacing the node triggers a redraw if the boxes changed
+			msoa.scene.Set("observations", ObservationsNode(msg.Frame.Observations))
 		}
 	}
 }
@@ -199,25 +181,16 @@ func (msoa *MdbSceneOverlayApp) Close() {
 	msoa.wg.Wait()
 }
 
-// BoxNormalize scales the normalized coordinates of a bounding box to the given width and height.
+// BoxToScene converts a normalized bounding box into the position and size of a scene node.
 //
 // Parameters:
 //   - bbox: A pointer to an axmdb.Box struct containing the normalized coordinates of the bounding box.
-//   - width: The width to scale the bounding box to.
-//   - height: The height to scale the bounding box to.
 //
 // Returns:
-//   - rectX: The scaled x-coordinate of the left side of the bounding box.
-//   - rectY: The scaled y-coordinate of the top side of the bounding box.
-//   - rectWidth: The scaled width of the bounding box.
-//   - rectHeight: The scaled height of the bounding box.
-func BoxNormalize(bbox *axmdb.Box, width, height float64) (rectX, rectY, rectWidth, rectHeight float64) {
-	// Scale the normalized coordinates
-	left := bbox.Left * width
-	right := bbox.Right * width
-	top := bbox.Top * height
-	bottom := bbox.Bottom * height
-	return left, top, right - left, bottom - top
+//   - pos: The normalized top left corner of the bounding box.
+//   - size: The normalized width and height of the bounding box.
+func BoxToScene(bbox *axmdb.Box) (pos, size scene.Point) {
+	return scene.Point{X: bbox.Left, Y: bbox.Top}, scene.Point{X: bbox.Right - bbox.Left, Y: bbox.Bottom - bbox.Top}
 }
 
 // BoxColor returns the color associated with a given class.
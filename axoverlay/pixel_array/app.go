@@ -2,10 +2,11 @@ package main
 
 import (
 	"math"
-	"time"
 
 	"github.com/Cacsjep/goxis/pkg/acapapp"
 	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
 type PixelChoord struct {
@@ -15,7 +16,10 @@ type PixelChoord struct {
 
 const numPoints = 600
 
-var PIXEL_ARRAY []PixelChoord
+var (
+	PIXEL_ARRAY []PixelChoord
+	PIXEL_SIZE  = 3.0 // Size of a pixel in pixels of the scene.DEFAULT_REFERENCE resolution
+)
 
 // Fill the pixel array with a circle of points
 func init() {
@@ -34,79 +38,39 @@ func init() {
 	}
 }
 
-// This example demonstrate how to use overlay provider to an array of pixels on a stream.
+// This example demonstrate how to use the overlay scene graph to draw an array of pixels on a stream.
+// The scene does not change, so the overlay is only rendered when a stream starts.
 //
 // ! Note: Overlay callbacks only invoked when stream is viewed via web ui or rtsp etc..
-
-// streamSelectCallback can be used to select which streams to render overlays to.
-// Note that YCBCR streams are always skipped since these are used for analytics.
-// ! Just for demo demonstration
-func streamSelectCallback(streamSelectEvent *axoverlay.OverlayStreamSelectEvent) bool {
-	return true
-}
-
-// adjustmentCallback is called when an overlay needs adjustments.
-// This let developers make adjustments to the size and position of their overlays for each stream.
-// This callback function is called prior to rendering every time when an overlay
-// is rendered on a stream, which is useful if the resolution has been
-// updated or rotation has changed.
-func adjustmentCallback(adjustmentEvent *axoverlay.OverlayAdjustmentEvent) {
-	app := adjustmentEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Adjust callback for overlay-%d: %dx%d", adjustmentEvent.OverlayId, adjustmentEvent.OverlayWidth, adjustmentEvent.OverlayHeight)
-	app.Syslog.Infof("Adjust callback for stream: %dx%d", adjustmentEvent.Stream.Width, adjustmentEvent.Stream.Height)
-
-	*adjustmentEvent.OverlayWidth = adjustmentEvent.Stream.Width
-	*adjustmentEvent.OverlayHeight = adjustmentEvent.Stream.Height
-}
-
-// renderCallback is called whenever the system redraws an overlay
-// This can happen in two cases, Redraw() is called or a new stream is started.
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	app := renderEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Render callback for camera: %d", renderEvent.Stream.Camera)
-	app.Syslog.Infof("Render callback for overlay-%d: %dx%d", renderEvent.OverlayId, renderEvent.OverlayWidth, renderEvent.OverlayHeight)
-	app.Syslog.Infof("Render callback for stream: %dx%d", renderEvent.Stream.Width, renderEvent.Stream.Height)
-	renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-	for _, p := range PIXEL_ARRAY {
-		renderEvent.CairoCtx.SetOperator(axoverlay.OPERATOR_SOURCE)
-		renderEvent.CairoCtx.Rectangle(p.X*float64(renderEvent.Stream.Width), p.Y*float64(renderEvent.Stream.Height), 3, 3) // for visibility we draw a 3x3 pixel
-		renderEvent.CairoCtx.SetSourceRGBA(axoverlay.ColorMaterialRed)
-		renderEvent.CairoCtx.Fill()
-	}
-}
-
 func main() {
 	// Initialize a new ACAP application instance.
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	app := acapapp.NewAcapApplication()
 
-	// Overlayprovider is an highlevel wrapper around AxOvleray to make life easier
-	overlayProvider, err := axoverlay.NewOverlayProvider(renderCallback, adjustmentCallback, streamSelectCallback)
-	if err != nil {
-		app.Syslog.Crit(err.Error())
+	// Each pixel is a dot of 3x3 pixels on a 1920x1080 stream, it is scaled with the stream resolution
+	// but stays at least one pixel on small streams
+	pixels := scene.Group{}
+	for _, p := range PIXEL_ARRAY {
+		pixels.Nodes = append(pixels.Nodes, scene.Dot{
+			Pos:   scene.Point{X: p.X, Y: p.Y},
+			Size:  PIXEL_SIZE,
+			Color: axoverlay.ColorMaterialRed,
+		})
 	}
-	app.AddCloseCleanFunc(overlayProvider.Cleanup)
+	sc := scene.New()
+	sc.Reference = scene.DEFAULT_REFERENCE
+	sc.Set("pixels", pixels)
 
-	// we pass app as userdata to access syslog from app in callbacks
-	if _, err = overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, app)); err != nil {
-		app.Syslog.Crit(err.Error())
+	// The overlay wraps the overlay provider and renders the scene on every stream
+	ov, err := overlay.New(sc)
+	if err != nil {
+		panic(err)
 	}
-
-	// Draw overlays
-	if err = overlayProvider.Redraw(); err != nil {
-		app.Syslog.Crit(err.Error())
+	ov.OnError = func(err error) {
+		app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
-
-	// Overlay update
-	ticker := time.NewTicker(time.Millisecond * 300)
-	go func() {
-		for true {
-			<-ticker.C
-			if err = overlayProvider.Redraw(); err != nil {
-				app.Syslog.Crit(err.Error())
-			}
-		}
-	}()
+	app.AddCloseCleanFunc(ov.Close)
+	ov.Start()
 
 	// Run gmain loop with signal handler attached.
 	// This will block the main thread until the application is stopped.
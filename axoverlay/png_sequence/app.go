@@ -4,61 +4,50 @@ import (
 	"time"
 
 	"github.com/Cacsjep/goxis/pkg/acapapp"
-	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/animation"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
-// This example demonstrate how to use overlay provider to draw png images on a stream.
+// This example demonstrate how to use the overlay scene graph to play an animation of png images on a stream.
 //
 // ! Note: Overlay callbacks only invoked when stream is viewed via web ui or rtsp etc..
 var (
-	app             *acapapp.AcapApplication
-	overlayProvider *axoverlay.OverlayProvider
-	err             error
-	image_seq       *ImageSequence
+	ANIMATION_PATH = "zinta"        // Directory of numbered PNG files (0.png, 1.png ..), an animated .gif or an .apng
+	ANIMATION_FPS  = 3.3            // Frames per second of the animation
+	ANIMATION_MODE = animation.Loop // animation.Loop, animation.PingPong or animation.Once
 )
 
-// streamSelectCallback can be used to select which streams to render overlays to.
-// Note that YCBCR streams are always skipped since these are used for analytics.
-// ! Just for demo demonstration
-func streamSelectCallback(streamSelectEvent *axoverlay.OverlayStreamSelectEvent) bool {
-	return true
-}
-
-// adjustmentCallback is called when an overlay needs adjustments.
-// This let developers make adjustments to the size and position of their overlays for each stream.
-// This callback function is called prior to rendering every time when an overlay
-// is rendered on a stream, which is useful if the resolution has been
-// updated or rotation has changed.
-func adjustmentCallback(adjustmentEvent *axoverlay.OverlayAdjustmentEvent) {
-	app := adjustmentEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Adjust callback for overlay-%d: %dx%d", adjustmentEvent.OverlayId, adjustmentEvent.OverlayWidth, adjustmentEvent.OverlayHeight)
-	app.Syslog.Infof("Adjust callback for stream: %dx%d", adjustmentEvent.Stream.Width, adjustmentEvent.Stream.Height)
-
-	*adjustmentEvent.OverlayWidth = adjustmentEvent.Stream.Width
-	*adjustmentEvent.OverlayHeight = adjustmentEvent.Stream.Height
-}
+var (
+	app    *acapapp.AcapApplication
+	ov     *overlay.Overlay
+	sc     *scene.Scene
+	err    error
+	anim   *animation.Animation
+	player *animation.Player
+)
 
-// renderCallback is called whenever the system redraws an overlay
-// This can happen in two cases, Redraw() is called or a new stream is started.
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	app := renderEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Render callback for camera: %d", renderEvent.Stream.Camera)
-	app.Syslog.Infof("Render callback for overlay-%d: %dx%d", renderEvent.OverlayId, renderEvent.OverlayWidth, renderEvent.OverlayHeight)
-	app.Syslog.Infof("Render callback for stream: %dx%d", renderEvent.Stream.Width, renderEvent.Stream.Height)
-	renderNextImageInSequenze(app, renderEvent)
+// frameImage returns the frame of the animation at a time at the bottom center of the stream.
+func frameImage(now time.Time) scene.Image {
+	return scene.Image{
+		Pos:   scene.Point{X: 0.5, Y: 1},
+		Align: scene.Point{X: 0.5, Y: 1},
+		Path:  player.Frame(now),
+	}
 }
 
-// renderNextImageInSequenze renders the next image in the sequence
-func renderNextImageInSequenze(app *acapapp.AcapApplication, renderEvent *axoverlay.OverlayRenderEvent) {
-	surface, err := axoverlay.NewCairoSurfaceFromPNG(image_seq.NextImageFilename())
-	if err != nil {
-		app.Syslog.Errorf("Failed to load PNG: %v", err)
-		return
+// play updates the image node whenever the player shows the next frame.
+// The frame is taken from the wall clock, so a late wake up skips frames instead of slowing down the animation.
+func play() {
+	for {
+		now := time.Now()
+		sc.Set("image", frameImage(now))
+		next := player.Next(now)
+		if player.Done(now) || next.IsZero() {
+			return
+		}
+		time.Sleep(time.Until(next))
 	}
-	defer surface.Destroy()
-	centerX := renderEvent.Stream.Width/2 - surface.Width()/2
-	stickyBottomY := renderEvent.Stream.Height - surface.Height()
-	renderEvent.CairoCtx.PaintSurface(surface, float64(centerX), float64(stickyBottomY))
 }
 
 func main() {
@@ -67,35 +56,42 @@ func main() {
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	app = acapapp.NewAcapApplication()
 
-	// Initialize image sequence
-	image_seq = NewImageSequence(24)
-
-	// Overlayprovider is an highlevel wrapper around AxOvleray to make life easier
-	if overlayProvider, err = axoverlay.NewOverlayProvider(renderCallback, adjustmentCallback, streamSelectCallback); err != nil {
+	// Load the frames of the animation, GIF and APNG frames are decoded to temporary PNG files
+	if anim, err = animation.Load(ANIMATION_PATH); err != nil {
 		panic(err)
 	}
-	app.AddCloseCleanFunc(overlayProvider.Cleanup)
-
-	// we pass app as userdata to access syslog from app in callbacks
-	if _, err = overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, app)); err != nil {
-		app.Syslog.Crit(err.Error())
+	app.AddCloseCleanFunc(func() {
+		if err := anim.Close(); err != nil {
+			app.Syslog.Errorf("Removing animation frames failed: %s", err.Error())
+		}
+	})
+	if player, err = animation.NewPlayer(anim, ANIMATION_FPS, ANIMATION_MODE); err != nil {
+		panic(err)
 	}
 
-	// Draw overlays
-	if err = overlayProvider.Redraw(); err != nil {
-		app.Syslog.Crit(err.Error())
+	// The image is placed with its bottom center at the bottom center of the stream,
+	// it is scaled from the reference resolution to each stream
+	sc = scene.New()
+	sc.Reference = scene.DEFAULT_REFERENCE
+	sc.Set("image", frameImage(time.Now()))
+
+	// The overlay wraps the overlay provider, it renders the scene on every stream
+	// and calls Redraw only when the scene changed. All frames are loaded as cairo surfaces up front.
+	if ov, err = overlay.New(sc); err != nil {
+		panic(err)
 	}
+	ov.OnError = func(err error) {
+		app.Syslog.Errorf("Overlay failed: %s", err.Error())
+	}
+	app.AddCloseCleanFunc(ov.Close)
+	if err = ov.Preload(anim.Frames...); err != nil {
+		panic(err)
+	}
+	ov.Start()
 
-	// Overlay update - increasing counter and call redraw to invoke a new render call
-	ticker := time.NewTicker(time.Millisecond * 300)
-	go func() {
-		for true {
-			<-ticker.C
-			if err = overlayProvider.Redraw(); err != nil {
-				app.Syslog.Crit(err.Error())
-			}
-		}
-	}()
+	// Overlay update - replacing the image node with the next frame triggers a redraw,
+	// the streams only draw the current frame and never advance the animation
+	go play()
 
 	// Run gmain loop with signal handler attached.
 	// This will block the main thread until the application is stopped.
@@ -6,58 +6,27 @@ import (
 
 	"github.com/Cacsjep/goxis/pkg/acapapp"
 	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/overlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
 )
 
-// This example demonstrate how to use overlay provider to draw overlays on the camera stream.
+// This example demonstrate how to use the overlay scene graph to draw overlays on the camera stream.
 //
 // Orginal C Example: https://github.com/AxisCommunications/acap-native-sdk-examples/tree/main/axoverlay
 // ! Note: Overlay callbacks only invoked when stream is viewed via web ui or rtsp etc..
 var (
-	app             *acapapp.AcapApplication
-	overlayProvider *axoverlay.OverlayProvider
-	err             error
-	overlay_id_rect int
-	overlay_id_text int
-	counter         int
+	app     *acapapp.AcapApplication
+	ov      *overlay.Overlay
+	sc      *scene.Scene
+	err     error
+	counter int
 )
 
-// streamSelectCallback can be used to select which streams to render overlays to.
-// Note that YCBCR streams are always skipped since these are used for analytics.
-// ! Just for demo demonstration
-func streamSelectCallback(streamSelectEvent *axoverlay.OverlayStreamSelectEvent) bool {
-	return true
-}
-
-// adjustmentCallback is called when an overlay needs adjustments.
-// This let developers make adjustments to the size and position of their overlays for each stream.
-// This callback function is called prior to rendering every time when an overlay
-// is rendered on a stream, which is useful if the resolution has been
-// updated or rotation has changed.
-func adjustmentCallback(adjustmentEvent *axoverlay.OverlayAdjustmentEvent) {
-	app := adjustmentEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Adjust callback for overlay-%d: %dx%d", adjustmentEvent.OverlayId, adjustmentEvent.OverlayWidth, adjustmentEvent.OverlayHeight)
-	app.Syslog.Infof("Adjust callback for stream: %dx%d", adjustmentEvent.Stream.Width, adjustmentEvent.Stream.Height)
-
-	*adjustmentEvent.OverlayWidth = adjustmentEvent.Stream.Width
-	*adjustmentEvent.OverlayHeight = adjustmentEvent.Stream.Height
-}
-
-// renderCallback is called whenever the system redraws an overlay
-// This can happen in two cases, Redraw() is called or a new stream is started.
-func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
-	app := renderEvent.Userdata.(*acapapp.AcapApplication)
-	app.Syslog.Infof("Render callback for camera: %d", renderEvent.Stream.Camera)
-	app.Syslog.Infof("Render callback for overlay-%d: %dx%d", renderEvent.OverlayId, renderEvent.OverlayWidth, renderEvent.OverlayHeight)
-	app.Syslog.Infof("Render callback for stream: %dx%d", renderEvent.Stream.Width, renderEvent.Stream.Height)
-
-	if renderEvent.OverlayId == overlay_id_text {
-		renderEvent.CairoCtx.DrawText(fmt.Sprintf("Counter: %d", counter), 10, 10, 32.0, "serif", axoverlay.ColorBlack)
-	} else if renderEvent.OverlayId == overlay_id_rect {
-		renderEvent.CairoCtx.DrawTransparent(renderEvent.Stream.Width, renderEvent.Stream.Height)
-		renderEvent.CairoCtx.DrawRect(0, 0, float64(renderEvent.Stream.Width), float64(renderEvent.Stream.Height/4), axoverlay.ColorMaterialRed, 9.6)
-		renderEvent.CairoCtx.DrawRect(0, float64(renderEvent.Stream.Height*3/4), float64(renderEvent.Stream.Width), float64(renderEvent.Stream.Height), axoverlay.ColorMaterialRed, 9.6)
-	} else {
-		app.Syslog.Warn("Unknown overlay id!")
+// counterText returns the counter in the top left corner of the stream.
+func counterText() scene.Text {
+	return scene.Text{
+		Text:      fmt.Sprintf("Counter: %d", counter),
+		TextStyle: scene.TextStyle{Size: 32, Font: "serif", Color: axoverlay.ColorBlack, Padding: 10},
 	}
 }
 
@@ -67,35 +36,35 @@ func main() {
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	app = acapapp.NewAcapApplication()
 
-	// Overlayprovider is an highlevel wrapper around AxOvleray to make life easier
-	if overlayProvider, err = axoverlay.NewOverlayProvider(renderCallback, adjustmentCallback, streamSelectCallback); err != nil {
+	// The scene holds what is drawn, in coordinates from 0 to 1 which are scaled to each stream.
+	// A red rect over the top and the bottom quarter of the stream and the counter text.
+	// The font size, line widths and padding are scaled from the reference resolution to each stream,
+	// so the counter covers the same part of a 4K stream and of a 480x270 stream.
+	sc = scene.New()
+	sc.Reference = scene.DEFAULT_REFERENCE
+	sc.Set("rects", scene.Group{Nodes: []scene.Node{
+		scene.Rect{Pos: scene.Point{X: 0, Y: 0}, Size: scene.Point{X: 1, Y: 0.25}, Style: scene.Style{Stroke: axoverlay.ColorMaterialRed, LineWidth: 9.6}},
+		scene.Rect{Pos: scene.Point{X: 0, Y: 0.75}, Size: scene.Point{X: 1, Y: 0.25}, Style: scene.Style{Stroke: axoverlay.ColorMaterialRed, LineWidth: 9.6}},
+	}})
+	sc.Set("counter", counterText())
+
+	// The overlay wraps the overlay provider, it renders the scene on every stream
+	// and calls Redraw only when the scene changed.
+	if ov, err = overlay.New(sc); err != nil {
 		panic(err)
 	}
-	app.AddCloseCleanFunc(overlayProvider.Cleanup)
-
-	// we pass app as userdata to access syslog from app in callbacks
-	if overlay_id_rect, err = overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayCustomNormalized, app)); err != nil {
-		app.Syslog.Crit(err.Error())
-	}
-
-	// we pass app as userdata to access syslog from app in callbacks
-	if overlay_id_text, err = overlayProvider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, app)); err != nil {
-		app.Syslog.Crit(err.Error())
-	}
-
-	// Draw overlays
-	if err = overlayProvider.Redraw(); err != nil {
-		app.Syslog.Crit(err.Error())
+	ov.OnError = func(err error) {
+		app.Syslog.Errorf("Overlay failed: %s", err.Error())
 	}
+	app.AddCloseCleanFunc(ov.Close)
+	ov.Start()
 
-	// Overlay update - increasing counter and call redraw to invoke a new render call
+	// Overlay update - increasing counter, replacing the text node triggers a redraw
 	go func() {
 		for true {
 			time.Sleep(time.Second * 1)
 			counter++
-			if err = overlayProvider.Redraw(); err != nil {
-				app.Syslog.Crit(err.Error())
-			}
+			sc.Set("counter", counterText())
 		}
 	}()
 
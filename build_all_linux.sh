@@ -3,10 +3,10 @@ go install github.com/Cacsjep/goxisbuilder@v1.2.6
 goxisbuilder -appdir "./axevent/send"
 goxisbuilder -appdir "./axevent/subscribe"
 goxisbuilder -appdir "./axevent/multiple_subscribe"
-goxisbuilder -appdir "./axlarod/classify" -files converted_model.tflite
+goxisbuilder -appdir "./axlarod/classify" -files "converted_model.tflite converted_model.txt"
 goxisbuilder -appdir "./axlarod/object_detection" -files ssd_mobilenet_v2_coco_quant_postprocess.tflite
 goxisbuilder -appdir "./axlarod/face_tracking" -files ssd_mobilenet_v2_face_quant_postprocess.tflite
-goxisbuilder -appdir "./axlarod/yolov5" -files yolov5n.tflite
+goxisbuilder -appdir "./axlarod/yolov5" -files "yolov5n.tflite yolov5n.txt"
 goxisbuilder -appdir "./axlicense" 
 goxisbuilder -appdir "./axoverlay/rects_text"
 goxisbuilder -appdir "./axoverlay/pixel_array"
@@ -2,10 +2,10 @@ go install github.com/Cacsjep/goxisbuilder@v1.2.6
 goxisbuilder.exe -appdir "./axevent/send"
 goxisbuilder.exe -appdir "./axevent/subscribe"
 goxisbuilder.exe -appdir "./axevent/multiple_subscribe"
-goxisbuilder.exe -appdir "./axlarod/classify" -files converted_model.tflite
+goxisbuilder.exe -appdir "./axlarod/classify" -files "converted_model.tflite converted_model.txt"
 goxisbuilder.exe -appdir "./axlarod/object_detection" -files ssd_mobilenet_v2_coco_quant_postprocess.tflite
 goxisbuilder.exe -appdir "./axlarod/face_tracking" -files ssd_mobilenet_v2_face_quant_postprocess.tflite
-goxisbuilder.exe -appdir "./axlarod/yolov5" -files yolov5n.tflite
+goxisbuilder.exe -appdir "./axlarod/yolov5" -files "yolov5n.tflite yolov5n.txt"
 goxisbuilder.exe -appdir "./axlicense" 
 goxisbuilder.exe -appdir "./axoverlay/pixel_array"
 goxisbuilder.exe -appdir "./axoverlay/rects_text"
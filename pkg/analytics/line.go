@@ -0,0 +1,199 @@
+// Package analytics provides movement analytics like line crossing counting on top of tracked objects,
+// and rules which turn classification scores into stable states for events.
+//
+// All coordinates are normalized to the model input, the same way as the boxes of the tracker package.
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Point is a point in normalized coordinates.
+type Point struct {
+	X, Y float32
+}
+
+// TrackPoint is the observed position of a track in the current frame.
+type TrackPoint struct {
+	ID    int       // Track ID
+	Class int       // Class index of the track
+	X, Y  float32   // Position, usually the box center
+	Time  time.Time // Time of the observation
+}
+
+// Direction is the direction a track crossed a line in.
+type Direction int
+
+const (
+	DirectionIn  Direction = iota // From the left to the right side of the line, looking from A to B
+	DirectionOut                  // From the right to the left side of the line, looking from A to B
+)
+
+// String returns a readable name of the direction.
+func (d Direction) String() string {
+	if d == DirectionIn {
+		return "in"
+	}
+	return "out"
+}
+
+// CountingLine is a virtual line with in and out counters.
+// The line goes from A to B, with image coordinates (y pointing down) the right side is the side
+// the right hand points to when walking from A to B, e.g. below a line from the left to the right edge.
+// With a DeadBand a track has to move at least that distance past the line before it is counted, and it is only
+// counted again after it moved the same distance past the line on the other side, so a track jittering on the
+// line is not counted in, out and in again.
+type CountingLine struct {
+	Name     string  // Name of the line, used in events
+	A, B     Point   // Start and end point of the line
+	Classes  []int   // Classes counted by the line, nil counts all classes
+	DeadBand float32 // Minimum distance of a track to the line to be on a side, 0 counts every change of the side
+	In       int     // Crossings from the left to the right side
+	Out      int     // Crossings from the right to the left side
+}
+
+// counts reports if a class is counted by the line.
+func (l *CountingLine) counts(class int) bool {
+	if l.Classes == nil {
+		return true
+	}
+	for _, c := range l.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// side returns the side of a point relative to the line, positive on the right and negative on the left side.
+func (l *CountingLine) side(p Point) float32 {
+	return cross(l.A, l.B, p)
+}
+
+// outside returns true if a point is at least the dead band away from the line.
+func (l *CountingLine) outside(p Point) bool {
+	if l.DeadBand <= 0 {
+		return true
+	}
+	length := math.Hypot(float64(l.B.X-l.A.X), float64(l.B.Y-l.A.Y))
+	return math.Abs(float64(l.side(p))) >= float64(l.DeadBand)*length
+}
+
+// Crossed checks if the movement from p to q crosses the line segment.
+// Returns:
+//
+//	Direction: The direction of the crossing.
+//	bool: True if the movement crosses the line.
+//
+// Behavior:
+//   - A point exactly on the line counts as the right side, so a movement that ends on the line and
+//     continues later is only counted once.
+//   - A movement that only crosses the infinite extension of the line is not counted.
+func (l *CountingLine) Crossed(p, q Point) (Direction, bool) {
+	fromRight := l.side(p) >= 0
+	toRight := l.side(q) >= 0
+	if fromRight == toRight {
+		return DirectionIn, false
+	}
+
+	// The line end points must be on different sides of the movement (or on it)
+	a := cross(p, q, l.A)
+	b := cross(p, q, l.B)
+	if (a > 0 && b > 0) || (a < 0 && b < 0) {
+		return DirectionIn, false
+	}
+
+	if toRight {
+		return DirectionIn, true
+	}
+	return DirectionOut, true
+}
+
+// LineCrossing is a single crossing of a track over a counting line.
+type LineCrossing struct {
+	Line      *CountingLine // The crossed line, counters are already updated
+	TrackID   int           // ID of the crossing track
+	Class     int           // Class of the crossing track
+	Direction Direction     // Direction of the crossing
+	Time      time.Time     // Time of the observation after the crossing
+}
+
+// lineTrack identifies a track on a line.
+type lineTrack struct {
+	line *CountingLine
+	id   int
+}
+
+// LineCounter counts tracks crossing a set of virtual lines.
+// It remembers the last observed position of each track outside of the dead band of each line and checks the
+// movement to the next observed position outside of the dead band against the line, so tracks that coast
+// without observations are counted once they are observed again.
+type LineCounter struct {
+	Lines []*CountingLine     // Lines to count on
+	last  map[lineTrack]Point // Last observed position outside of the dead band by line and track ID
+}
+
+// NewLineCounter creates a line counter for the given lines.
+func NewLineCounter(lines ...*CountingLine) *LineCounter {
+	return &LineCounter{Lines: lines, last: make(map[lineTrack]Point)}
+}
+
+// Update checks the movement of each observed track since its last observation against all lines.
+// Observations within the dead band of a line are skipped for that line.
+// Args:
+//   - points: The observed positions of the tracks in the current frame.
+//
+// Returns:
+//
+//	[]LineCrossing: The crossings in this frame, the counters of the lines are already incremented.
+func (lc *LineCounter) Update(points []TrackPoint) []LineCrossing {
+	var crossings []LineCrossing
+	for _, p := range points {
+		to := Point{X: p.X, Y: p.Y}
+		for _, line := range lc.Lines {
+			if !line.counts(p.Class) || !line.outside(to) {
+				continue
+			}
+			key := lineTrack{line: line, id: p.ID}
+			from, known := lc.last[key]
+			lc.last[key] = to
+			if !known {
+				continue // First observation of the track outside of the dead band
+			}
+			direction, crossed := line.Crossed(from, to)
+			if !crossed {
+				continue
+			}
+			if direction == DirectionIn {
+				line.In++
+			} else {
+				line.Out++
+			}
+			crossings = append(crossings, LineCrossing{Line: line, TrackID: p.ID, Class: p.Class, Direction: direction, Time: p.Time})
+		}
+	}
+	return crossings
+}
+
+// Remove forgets the last positions of a track, should be called when the track is removed from the tracker.
+func (lc *LineCounter) Remove(trackID int) {
+	for key := range lc.last {
+		if key.id == trackID {
+			delete(lc.last, key)
+		}
+	}
+}
+
+// Reset sets all counters to zero and forgets all track positions.
+func (lc *LineCounter) Reset() {
+	for _, line := range lc.Lines {
+		line.In, line.Out = 0, 0
+	}
+	lc.last = make(map[lineTrack]Point)
+}
+
+// cross computes the z component of the cross product (b - a) x (p - a).
+func cross(a, b, p Point) float32 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
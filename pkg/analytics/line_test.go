@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+// horizontalLine returns a line across the middle of the image, the right side is below the line.
+func horizontalLine() *CountingLine {
+	return &CountingLine{Name: "door", A: Point{X: 0.1, Y: 0.5}, B: Point{X: 0.9, Y: 0.5}}
+}
+
+func TestCountingLineCrossed(t *testing.T) {
+	tests := []struct {
+		name      string
+		p, q      Point
+		crossed   bool
+		direction Direction
+	}{
+		{name: "downwards is in", p: Point{X: 0.5, Y: 0.4}, q: Point{X: 0.5, Y: 0.6}, crossed: true, direction: DirectionIn},
+		{name: "upwards is out", p: Point{X: 0.5, Y: 0.6}, q: Point{X: 0.5, Y: 0.4}, crossed: true, direction: DirectionOut},
+		{name: "diagonal through the segment", p: Point{X: 0.2, Y: 0.3}, q: Point{X: 0.4, Y: 0.7}, crossed: true, direction: DirectionIn},
+		{name: "beyond the end point", p: Point{X: 0.95, Y: 0.4}, q: Point{X: 0.95, Y: 0.6}},
+		{name: "before the start point", p: Point{X: 0.05, Y: 0.6}, q: Point{X: 0.05, Y: 0.4}},
+		{name: "diagonal passing the end point", p: Point{X: 0.85, Y: 0.3}, q: Point{X: 1.0, Y: 0.6}},
+		{name: "same side", p: Point{X: 0.5, Y: 0.1}, q: Point{X: 0.6, Y: 0.4}},
+		{name: "ending exactly on the line is in", p: Point{X: 0.5, Y: 0.4}, q: Point{X: 0.5, Y: 0.5}, crossed: true, direction: DirectionIn},
+		{name: "leaving the line downwards is not counted again", p: Point{X: 0.5, Y: 0.5}, q: Point{X: 0.5, Y: 0.6}},
+		{name: "leaving the line upwards is out", p: Point{X: 0.5, Y: 0.5}, q: Point{X: 0.5, Y: 0.4}, crossed: true, direction: DirectionOut},
+		{name: "through the end point", p: Point{X: 0.9, Y: 0.4}, q: Point{X: 0.9, Y: 0.6}, crossed: true, direction: DirectionIn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			direction, crossed := horizontalLine().Crossed(tt.p, tt.q)
+			if crossed != tt.crossed || (crossed && direction != tt.direction) {
+				t.Errorf("Crossed(%v, %v) = %v, %v, want %v, %v", tt.p, tt.q, direction, crossed, tt.direction, tt.crossed)
+			}
+		})
+	}
+}
+
+// trajectory returns the observations of a track moving through points, one per frame.
+func trajectory(id, class int, start time.Time, points ...Point) [][]TrackPoint {
+	frames := make([][]TrackPoint, len(points))
+	for i, p := range points {
+		frames[i] = []TrackPoint{{ID: id, Class: class, X: p.X, Y: p.Y, Time: start.Add(time.Duration(i) * 100 * time.Millisecond)}}
+	}
+	return frames
+}
+
+func TestLineCounterUpdate(t *testing.T) {
+	start := time.Now()
+	people := horizontalLine()
+	people.Classes = []int{0}
+	all := &CountingLine{Name: "all", A: Point{X: 0.5, Y: 0.1}, B: Point{X: 0.5, Y: 0.9}}
+	lc := NewLineCounter(people, all)
+
+	// A person (class 0) walks down through both lines, a car (class 2) drives up and back down
+	// through the people line and left through the all line
+	person := trajectory(1, 0, start, Point{X: 0.3, Y: 0.3}, Point{X: 0.4, Y: 0.45}, Point{X: 0.45, Y: 0.5}, Point{X: 0.6, Y: 0.7})
+	car := trajectory(2, 2, start, Point{X: 0.7, Y: 0.7}, Point{X: 0.6, Y: 0.3}, Point{X: 0.4, Y: 0.6}, Point{X: 0.3, Y: 0.6})
+
+	var crossings []LineCrossing
+	for i := range person {
+		crossings = append(crossings, lc.Update(append(person[i], car[i]...))...)
+	}
+
+	if people.In != 1 || people.Out != 0 {
+		t.Errorf("people line in %d out %d, want the person in once and the car not counted", people.In, people.Out)
+	}
+	// The all line goes down, its right side is the left of the image
+	if all.In != 1 || all.Out != 1 {
+		t.Errorf("all line in %d out %d, want the car in once and the person out once", all.In, all.Out)
+	}
+	if len(crossings) != 3 {
+		t.Fatalf("got %d crossings, want 3: %+v", len(crossings), crossings)
+	}
+	for _, c := range crossings {
+		if c.Line == people && c.TrackID != 1 {
+			t.Errorf("people line crossed by track %d", c.TrackID)
+		}
+	}
+
+	// A removed track starts over, its next observation is only the first position
+	lc.Remove(1)
+	if got := lc.Update([]TrackPoint{{ID: 1, X: 0.3, Y: 0.3}}); len(got) != 0 {
+		t.Errorf("first observation after Remove counted: %+v", got)
+	}
+
+	lc.Reset()
+	if people.In != 0 || all.In != 0 || all.Out != 0 {
+		t.Error("Reset did not clear the counters")
+	}
+}
+
+func TestLineCounterDeadBand(t *testing.T) {
+	start := time.Now()
+	// A track walks down to the line, jitters across it and then continues down, then comes back up
+	jitter := []Point{
+		{X: 0.5, Y: 0.3}, {X: 0.5, Y: 0.44}, {X: 0.5, Y: 0.51}, {X: 0.5, Y: 0.49}, {X: 0.5, Y: 0.52},
+		{X: 0.5, Y: 0.48}, {X: 0.5, Y: 0.56}, {X: 0.5, Y: 0.53}, {X: 0.5, Y: 0.47}, {X: 0.5, Y: 0.57},
+		{X: 0.5, Y: 0.7}, {X: 0.5, Y: 0.4},
+	}
+	tests := []struct {
+		name     string
+		deadBand float32
+		in, out  int
+	}{
+		{name: "without dead band every change of the side is counted", in: 4, out: 4},
+		{name: "dead band counts once in and once out", deadBand: 0.05, in: 1, out: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := horizontalLine()
+			line.DeadBand = tt.deadBand
+			lc := NewLineCounter(line)
+			for _, frame := range trajectory(1, 0, start, jitter...) {
+				lc.Update(frame)
+			}
+			if line.In != tt.in || line.Out != tt.out {
+				t.Errorf("got in %d out %d, want in %d out %d", line.In, line.Out, tt.in, tt.out)
+			}
+		})
+	}
+
+	// A track that first appears within the dead band is counted once it is outside of it on both sides
+	line := horizontalLine()
+	line.DeadBand = 0.05
+	lc := NewLineCounter(line)
+	for _, frame := range trajectory(1, 0, start, Point{X: 0.5, Y: 0.52}, Point{X: 0.5, Y: 0.4}, Point{X: 0.5, Y: 0.6}) {
+		lc.Update(frame)
+	}
+	if line.In != 1 || line.Out != 0 {
+		t.Errorf("got in %d out %d for a track starting on the line, want in 1", line.In, line.Out)
+	}
+}
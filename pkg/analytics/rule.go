@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClassRule turns the noisy score of a class into a stable active state, e.g. "person > 70% for 2s".
+// The rule activates when the score stays above OnThreshold for OnDelay, and deactivates when the score
+// stays below OffThreshold for OffDelay, but not before it was active for MinHold.
+// Fields:
+//   - Label: Label of the class, matched against the labels of the classifier.
+//   - OnThreshold: Score above which the rule activates.
+//   - OffThreshold: Score below which the rule deactivates, lower than OnThreshold for hysteresis.
+//   - OnDelay: How long the score must stay above OnThreshold.
+//   - OffDelay: How long the score must stay below OffThreshold.
+//   - MinHold: Minimum time the rule stays active, so short activations still trigger action rules.
+type ClassRule struct {
+	Label        string
+	OnThreshold  float32
+	OffThreshold float32
+	OnDelay      time.Duration
+	OffDelay     time.Duration
+	MinHold      time.Duration
+	active       bool
+	pending      time.Time // Time since the score passed the threshold towards the other state, zero if it did not
+	activated    time.Time // Time of the last activation
+}
+
+// ParseClassRule parses a rule like "person > 70% for 2s" or "car > 0.6".
+// The threshold is a percentage or a fraction, the optional duration is the OnDelay.
+// The OffThreshold is set to the OnThreshold, OffDelay and MinHold are zero.
+func ParseClassRule(s string) (*ClassRule, error) {
+	label, condition, ok := strings.Cut(s, ">")
+	if !ok {
+		return nil, fmt.Errorf("rule %q: expected <label> > <threshold> [for <duration>]", s)
+	}
+	rule := &ClassRule{Label: strings.TrimSpace(label)}
+	if rule.Label == "" {
+		return nil, fmt.Errorf("rule %q: missing label", s)
+	}
+
+	threshold, delay, hasDelay := strings.Cut(condition, " for ")
+	threshold = strings.TrimSpace(threshold)
+	number, scale := threshold, 1.0
+	if strings.HasSuffix(number, "%") {
+		number, scale = strings.TrimSpace(strings.TrimSuffix(number, "%")), 0.01
+	}
+	value, err := strconv.ParseFloat(number, 32)
+	if err != nil || value*scale < 0 || value*scale > 1 {
+		return nil, fmt.Errorf("rule %q: invalid threshold %q", s, threshold)
+	}
+	rule.OnThreshold = float32(value * scale)
+	rule.OffThreshold = rule.OnThreshold
+
+	if hasDelay {
+		// Allow a space between the value and the unit, e.g. "2 s"
+		if rule.OnDelay, err = time.ParseDuration(strings.ReplaceAll(delay, " ", "")); err != nil || rule.OnDelay < 0 {
+			return nil, fmt.Errorf("rule %q: invalid duration %q", s, strings.TrimSpace(delay))
+		}
+	}
+	return rule, nil
+}
+
+// Update feeds the score of the current frame into the rule.
+// Returns true if the active state changed.
+func (r *ClassRule) Update(score float32, t time.Time) bool {
+	var passed bool
+	var delay time.Duration
+	if r.active {
+		passed, delay = score < r.OffThreshold, r.OffDelay
+	} else {
+		passed, delay = score > r.OnThreshold, r.OnDelay
+	}
+	if !passed {
+		r.pending = time.Time{}
+		return false
+	}
+	if r.pending.IsZero() {
+		r.pending = t
+	}
+	if t.Sub(r.pending) < delay {
+		return false
+	}
+	if r.active && t.Sub(r.activated) < r.MinHold {
+		return false
+	}
+
+	r.active = !r.active
+	r.pending = time.Time{}
+	if r.active {
+		r.activated = t
+	}
+	return true
+}
+
+// Active returns true if the rule is active.
+func (r *ClassRule) Active() bool {
+	return r.active
+}
+
+// Reset deactivates the rule without reporting a change.
+func (r *ClassRule) Reset() {
+	r.active = false
+	r.pending = time.Time{}
+}
+
+// String returns the rule in the format of ParseClassRule.
+func (r *ClassRule) String() string {
+	s := fmt.Sprintf("%s > %.4g%%", r.Label, r.OnThreshold*100)
+	if r.OnDelay > 0 {
+		s += " for " + r.OnDelay.String()
+	}
+	return s
+}
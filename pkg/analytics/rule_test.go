@@ -0,0 +1,153 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClassRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    ClassRule
+		wantErr string
+	}{
+		{rule: "person > 70% for 2s", want: ClassRule{Label: "person", OnThreshold: 0.7, OffThreshold: 0.7, OnDelay: 2 * time.Second}},
+		{rule: "car > 0.6", want: ClassRule{Label: "car", OnThreshold: 0.6, OffThreshold: 0.6}},
+		{rule: " fire truck>50 % for 1 s ", want: ClassRule{Label: "fire truck", OnThreshold: 0.5, OffThreshold: 0.5, OnDelay: time.Second}},
+		{rule: "person > 100% for 500ms", want: ClassRule{Label: "person", OnThreshold: 1, OffThreshold: 1, OnDelay: 500 * time.Millisecond}},
+		{rule: "person 70%", wantErr: "expected <label> > <threshold>"},
+		{rule: " > 70%", wantErr: "missing label"},
+		{rule: "person > 170%", wantErr: "invalid threshold"},
+		{rule: "person > -0.1", wantErr: "invalid threshold"},
+		{rule: "person > many", wantErr: "invalid threshold"},
+		{rule: "person > 70% for soon", wantErr: "invalid duration"},
+		{rule: "person > 70% for -2s", wantErr: "invalid duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseClassRule(tt.rule)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *rule != tt.want {
+				t.Errorf("got %+v, want %+v", *rule, tt.want)
+			}
+			// String returns a rule which parses to the same rule
+			again, err := ParseClassRule(rule.String())
+			if err != nil || *again != *rule {
+				t.Errorf("%q parsed to %+v, %v", rule.String(), again, err)
+			}
+		})
+	}
+}
+
+// ruleStep is a score fed into a rule at an offset from the start with the expected state afterwards.
+type ruleStep struct {
+	at      time.Duration
+	score   float32
+	changed bool
+	active  bool
+}
+
+func TestClassRuleUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  ClassRule
+		steps []ruleStep
+	}{
+		{
+			name: "on delay",
+			rule: ClassRule{OnThreshold: 0.7, OffThreshold: 0.5, OnDelay: 2 * time.Second},
+			steps: []ruleStep{
+				{at: 0, score: 0.8},
+				{at: 1500 * time.Millisecond, score: 0.8},
+				{at: 1600 * time.Millisecond, score: 0.6}, // Drops below the threshold, the delay starts again
+				{at: 2 * time.Second, score: 0.8},
+				{at: 3900 * time.Millisecond, score: 0.8},
+				{at: 4 * time.Second, score: 0.8, changed: true, active: true},
+				{at: 5 * time.Second, score: 0.8, active: true},
+			},
+		},
+		{
+			name: "score on the threshold does not activate",
+			rule: ClassRule{OnThreshold: 0.7, OffThreshold: 0.5},
+			steps: []ruleStep{
+				{at: 0, score: 0.7},
+				{at: time.Second, score: 0.71, changed: true, active: true},
+			},
+		},
+		{
+			name: "hysteresis and off delay",
+			rule: ClassRule{OnThreshold: 0.7, OffThreshold: 0.5, OffDelay: time.Second},
+			steps: []ruleStep{
+				{at: 0, score: 0.8, changed: true, active: true},
+				{at: time.Second, score: 0.6, active: true}, // Between the thresholds
+				{at: 2 * time.Second, score: 0.4, active: true},
+				{at: 2500 * time.Millisecond, score: 0.6, active: true}, // Back above the off threshold, the delay starts again
+				{at: 3 * time.Second, score: 0.4, active: true},
+				{at: 3900 * time.Millisecond, score: 0.4, active: true},
+				{at: 4 * time.Second, score: 0.4, changed: true},
+				{at: 5 * time.Second, score: 0.6}, // Below the on threshold
+			},
+		},
+		{
+			name: "min hold",
+			rule: ClassRule{OnThreshold: 0.7, OffThreshold: 0.5, MinHold: 5 * time.Second},
+			steps: []ruleStep{
+				{at: 0, score: 0.8, changed: true, active: true},
+				{at: time.Second, score: 0.1, active: true},
+				{at: 4900 * time.Millisecond, score: 0.1, active: true},
+				{at: 5 * time.Second, score: 0.1, changed: true},
+				{at: 6 * time.Second, score: 0.8, changed: true, active: true}, // Held again from the new activation
+				{at: 7 * time.Second, score: 0.1, active: true},
+			},
+		},
+		{
+			name: "off delay longer than min hold",
+			rule: ClassRule{OnThreshold: 0.7, OffThreshold: 0.5, OffDelay: 3 * time.Second, MinHold: time.Second},
+			steps: []ruleStep{
+				{at: 0, score: 0.8, changed: true, active: true},
+				{at: 500 * time.Millisecond, score: 0.1, active: true},
+				{at: 3 * time.Second, score: 0.1, active: true},
+				{at: 3500 * time.Millisecond, score: 0.1, changed: true},
+			},
+		},
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			for _, step := range tt.steps {
+				changed := rule.Update(step.score, start.Add(step.at))
+				if changed != step.changed || rule.Active() != step.active {
+					t.Fatalf("at %s score %.2f: changed %t, active %t, want %t, %t", step.at, step.score, changed, rule.Active(), step.changed, step.active)
+				}
+			}
+		})
+	}
+}
+
+func TestClassRuleReset(t *testing.T) {
+	rule := ClassRule{OnThreshold: 0.5, OffThreshold: 0.5, OnDelay: time.Second}
+	start := time.Now()
+	rule.Update(0.9, start)
+	rule.Update(0.9, start.Add(time.Second))
+	if !rule.Active() {
+		t.Fatal("rule not active")
+	}
+	rule.Reset()
+	if rule.Active() {
+		t.Fatal("rule active after reset")
+	}
+	// The on delay starts again after a reset
+	if rule.Update(0.9, start.Add(2*time.Second)) {
+		t.Error("rule activated without the on delay")
+	}
+}
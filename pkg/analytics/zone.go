@@ -0,0 +1,192 @@
+package analytics
+
+import "time"
+
+// ZoneEventType is the type of a zone event.
+type ZoneEventType int
+
+const (
+	ZoneEnter       ZoneEventType = iota // Track entered the zone
+	ZoneExit                             // Track left the zone or was removed
+	ZoneLoiterStart                      // Dwell time of the track exceeded the loitering threshold
+	ZoneLoiterStop                       // Loitering track left the zone or was removed
+)
+
+// String returns a readable name of the zone event type.
+func (t ZoneEventType) String() string {
+	switch t {
+	case ZoneEnter:
+		return "enter"
+	case ZoneExit:
+		return "exit"
+	case ZoneLoiterStart:
+		return "loiter start"
+	case ZoneLoiterStop:
+		return "loiter stop"
+	}
+	return "unknown"
+}
+
+// ZoneEvent is a single change of a track in a zone.
+type ZoneEvent struct {
+	Zone    *Zone         // The zone of the event
+	TrackID int           // ID of the track
+	Class   int           // Class of the track
+	Type    ZoneEventType // Type of the event
+	Dwell   time.Duration // Dwell time of the track in the zone at the time of the event
+	Time    time.Time     // Time of the observation
+}
+
+// zoneVisit is the stay of a single track inside a zone.
+type zoneVisit struct {
+	class     int
+	entered   time.Time
+	last      time.Time
+	loitering bool
+}
+
+// Zone is a polygon area with occupancy, dwell time and loitering analytics.
+// Fields:
+//   - Name: Name of the zone, used in events.
+//   - Polygon: Corner points of the zone, the polygon is closed automatically.
+//   - Classes: Classes monitored by the zone, nil monitors all classes.
+//   - LoiterThreshold: Dwell time after which a track is loitering, 0 disables loitering.
+type Zone struct {
+	Name            string
+	Polygon         []Point
+	Classes         []int
+	LoiterThreshold time.Duration
+	visits          map[int]*zoneVisit // Tracks inside the zone by track ID
+}
+
+// Contains checks if a point is inside the zone polygon using the even-odd rule.
+func (z *Zone) Contains(p Point) bool {
+	inside := false
+	n := len(z.Polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := z.Polygon[i], z.Polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Occupancy returns the number of tracks inside the zone.
+func (z *Zone) Occupancy() int {
+	return len(z.visits)
+}
+
+// Dwell returns how long a track has been inside the zone, up to its last observation.
+// Returns false if the track is not inside the zone.
+func (z *Zone) Dwell(trackID int) (time.Duration, bool) {
+	visit, ok := z.visits[trackID]
+	if !ok {
+		return 0, false
+	}
+	return visit.last.Sub(visit.entered), true
+}
+
+// Loitering reports if any track inside the zone is loitering.
+func (z *Zone) Loitering() bool {
+	for _, visit := range z.visits {
+		if visit.loitering {
+			return true
+		}
+	}
+	return false
+}
+
+// monitors reports if a class is monitored by the zone.
+func (z *Zone) monitors(class int) bool {
+	if z.Classes == nil {
+		return true
+	}
+	for _, c := range z.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// leave removes a track from the zone and returns its exit events.
+func (z *Zone) leave(trackID int, t time.Time) []ZoneEvent {
+	visit, ok := z.visits[trackID]
+	if !ok {
+		return nil
+	}
+	delete(z.visits, trackID)
+
+	var events []ZoneEvent
+	if visit.loitering {
+		events = append(events, ZoneEvent{Zone: z, TrackID: trackID, Class: visit.class, Type: ZoneLoiterStop, Dwell: t.Sub(visit.entered), Time: t})
+	}
+	return append(events, ZoneEvent{Zone: z, TrackID: trackID, Class: visit.class, Type: ZoneExit, Dwell: t.Sub(visit.entered), Time: t})
+}
+
+// ZoneMonitor keeps track of the tracks inside a set of zones.
+// Tracks that are not observed in a frame (e.g. coasting tracks) keep their zone state until they are
+// observed again or removed, so short occlusions do not reset the dwell time.
+type ZoneMonitor struct {
+	Zones []*Zone // Monitored zones, zones may also be appended later
+}
+
+// NewZoneMonitor creates a zone monitor for the given zones.
+func NewZoneMonitor(zones ...*Zone) *ZoneMonitor {
+	return &ZoneMonitor{Zones: zones}
+}
+
+// Update checks the observed positions of the tracks against all zones.
+// Args:
+//   - points: The observed positions of the tracks in the current frame.
+//
+// Returns:
+//
+//	[]ZoneEvent: Enter, exit and loitering changes in this frame.
+func (zm *ZoneMonitor) Update(points []TrackPoint) []ZoneEvent {
+	var events []ZoneEvent
+	for _, zone := range zm.Zones {
+		for _, p := range points {
+			if !zone.monitors(p.Class) {
+				continue
+			}
+			visit, inside := zone.visits[p.ID]
+
+			if !zone.Contains(Point{X: p.X, Y: p.Y}) {
+				if inside {
+					events = append(events, zone.leave(p.ID, p.Time)...)
+				}
+				continue
+			}
+
+			if !inside {
+				// The visits are allocated on the first visit, so zones do not need a constructor
+				if zone.visits == nil {
+					zone.visits = make(map[int]*zoneVisit)
+				}
+				visit = &zoneVisit{class: p.Class, entered: p.Time}
+				zone.visits[p.ID] = visit
+				events = append(events, ZoneEvent{Zone: zone, TrackID: p.ID, Class: p.Class, Type: ZoneEnter, Time: p.Time})
+			}
+			visit.last = p.Time
+
+			dwell := p.Time.Sub(visit.entered)
+			if zone.LoiterThreshold > 0 && !visit.loitering && dwell >= zone.LoiterThreshold {
+				visit.loitering = true
+				events = append(events, ZoneEvent{Zone: zone, TrackID: p.ID, Class: p.Class, Type: ZoneLoiterStart, Dwell: dwell, Time: p.Time})
+			}
+		}
+	}
+	return events
+}
+
+// Remove takes a track out of all zones, should be called when the track is removed from the tracker.
+// Returns the exit events of the zones the track was inside.
+func (zm *ZoneMonitor) Remove(trackID int, t time.Time) []ZoneEvent {
+	var events []ZoneEvent
+	for _, zone := range zm.Zones {
+		events = append(events, zone.leave(trackID, t)...)
+	}
+	return events
+}
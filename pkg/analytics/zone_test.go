@@ -0,0 +1,154 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+// lZone returns an L shaped zone, the upper right quarter of the square is cut out.
+func lZone() *Zone {
+	return &Zone{
+		Name:    "l",
+		Polygon: []Point{{X: 0, Y: 0}, {X: 0.4, Y: 0}, {X: 0.4, Y: 0.6}, {X: 1, Y: 0.6}, {X: 1, Y: 1}, {X: 0, Y: 1}},
+	}
+}
+
+func TestZoneContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      Point
+		inside bool
+	}{
+		{name: "vertical bar", p: Point{X: 0.2, Y: 0.2}, inside: true},
+		{name: "horizontal bar", p: Point{X: 0.8, Y: 0.8}, inside: true},
+		{name: "corner", p: Point{X: 0.2, Y: 0.8}, inside: true},
+		{name: "concave notch", p: Point{X: 0.8, Y: 0.2}},
+		{name: "notch next to the inner corner", p: Point{X: 0.41, Y: 0.59}},
+		{name: "left of the zone", p: Point{X: -0.1, Y: 0.5}},
+		{name: "below the zone", p: Point{X: 0.5, Y: 1.1}},
+	}
+	zone := lZone()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if inside := zone.Contains(tt.p); inside != tt.inside {
+				t.Errorf("Contains(%v) = %t, want %t", tt.p, inside, tt.inside)
+			}
+		})
+	}
+}
+
+// eventTypes returns the types of zone events.
+func eventTypes(events []ZoneEvent) []ZoneEventType {
+	types := make([]ZoneEventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestZoneDwellAcrossCoasting(t *testing.T) {
+	zone := lZone()
+	zm := NewZoneMonitor(zone)
+	start := time.Now()
+	inside := Point{X: 0.2, Y: 0.8}
+
+	if events := zm.Update([]TrackPoint{{ID: 1, X: inside.X, Y: inside.Y, Time: start}}); len(events) != 1 || events[0].Type != ZoneEnter {
+		t.Fatalf("got events %v, want enter", eventTypes(events))
+	}
+	// The track coasts without observations, it stays in the zone
+	for i := 1; i < 5; i++ {
+		if events := zm.Update(nil); len(events) != 0 {
+			t.Fatalf("coasting frame %d: got events %v", i, eventTypes(events))
+		}
+	}
+	if zone.Occupancy() != 1 {
+		t.Fatalf("occupancy %d while coasting, want 1", zone.Occupancy())
+	}
+	// Observed again, the dwell time continues from the first enter without a new enter event
+	if events := zm.Update([]TrackPoint{{ID: 1, X: inside.X, Y: inside.Y, Time: start.Add(5 * time.Second)}}); len(events) != 0 {
+		t.Fatalf("got events %v after coasting, want none", eventTypes(events))
+	}
+	if dwell, ok := zone.Dwell(1); !ok || dwell != 5*time.Second {
+		t.Errorf("got dwell %s, %t, want 5s", dwell, ok)
+	}
+}
+
+func TestZoneLoiterThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		at        []time.Duration
+		loiterAt  int // Index of the observation which starts loitering, -1 for none
+	}{
+		{name: "just below the threshold", threshold: 2 * time.Second, at: []time.Duration{0, time.Second, 1999 * time.Millisecond}, loiterAt: -1},
+		{name: "on the threshold", threshold: 2 * time.Second, at: []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, loiterAt: 2},
+		{name: "zero threshold disables loitering", at: []time.Duration{0, time.Hour}, loiterAt: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := lZone()
+			zone.LoiterThreshold = tt.threshold
+			zm := NewZoneMonitor(zone)
+			start := time.Now()
+			for i, at := range tt.at {
+				events := zm.Update([]TrackPoint{{ID: 1, X: 0.2, Y: 0.2, Time: start.Add(at)}})
+				loiters := 0
+				for _, e := range events {
+					if e.Type == ZoneLoiterStart {
+						loiters++
+						if e.Dwell != at {
+							t.Errorf("loiter start with dwell %s, want %s", e.Dwell, at)
+						}
+					}
+				}
+				// Loitering starts once
+				if want := i == tt.loiterAt; (loiters == 1) != want || loiters > 1 {
+					t.Errorf("observation %d at %s: %d loiter starts", i, at, loiters)
+				}
+			}
+			if loitering := tt.loiterAt >= 0; zone.Loitering() != loitering {
+				t.Errorf("zone loitering %t, want %t", zone.Loitering(), loitering)
+			}
+		})
+	}
+}
+
+func TestZoneMonitorRemove(t *testing.T) {
+	zone := lZone()
+	zone.LoiterThreshold = time.Second
+	other := &Zone{Name: "other", Polygon: []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}}
+	zm := NewZoneMonitor(zone, other)
+	start := time.Now()
+
+	zm.Update([]TrackPoint{{ID: 1, X: 0.2, Y: 0.8, Time: start}, {ID: 2, X: 0.3, Y: 0.9, Time: start}})
+	zm.Update([]TrackPoint{{ID: 1, X: 0.2, Y: 0.8, Time: start.Add(time.Second)}})
+
+	// The removed loitering track stops loitering and exits, its dwell time is taken up to the removal
+	events := zm.Remove(1, start.Add(3*time.Second))
+	if len(events) != 2 || events[0].Type != ZoneLoiterStop || events[1].Type != ZoneExit {
+		t.Fatalf("got events %v, want loiter stop and exit", eventTypes(events))
+	}
+	for _, e := range events {
+		if e.Zone != zone || e.TrackID != 1 || e.Dwell != 3*time.Second {
+			t.Errorf("got event %+v, want track 1 in %s after 3s", e, zone.Name)
+		}
+	}
+	if zone.Occupancy() != 1 || zone.Loitering() {
+		t.Errorf("occupancy %d, loitering %t after the removal, want 1 and not loitering", zone.Occupancy(), zone.Loitering())
+	}
+	if events := zm.Remove(1, start.Add(4*time.Second)); len(events) != 0 {
+		t.Errorf("second removal got events %v", eventTypes(events))
+	}
+}
+
+func TestZoneAddedLater(t *testing.T) {
+	zm := NewZoneMonitor()
+	zm.Zones = append(zm.Zones, lZone())
+	// A track leaving a zone it never entered has no events
+	if events := zm.Remove(1, time.Now()); len(events) != 0 {
+		t.Fatalf("got events %v", eventTypes(events))
+	}
+	if events := zm.Update([]TrackPoint{{ID: 1, X: 0.2, Y: 0.2, Time: time.Now()}}); len(events) != 1 || events[0].Type != ZoneEnter {
+		t.Fatalf("got events %v, want enter", eventTypes(events))
+	}
+}
@@ -0,0 +1,174 @@
+// Package animation plays image sequences on an overlay at a fixed frame rate.
+//
+// An Animation is a list of PNG files, loaded from a directory of numbered PNGs or decoded from an animated
+// GIF or APNG. Decoded frames are composited to full images and written as PNG files to a temporary directory,
+// because the axoverlay cairo surfaces can only be created from PNG files, so every frame can be preloaded
+// and cached as a surface, e.g. with overlay.Preload. A Player selects the frame from the wall clock.
+package animation
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoFrames is returned if an animation has no frames.
+var ErrNoFrames = errors.New("animation has no frames")
+
+// Animation is a sequence of PNG files of the same size.
+type Animation struct {
+	Frames []string // Frames are the paths of the PNG files in playback order
+	dir    string   // dir contains the decoded frames, it is removed by Close
+}
+
+// Load loads a directory of numbered PNG files, an animated GIF or an APNG, see LoadDir, LoadGIF and LoadAPNG.
+func Load(path string) (*Animation, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return LoadDir(path)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif":
+		return LoadGIF(path)
+	case ".png", ".apng":
+		return LoadAPNG(path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported animation file, use a directory, .gif, .png or .apng", path)
+	}
+}
+
+// LoadDir loads the PNG files of a directory named by their frame number, e.g. 0.png, 1.png .. 24.png.
+// The files are played in the order of their numbers, files without a number are ignored.
+func LoadDir(dir string) (*Animation, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	numbers := map[string]int{}
+	for _, path := range paths {
+		if n, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))); err == nil {
+			numbers[path] = n
+		}
+	}
+	a := &Animation{}
+	for path := range numbers {
+		a.Frames = append(a.Frames, path)
+	}
+	if len(a.Frames) == 0 {
+		return nil, fmt.Errorf("%s: %w", dir, ErrNoFrames)
+	}
+	sort.Slice(a.Frames, func(i, j int) bool { return numbers[a.Frames[i]] < numbers[a.Frames[j]] })
+	return a, nil
+}
+
+// LoadGIF decodes an animated GIF and writes its frames as PNG files.
+// Each frame is drawn over the previous frames according to the GIF disposal of the frame before.
+func LoadGIF(path string) (*Animation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoFrames)
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	w, err := newFrameWriter()
+	if err != nil {
+		return nil, err
+	}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if err := w.write(canvas); err != nil {
+			return nil, w.fail(err)
+		}
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return w.animation, nil
+}
+
+// Close removes the PNG files of a decoded GIF or APNG, files of a directory are kept.
+func (a *Animation) Close() error {
+	if a.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(a.dir)
+}
+
+// frameWriter writes decoded frames as numbered PNG files to a temporary directory.
+type frameWriter struct {
+	animation *Animation
+}
+
+// newFrameWriter creates the temporary directory of an animation.
+func newFrameWriter() (*frameWriter, error) {
+	dir, err := os.MkdirTemp("", "animation")
+	if err != nil {
+		return nil, err
+	}
+	return &frameWriter{animation: &Animation{dir: dir}}, nil
+}
+
+// write adds a frame to the animation.
+func (w *frameWriter) write(img image.Image) error {
+	path := filepath.Join(w.animation.dir, fmt.Sprintf("%d.png", len(w.animation.Frames)))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	w.animation.Frames = append(w.animation.Frames, path)
+	return nil
+}
+
+// fail removes the written frames and returns err.
+func (w *frameWriter) fail(err error) error {
+	w.animation.Close()
+	return err
+}
+
+// cloneRGBA returns a copy of an image.
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	c := image.NewRGBA(img.Bounds())
+	copy(c.Pix, img.Pix)
+	return c
+}
@@ -0,0 +1,131 @@
+package animation
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	red         = color.RGBA{R: 255, A: 255}
+	green       = color.RGBA{G: 255, A: 255}
+	blue        = color.RGBA{B: 255, A: 255}
+	white       = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	transparent = color.RGBA{}
+)
+
+// pixel is the expected color of a pixel of a decoded frame.
+type pixel struct {
+	x, y  int
+	color color.RGBA
+}
+
+// checkFrames compares pixels of the decoded frames of an animation.
+func checkFrames(t *testing.T, a *Animation, want [][]pixel) {
+	t.Helper()
+	if len(a.Frames) != len(want) {
+		t.Fatalf("got %d frames, want %d", len(a.Frames), len(want))
+	}
+	for i, path := range a.Frames {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range want[i] {
+			if got := color.RGBAModel.Convert(img.At(p.x, p.y)).(color.RGBA); got != p.color {
+				t.Errorf("frame %d pixel %d,%d: got %v, want %v", i, p.x, p.y, got, p.color)
+			}
+		}
+	}
+}
+
+// filled returns a paletted image of a rectangle in a single color.
+func filled(r image.Rectangle, c color.Color) *image.Paletted {
+	img := image.NewPaletted(r, color.Palette{red, green, blue, white})
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestLoadGIF(t *testing.T) {
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			filled(image.Rect(0, 0, 4, 4), red),
+			filled(image.Rect(0, 0, 2, 2), blue),
+			filled(image.Rect(2, 2, 4, 4), green),
+			filled(image.Rect(3, 0, 4, 1), white),
+		},
+		Delay:    []int{10, 10, 10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+	}
+	path := filepath.Join(t.TempDir(), "test.gif")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	checkFrames(t, a, [][]pixel{
+		{{0, 0, red}, {3, 3, red}},
+		// Drawn over the first frame, then cleared to the background
+		{{0, 0, blue}, {1, 1, blue}, {2, 2, red}},
+		// Drawn over the cleared area, then restored to the image before this frame
+		{{0, 0, transparent}, {1, 1, transparent}, {2, 2, green}, {3, 3, green}, {3, 0, red}},
+		{{0, 0, transparent}, {2, 2, red}, {3, 3, red}, {3, 0, white}},
+	})
+
+	// The decoded frames are removed by Close
+	dir := filepath.Dir(a.Frames[0])
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("frames directory %s not removed: %v", dir, err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	// Sorted by number, not by name, files without a number are ignored
+	for _, name := range []string{"10.png", "2.png", "1.png", "cover.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, path := range a.Frames {
+		names = append(names, filepath.Base(path))
+	}
+	if len(names) != 3 || names[0] != "1.png" || names[1] != "2.png" || names[2] != "10.png" {
+		t.Errorf("got frames %v, want 1.png 2.png 10.png", names)
+	}
+
+	if _, err := Load(t.TempDir()); !errors.Is(err, ErrNoFrames) {
+		t.Errorf("got %v for an empty directory, want ErrNoFrames", err)
+	}
+}
@@ -0,0 +1,181 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// pngSignature starts every PNG file.
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// APNG dispose and blend operations of a frame control chunk.
+const (
+	apngDisposeBackground = 1 // Clear the frame area to transparent before the next frame
+	apngDisposePrevious   = 2 // Restore the frame area to the previous image before the next frame
+	apngBlendOver         = 1 // Draw the frame over the previous image instead of replacing it
+)
+
+// apngFrame is a frame of an APNG with the compressed image data of its fdAT or IDAT chunks.
+type apngFrame struct {
+	rect    image.Rectangle
+	dispose byte
+	blend   byte
+	data    []byte
+}
+
+// LoadAPNG decodes an animated PNG and writes its frames as PNG files.
+// A PNG without animation control chunk is a single frame and used as it is.
+func LoadAPNG(path string) (*Animation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ihdr, shared, frames, animated, err := parseAPNG(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if !animated {
+		return &Animation{Frames: []string{path}}, nil
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoFrames)
+	}
+
+	w, err := newFrameWriter()
+	if err != nil {
+		return nil, err
+	}
+	bounds := image.Rect(0, 0, int(binary.BigEndian.Uint32(ihdr[0:4])), int(binary.BigEndian.Uint32(ihdr[4:8])))
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range frames {
+		if !frame.rect.In(bounds) {
+			return nil, w.fail(fmt.Errorf("%s: frame %d is outside of the image", path, i))
+		}
+		img, err := frame.decode(ihdr, shared)
+		if err != nil {
+			return nil, w.fail(fmt.Errorf("%s: frame %d: %w", path, i, err))
+		}
+
+		// The first frame has no previous image, so it is disposed to the background
+		dispose := frame.dispose
+		if i == 0 && dispose == apngDisposePrevious {
+			dispose = apngDisposeBackground
+		}
+		var previous *image.RGBA
+		if dispose == apngDisposePrevious {
+			previous = cloneRGBA(canvas)
+		}
+		op := draw.Src
+		if frame.blend == apngBlendOver {
+			op = draw.Over
+		}
+		draw.Draw(canvas, frame.rect, img, img.Bounds().Min, op)
+		if err := w.write(canvas); err != nil {
+			return nil, w.fail(err)
+		}
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, frame.rect, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			canvas = previous
+		}
+	}
+	return w.animation, nil
+}
+
+// parseAPNG splits an APNG into the IHDR data, the chunks shared by all frames like PLTE and tRNS, and the frames.
+// animated is false if the PNG has no acTL chunk.
+func parseAPNG(b []byte) (ihdr []byte, shared [][]byte, frames []*apngFrame, animated bool, err error) {
+	if !bytes.HasPrefix(b, []byte(pngSignature)) {
+		return nil, nil, nil, false, errors.New("not a PNG file")
+	}
+	b = b[len(pngSignature):]
+	var current *apngFrame
+	seenData := false
+	for len(b) >= 12 {
+		length := binary.BigEndian.Uint32(b[0:4])
+		if uint64(length)+12 > uint64(len(b)) {
+			return nil, nil, nil, false, errors.New("truncated chunk")
+		}
+		typ, data := string(b[4:8]), b[8:8+length]
+		chunk := b[:12+length]
+		b = b[12+length:]
+
+		switch typ {
+		case "IHDR":
+			if len(data) != 13 {
+				return nil, nil, nil, false, errors.New("invalid IHDR chunk")
+			}
+			ihdr = data
+		case "acTL":
+			animated = true
+		case "fcTL":
+			if len(data) != 26 {
+				return nil, nil, nil, false, errors.New("invalid fcTL chunk")
+			}
+			x, y := int(binary.BigEndian.Uint32(data[12:16])), int(binary.BigEndian.Uint32(data[16:20]))
+			width, height := int(binary.BigEndian.Uint32(data[4:8])), int(binary.BigEndian.Uint32(data[8:12]))
+			current = &apngFrame{rect: image.Rect(x, y, x+width, y+height), dispose: data[24], blend: data[25]}
+			frames = append(frames, current)
+		case "IDAT":
+			// The default image is only part of the animation if a fcTL chunk comes before it
+			seenData = true
+			if current != nil {
+				current.data = append(current.data, data...)
+			}
+		case "fdAT":
+			if current == nil || len(data) < 4 {
+				return nil, nil, nil, false, errors.New("invalid fdAT chunk")
+			}
+			current.data = append(current.data, data[4:]...)
+		case "IEND":
+			b = nil
+		default:
+			if !seenData {
+				shared = append(shared, chunk)
+			}
+		}
+	}
+	if ihdr == nil {
+		return nil, nil, nil, false, errors.New("missing IHDR chunk")
+	}
+	return ihdr, shared, frames, animated, nil
+}
+
+// decode decodes a frame as a standalone PNG with the frame size.
+func (f *apngFrame) decode(ihdr []byte, shared [][]byte) (image.Image, error) {
+	header := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(header[0:4], uint32(f.rect.Dx()))
+	binary.BigEndian.PutUint32(header[4:8], uint32(f.rect.Dy()))
+
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+	writeChunk(&buf, "IHDR", header)
+	for _, chunk := range shared {
+		buf.Write(chunk)
+	}
+	writeChunk(&buf, "IDAT", f.data)
+	writeChunk(&buf, "IEND", nil)
+	return png.Decode(&buf)
+}
+
+// writeChunk writes a PNG chunk with its length and checksum.
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	buf.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	buf.WriteString(typ)
+	buf.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	buf.Write(n[:])
+}
@@ -0,0 +1,213 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testFrame is a frame of a generated APNG, a rectangle in a single color.
+type testFrame struct {
+	rect    image.Rectangle
+	color   color.RGBA
+	dispose byte
+	blend   byte
+	data    []byte // data replaces the compressed image data if set
+}
+
+// encodeFrame encodes an opaque rectangle and returns its IHDR and IDAT data.
+func encodeFrame(t *testing.T, r image.Rectangle, c color.RGBA) (ihdr, idat []byte) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()[len(pngSignature):]
+	for len(b) >= 12 {
+		length := binary.BigEndian.Uint32(b[0:4])
+		switch typ, data := string(b[4:8]), b[8:8+length]; typ {
+		case "IHDR":
+			ihdr = data
+		case "IDAT":
+			idat = append(idat, data...)
+		}
+		b = b[12+length:]
+	}
+	return ihdr, idat
+}
+
+// buildAPNG returns an APNG of the frames, the first frame is the default image and has to fill the image.
+func buildAPNG(t *testing.T, width, height int, frames []testFrame) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+
+	ihdr, _ := encodeFrame(t, image.Rect(0, 0, width, height), frames[0].color)
+	writeChunk(&buf, "IHDR", ihdr)
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:4], uint32(len(frames)))
+	writeChunk(&buf, "acTL", actl)
+
+	seq := uint32(0)
+	for i, f := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(f.rect.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(f.rect.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:16], uint32(f.rect.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:20], uint32(f.rect.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:22], 1)  // Delay numerator
+		binary.BigEndian.PutUint16(fctl[22:24], 10) // Delay denominator
+		fctl[24], fctl[25] = f.dispose, f.blend
+		writeChunk(&buf, "fcTL", fctl)
+		seq++
+
+		_, data := encodeFrame(t, f.rect, f.color)
+		if f.data != nil {
+			data = f.data
+		}
+		if i == 0 {
+			writeChunk(&buf, "IDAT", data)
+			continue
+		}
+		fdat := binary.BigEndian.AppendUint32(nil, seq)
+		writeChunk(&buf, "fdAT", append(fdat, data...))
+		seq++
+	}
+	writeChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+// writeAPNG writes an APNG into a temporary file and returns its path.
+func writeAPNG(t *testing.T, b []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.apng")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAPNG(t *testing.T) {
+	path := writeAPNG(t, buildAPNG(t, 4, 4, []testFrame{
+		{rect: image.Rect(0, 0, 4, 4), color: red},
+		{rect: image.Rect(1, 1, 3, 3), color: blue, blend: apngBlendOver, dispose: apngDisposeBackground},
+		{rect: image.Rect(0, 0, 1, 1), color: green, blend: apngBlendOver, dispose: apngDisposePrevious},
+		{rect: image.Rect(3, 3, 4, 4), color: white}, // Replaces the area and is not disposed
+	}))
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	checkFrames(t, a, [][]pixel{
+		{{0, 0, red}, {3, 3, red}},
+		// Drawn over the first frame, then cleared to the background
+		{{0, 0, red}, {1, 1, blue}, {2, 2, blue}, {3, 3, red}},
+		// Drawn next to the cleared area, then restored to the image before this frame
+		{{0, 0, green}, {1, 1, transparent}, {2, 2, transparent}, {3, 3, red}},
+		{{0, 0, red}, {1, 1, transparent}, {3, 3, white}},
+	})
+}
+
+func TestLoadAPNGFirstFrameDisposePrevious(t *testing.T) {
+	// The first frame has no previous image, it is disposed to the background instead
+	path := writeAPNG(t, buildAPNG(t, 2, 2, []testFrame{
+		{rect: image.Rect(0, 0, 2, 2), color: red, dispose: apngDisposePrevious},
+		{rect: image.Rect(0, 0, 1, 1), color: blue, blend: apngBlendOver},
+	}))
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	checkFrames(t, a, [][]pixel{
+		{{0, 0, red}, {1, 1, red}},
+		{{0, 0, blue}, {1, 1, transparent}},
+	})
+}
+
+func TestLoadAPNGStill(t *testing.T) {
+	// A PNG without animation control chunk is used as it is
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := writeAPNG(t, buf.Bytes())
+	a, err := LoadAPNG(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Frames) != 1 || a.Frames[0] != path {
+		t.Errorf("got frames %v, want the file itself", a.Frames)
+	}
+}
+
+func TestLoadAPNGInvalid(t *testing.T) {
+	frames := func(second testFrame) []testFrame {
+		return []testFrame{{rect: image.Rect(0, 0, 4, 4), color: red}, second}
+	}
+	valid := buildAPNG(t, 4, 4, frames(testFrame{rect: image.Rect(0, 0, 2, 2), color: blue}))
+	fdat := bytes.LastIndex(valid, []byte("fdAT"))
+
+	// A fdAT chunk without sequence number
+	var short bytes.Buffer
+	short.Write(valid[:fdat-4])
+	writeChunk(&short, "fdAT", []byte{0, 0})
+	writeChunk(&short, "IEND", nil)
+
+	// A fdAT chunk before any fcTL chunk
+	var orphan bytes.Buffer
+	orphan.WriteString(pngSignature)
+	ihdr, _ := encodeFrame(t, image.Rect(0, 0, 4, 4), red)
+	writeChunk(&orphan, "IHDR", ihdr)
+	writeChunk(&orphan, "acTL", make([]byte, 8))
+	writeChunk(&orphan, "fdAT", make([]byte, 8))
+	writeChunk(&orphan, "IEND", nil)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "not a png", data: []byte("GIF89a"), wantErr: "not a PNG file"},
+		{name: "truncated fdAT", data: valid[:fdat+10], wantErr: "truncated chunk"},
+		{name: "fdAT without sequence number", data: short.Bytes(), wantErr: "invalid fdAT chunk"},
+		{name: "fdAT without fcTL", data: orphan.Bytes(), wantErr: "invalid fdAT chunk"},
+		{
+			name:    "corrupt fdAT data",
+			data:    buildAPNG(t, 4, 4, frames(testFrame{rect: image.Rect(0, 0, 2, 2), color: blue, data: []byte("not zlib data")})),
+			wantErr: "frame 1",
+		},
+		{
+			name:    "frame outside of the image",
+			data:    buildAPNG(t, 4, 4, frames(testFrame{rect: image.Rect(3, 3, 5, 5), color: blue})),
+			wantErr: "frame 1 is outside of the image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := LoadAPNG(writeAPNG(t, tt.data))
+			if err == nil {
+				a.Close()
+				t.Fatalf("no error, want %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package animation
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mode is how a player continues after the last frame.
+type Mode int
+
+const (
+	Loop     Mode = iota // Start again with the first frame
+	PingPong             // Play backwards to the first frame and forwards again
+	Once                 // Stay on the last frame
+)
+
+// Player selects the frame of an animation from the wall clock, so the animation plays at the same speed
+// no matter how often the frame is asked for, e.g. by the render callbacks of several streams.
+// A player is safe for concurrent use, except Restart.
+type Player struct {
+	Animation *Animation
+	FPS       float64 // FPS is the number of frames per second
+	Mode      Mode
+	start     time.Time
+}
+
+// NewPlayer creates a player which starts playing now.
+// Returns an error if fps is not positive, the frames would never advance.
+func NewPlayer(a *Animation, fps float64, mode Mode) (*Player, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid animation fps %v, must be positive", fps)
+	}
+	return &Player{Animation: a, FPS: fps, Mode: mode, start: time.Now()}, nil
+}
+
+// Restart plays the animation from the first frame at a time.
+func (p *Player) Restart(now time.Time) {
+	p.start = now
+}
+
+// step returns the number of frame intervals from the start to a time.
+func (p *Player) step(now time.Time) int {
+	if p.FPS <= 0 || now.Before(p.start) {
+		return 0
+	}
+	return int(now.Sub(p.start).Seconds() * p.FPS)
+}
+
+// Index returns the index of the frame shown at a time.
+func (p *Player) Index(now time.Time) int {
+	n := len(p.Animation.Frames)
+	if n < 2 {
+		return 0
+	}
+	step := p.step(now)
+	switch p.Mode {
+	case PingPong:
+		// A period plays the frames forwards and backwards without repeating the first and last frame
+		period := 2*n - 2
+		i := step % period
+		if i >= n {
+			i = period - i
+		}
+		return i
+	case Once:
+		return min(step, n-1)
+	default:
+		return step % n
+	}
+}
+
+// Frame returns the path of the PNG file shown at a time.
+func (p *Player) Frame(now time.Time) string {
+	return p.Animation.Frames[p.Index(now)]
+}
+
+// Next returns the time of the next frame after a time.
+// Returns the zero time if FPS is not positive, the frame never changes then.
+func (p *Player) Next(now time.Time) time.Time {
+	if p.FPS <= 0 {
+		return time.Time{}
+	}
+	return p.start.Add(time.Duration(float64(p.step(now)+1) / p.FPS * float64(time.Second)))
+}
+
+// Done reports whether a player in Once mode shows the last frame at a time, other modes never end.
+func (p *Player) Done(now time.Time) bool {
+	return p.Mode == Once && p.Index(now) == len(p.Animation.Frames)-1
+}
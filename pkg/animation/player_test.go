@@ -0,0 +1,68 @@
+package animation
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlayerIndex(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want []int // Frame index at each second from the start
+	}{
+		{mode: Loop, want: []int{0, 1, 2, 3, 0, 1, 2, 3, 0, 1}},
+		{mode: PingPong, want: []int{0, 1, 2, 3, 2, 1, 0, 1, 2, 3}},
+		{mode: Once, want: []int{0, 1, 2, 3, 3, 3, 3, 3, 3, 3}},
+	}
+	a := &Animation{Frames: []string{"0.png", "1.png", "2.png", "3.png"}}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		p, err := NewPlayer(a, 1, tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Restart(start)
+		var got []int
+		for i := range tt.want {
+			// Half way through the frame interval, so the test does not depend on rounding at the frame change
+			got = append(got, p.Index(start.Add(time.Duration(i)*time.Second+500*time.Millisecond)))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("mode %d: got %v, want %v", tt.mode, got, tt.want)
+		}
+		if p.Index(start.Add(-time.Second)) != 0 {
+			t.Errorf("mode %d: frame before the start is not the first frame", tt.mode)
+		}
+		if done := p.Done(start.Add(3 * time.Second)); done != (tt.mode == Once) {
+			t.Errorf("mode %d: done %t on the last frame", tt.mode, done)
+		}
+	}
+}
+
+func TestPlayerNext(t *testing.T) {
+	a := &Animation{Frames: []string{"0.png", "1.png"}}
+	p, err := NewPlayer(a, 4, Loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p.Restart(start)
+	if next := p.Next(start.Add(300 * time.Millisecond)); !next.Equal(start.Add(500 * time.Millisecond)) {
+		t.Errorf("got next frame at %s, want 500ms after the start", next.Sub(start))
+	}
+
+	// A player without frame rate has no next frame instead of asking for the next frame right away
+	p.FPS = 0
+	if next := p.Next(start); !next.IsZero() {
+		t.Errorf("got next frame %s without frame rate, want zero time", next)
+	}
+}
+
+func TestNewPlayerInvalidFPS(t *testing.T) {
+	for _, fps := range []float64{0, -1} {
+		if _, err := NewPlayer(&Animation{Frames: []string{"0.png"}}, fps, Loop); err == nil {
+			t.Errorf("fps %v: no error", fps)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package larodmodel creates larod inference models with memory mapped tensors sized from the tflite model metadata.
+package larodmodel
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/Cacsjep/goxis/pkg/axlarod"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+)
+
+// Expectation describes the tensors an application expects from a model, nil skips the check.
+type Expectation struct {
+	Inputs  []tflite.TensorSpec
+	Outputs []tflite.TensorSpec
+}
+
+// MemMapConfiguration builds the memory map configuration of a model from its tflite metadata.
+// Args:
+//   - model: The tflite metadata of the model.
+//   - inputs: Inputs shared with other models, e.g. the output of the preprocessing model, by input index.
+//
+// Returns:
+//
+//	axlarod.MemMapConfiguration: Configuration with a file for each tensor, sized from the model.
+//	error: If a shared input is smaller than the model input.
+func MemMapConfiguration(model *tflite.Model, inputs map[int]*axlarod.MemMapFile) (axlarod.MemMapConfiguration, error) {
+	config := axlarod.MemMapConfiguration{
+		InputTmpMapFiles:  make(map[int]*axlarod.MemMapFile, len(model.Inputs)),
+		OutputTmpMapFiles: make(map[int]*axlarod.MemMapFile, len(model.Outputs)),
+	}
+	for i, tensor := range model.Inputs {
+		if shared, ok := inputs[i]; ok {
+			if shared.Size < uint(tensor.Size()) {
+				return config, fmt.Errorf("input %d %s needs %d bytes, but the shared input has %d bytes", i, tensor, tensor.Size(), shared.Size)
+			}
+			config.InputTmpMapFiles[i] = shared
+			continue
+		}
+		config.InputTmpMapFiles[i] = &axlarod.MemMapFile{Size: uint(tensor.Size())}
+	}
+	for i, tensor := range model.Outputs {
+		config.OutputTmpMapFiles[i] = &axlarod.MemMapFile{Size: uint(tensor.Size())}
+	}
+	return config, nil
+}
+
+// NewInferModel reads the tflite metadata of a model file, checks it against the expectation and creates the larod model.
+// Args:
+//   - l: The larod connection.
+//   - modelFilePath: Path of the .tflite model.
+//   - chipString: The larod device, e.g. axis-a8-dlpu-tflite.
+//   - inputs: Inputs shared with other models by input index, the other inputs get their own file.
+//   - expect: The expected tensors, a mismatch fails before the model is loaded.
+//
+// Returns:
+//
+//	*axlarod.LarodModel: The larod model with memory mapped tensors.
+//	*tflite.Model: The tflite metadata, e.g. for the quantization parameters of the outputs.
+//	error: If the model can not be read, does not match the expectation or can not be loaded.
+func NewInferModel(l *axlarod.Larod, modelFilePath string, chipString string, inputs map[int]*axlarod.MemMapFile, expect Expectation) (*axlarod.LarodModel, *tflite.Model, error) {
+	meta, err := tflite.ReadFile(modelFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = meta.Expect(expect.Inputs, expect.Outputs); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", modelFilePath, err)
+	}
+
+	config, err := MemMapConfiguration(meta, inputs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", modelFilePath, err)
+	}
+
+	model, err := l.NewInferModel(modelFilePath, chipString, config, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return model, meta, nil
+}
+
+// Outputs returns the memory mapped output tensors of a model without copying them.
+// The slices are only valid until the next job of the model, which overwrites the tensors.
+// Args:
+//   - model: The larod model, created with memory mapped outputs.
+//   - dst: Reused slice for the result, may be nil.
+//
+// Returns:
+//
+//	[][]byte: The output tensors in order of the model outputs.
+//	error: If an output is not memory mapped.
+func Outputs(model *axlarod.LarodModel, dst [][]byte) ([][]byte, error) {
+	dst = dst[:0]
+	for i, output := range model.Outputs {
+		mmf := output.MemMapFile
+		if mmf == nil || mmf.MemoryAddress == nil {
+			return nil, fmt.Errorf("output tensor %d is not memory mapped", i)
+		}
+		dst = append(dst, unsafe.Slice((*byte)(mmf.MemoryAddress), mmf.Size))
+	}
+	return dst, nil
+}
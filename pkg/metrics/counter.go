@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a monotonically increasing counter, safe for concurrent use.
+type Counter struct {
+	labels string
+	value  atomic.Uint64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value returns the current value.
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) samples() []sample {
+	return []sample{{labels: c.labels, value: float64(c.value.Load())}}
+}
+
+// Gauge is a value that can go up and down, safe for concurrent use.
+type Gauge struct {
+	labels string
+	bits   atomic.Uint64
+}
+
+// Set sets the gauge.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+func (g *Gauge) samples() []sample {
+	return []sample{{labels: g.labels, value: g.Value()}}
+}
+
+// Rate measures the rate of events, e.g. the effective frames per second, over the last events.
+// It is safe for concurrent use.
+type Rate struct {
+	mu    sync.Mutex
+	times []time.Time // Ring buffer of the last event times
+	next  int
+}
+
+// NewRate creates a rate over the last n events.
+func NewRate(n int) *Rate {
+	return &Rate{times: make([]time.Time, 0, max(2, n))}
+}
+
+// Mark records an event now.
+func (r *Rate) Mark() {
+	r.MarkAt(time.Now())
+}
+
+// MarkAt records an event at the given time.
+func (r *Rate) MarkAt(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.times) < cap(r.times) {
+		r.times = append(r.times, t)
+	} else {
+		r.times[r.next] = t
+	}
+	r.next = (r.next + 1) % cap(r.times)
+}
+
+// PerSecond returns the events per second over the window, 0 if there are less than two events.
+// The window ends now, so the rate drops when the events stop.
+func (r *Rate) PerSecond() float64 {
+	return r.PerSecondAt(time.Now())
+}
+
+// PerSecondAt returns the events per second over the window ending at the given time.
+func (r *Rate) PerSecondAt(now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.times) < 2 {
+		return 0
+	}
+	oldest := r.times[0]
+	if len(r.times) == cap(r.times) {
+		oldest = r.times[r.next]
+	}
+	elapsed := now.Sub(oldest).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(len(r.times)-1) / elapsed
+}
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// LATENCY_BUCKETS are the default bucket upper bounds in seconds, from 1 ms up to 2.5 s.
+	LATENCY_BUCKETS = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+	// WINDOW_SIZE is the number of recent observations used for the rolling quantiles.
+	WINDOW_SIZE = 512
+	// QUANTILES are the exposed rolling quantiles.
+	QUANTILES = []float64{0.5, 0.95, 0.99}
+)
+
+// Histogram records durations in cumulative buckets and a rolling window of the last observations.
+// It is safe for concurrent use.
+type Histogram struct {
+	mu      sync.Mutex
+	labels  Labels
+	bounds  []float64 // Bucket upper bounds in seconds
+	buckets []uint64  // Observations per bucket, not cumulative, the last bucket is +Inf
+	count   uint64
+	sum     float64
+	window  []float64 // Ring buffer of the last observations in seconds
+	next    int       // Next write position in the window
+	sorted  []float64 // Reused buffer to compute the quantiles
+}
+
+// NewHistogram creates a histogram with LATENCY_BUCKETS and a window of WINDOW_SIZE observations.
+func NewHistogram(labels Labels) *Histogram {
+	return &Histogram{
+		labels:  labels,
+		bounds:  LATENCY_BUCKETS,
+		buckets: make([]uint64, len(LATENCY_BUCKETS)+1),
+		window:  make([]float64, 0, WINDOW_SIZE),
+	}
+}
+
+// Observe records a duration.
+func (h *Histogram) Observe(d time.Duration) {
+	h.ObserveSeconds(d.Seconds())
+}
+
+// ObserveMilliseconds records a duration in milliseconds, e.g. the ExecutionTime of a larod job.
+func (h *Histogram) ObserveMilliseconds(ms float64) {
+	h.ObserveSeconds(ms / 1000)
+}
+
+// ObserveSeconds records a duration in seconds.
+func (h *Histogram) ObserveSeconds(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buckets[sort.SearchFloat64s(h.bounds, v)]++
+	h.count++
+	h.sum += v
+	if len(h.window) < cap(h.window) {
+		h.window = append(h.window, v)
+	} else {
+		h.window[h.next] = v
+	}
+	h.next = (h.next + 1) % cap(h.window)
+}
+
+// Since records the duration since start, for `defer h.Since(time.Now())`.
+func (h *Histogram) Since(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+// Quantiles returns the quantiles of the rolling window in seconds, NaN if nothing was observed yet.
+func (h *Histogram) Quantiles(qs ...float64) []float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.quantiles(qs)
+}
+
+func (h *Histogram) quantiles(qs []float64) []float64 {
+	values := make([]float64, len(qs))
+	if len(h.window) == 0 {
+		for i := range values {
+			values[i] = math.NaN()
+		}
+		return values
+	}
+	h.sorted = append(h.sorted[:0], h.window...)
+	sort.Float64s(h.sorted)
+	for i, q := range qs {
+		// Nearest rank
+		rank := int(math.Ceil(q*float64(len(h.sorted)))) - 1
+		values[i] = h.sorted[min(max(rank, 0), len(h.sorted)-1)]
+	}
+	return values
+}
+
+// String returns the rolling p50, p95 and p99 in milliseconds for logging.
+func (h *Histogram) String() string {
+	q := h.Quantiles(QUANTILES...)
+	return fmt.Sprintf("p50 %.1fms p95 %.1fms p99 %.1fms", q[0]*1000, q[1]*1000, q[2]*1000)
+}
+
+// histogramCollector exposes the cumulative buckets.
+type histogramCollector struct{ h *Histogram }
+
+func (c histogramCollector) samples() []sample {
+	h := c.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := make([]sample, 0, len(h.buckets)+2)
+	var cumulative uint64
+	for i, n := range h.buckets {
+		cumulative += n
+		le := math.Inf(1)
+		if i < len(h.bounds) {
+			le = h.bounds[i]
+		}
+		samples = append(samples, sample{suffix: "_bucket", labels: h.labels.with("le", formatValue(le)), value: float64(cumulative)})
+	}
+	return append(samples,
+		sample{suffix: "_sum", labels: h.labels.String(), value: h.sum},
+		sample{suffix: "_count", labels: h.labels.String(), value: float64(h.count)},
+	)
+}
+
+// windowCollector exposes the rolling quantiles as summary.
+// Like the summaries of the Prometheus client, only the quantiles are computed over the window,
+// _sum and _count are cumulative so rate() and increase() work on them.
+type windowCollector struct{ h *Histogram }
+
+func (c windowCollector) samples() []sample {
+	h := c.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := make([]sample, 0, len(QUANTILES)+2)
+	for i, v := range h.quantiles(QUANTILES) {
+		samples = append(samples, sample{labels: h.labels.with("quantile", formatValue(QUANTILES[i])), value: v})
+	}
+	return append(samples,
+		sample{suffix: "_sum", labels: h.labels.String(), value: h.sum},
+		sample{suffix: "_count", labels: h.labels.String(), value: float64(h.count)},
+	)
+}
@@ -0,0 +1,202 @@
+// Package metrics collects latency histograms, counters and gauges and writes them in the Prometheus text format.
+//
+// It only uses the standard library, pkg/pipeline and fiber for the handler, so it can be used by every example
+// without pulling in the Prometheus client. Pipeline bundles the metrics shared by the larod examples.
+// Histograms keep cumulative buckets for Prometheus queries and a rolling window of the last observations
+// for the p50, p95 and p99 quantiles of the current load, which are also used in the syslog summaries.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Labels are the label names and values of a metric.
+type Labels map[string]string
+
+// String formats the labels sorted by name, e.g. `{stage="infer"}`, empty if there are no labels.
+func (l Labels) String() string {
+	return l.with("", "")
+}
+
+// with formats the labels with an additional label, e.g. le or quantile, which is skipped if name is empty.
+func (l Labels) with(name, value string) string {
+	if len(l) == 0 && name == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, l[k]))
+	}
+	if name != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// merge returns the labels with the labels of other added, other wins on equal names.
+func (l Labels) merge(other Labels) Labels {
+	merged := make(Labels, len(l)+len(other))
+	for k, v := range l {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// sample is a single line of the exposition format.
+type sample struct {
+	suffix string // Suffix of the family name, e.g. _bucket
+	labels string // Formatted labels
+	value  float64
+}
+
+// collector provides the samples of a metric.
+type collector interface {
+	samples() []sample
+}
+
+// family is a metric name with its type, help text and collectors, e.g. one per label set.
+type family struct {
+	name       string
+	help       string
+	typ        string
+	collectors []collector
+}
+
+// Registry holds the metrics of an application.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	byName   map[string]*family
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byName: map[string]*family{}}
+}
+
+// register adds a collector to a family, the family is created on first use.
+// It panics if the family is registered with another type, which is a programming error.
+func (r *Registry) register(name, help, typ string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.byName[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ}
+		r.byName[name] = f
+		r.families = append(r.families, f)
+	}
+	if f.typ != typ {
+		panic(fmt.Sprintf("metrics: %s registered as %s and %s", name, f.typ, typ))
+	}
+	f.collectors = append(f.collectors, c)
+}
+
+// Histogram registers a latency histogram in seconds, see NewHistogram.
+// The rolling quantiles are exposed as summary with the name suffix _window.
+func (r *Registry) Histogram(name, help string, labels Labels) *Histogram {
+	h := NewHistogram(labels)
+	r.register(name, help, "histogram", histogramCollector{h})
+	r.register(name+"_window", help+" (rolling window)", "summary", windowCollector{h})
+	return h
+}
+
+// Counter registers a counter.
+func (r *Registry) Counter(name, help string, labels Labels) *Counter {
+	c := &Counter{labels: labels.String()}
+	r.register(name, help, "counter", c)
+	return c
+}
+
+// CounterFunc registers a counter whose value is read on each scrape, e.g. from existing statistics.
+func (r *Registry) CounterFunc(name, help string, labels Labels, f func() float64) {
+	r.register(name, help, "counter", funcCollector{labels: labels.String(), f: f})
+}
+
+// Gauge registers a gauge.
+func (r *Registry) Gauge(name, help string, labels Labels) *Gauge {
+	g := &Gauge{labels: labels.String()}
+	r.register(name, help, "gauge", g)
+	return g
+}
+
+// GaugeFunc registers a gauge whose value is read on each scrape.
+func (r *Registry) GaugeFunc(name, help string, labels Labels, f func() float64) {
+	r.register(name, help, "gauge", funcCollector{labels: labels.String(), f: f})
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+// Args:
+//   - w: The writer, e.g. the fiber context of the /metrics request.
+//
+// Returns:
+//
+//	error: If writing fails.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, c := range f.collectors {
+			for _, s := range c.samples() {
+				fmt.Fprintf(bw, "%s%s%s %s\n", f.name, s.suffix, s.labels, formatValue(s.value))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler returns a fiber handler that writes the metrics for a scrape, e.g. on /metrics of the webserver
+// behind the reverse proxy of the manifest.
+func (r *Registry) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, CONTENT_TYPE)
+		return r.WritePrometheus(c)
+	}
+}
+
+// CONTENT_TYPE is the content type of the Prometheus text exposition format.
+const CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// formatValue formats a sample value like the Prometheus client.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// funcCollector reads the value of a counter or gauge on each scrape.
+type funcCollector struct {
+	labels string
+	f      func() float64
+}
+
+func (c funcCollector) samples() []sample {
+	return []sample{{labels: c.labels, value: c.f()}}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	defer func(size int) { WINDOW_SIZE = size }(WINDOW_SIZE)
+	WINDOW_SIZE = 4
+
+	r := NewRegistry()
+	h := r.Histogram("stage_seconds", "Stage duration.", Labels{"stage": "infer"})
+	for _, ms := range []float64{2, 4, 8, 16, 32, 64} {
+		h.ObserveMilliseconds(ms)
+	}
+	r.Counter("frames_total", "Frames.", nil).Add(3)
+	r.GaugeFunc("fps", "Frames per second.", Labels{"b": "2", "a": "1"}, func() float64 { return 12.5 })
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP stage_seconds Stage duration.
+# TYPE stage_seconds histogram
+stage_seconds_bucket{stage="infer",le="0.001"} 0
+stage_seconds_bucket{stage="infer",le="0.0025"} 1
+stage_seconds_bucket{stage="infer",le="0.005"} 2
+stage_seconds_bucket{stage="infer",le="0.01"} 3
+stage_seconds_bucket{stage="infer",le="0.025"} 4
+stage_seconds_bucket{stage="infer",le="0.05"} 5
+stage_seconds_bucket{stage="infer",le="0.1"} 6
+stage_seconds_bucket{stage="infer",le="0.25"} 6
+stage_seconds_bucket{stage="infer",le="0.5"} 6
+stage_seconds_bucket{stage="infer",le="1"} 6
+stage_seconds_bucket{stage="infer",le="2.5"} 6
+stage_seconds_bucket{stage="infer",le="+Inf"} 6
+stage_seconds_sum{stage="infer"} 0.126
+stage_seconds_count{stage="infer"} 6
+# HELP stage_seconds_window Stage duration. (rolling window)
+# TYPE stage_seconds_window summary
+stage_seconds_window{stage="infer",quantile="0.5"} 0.016
+stage_seconds_window{stage="infer",quantile="0.95"} 0.064
+stage_seconds_window{stage="infer",quantile="0.99"} 0.064
+stage_seconds_window_sum{stage="infer"} 0.126
+stage_seconds_window_count{stage="infer"} 6
+# HELP frames_total Frames.
+# TYPE frames_total counter
+frames_total 3
+# HELP fps Frames per second.
+# TYPE fps gauge
+fps{a="1",b="2"} 12.5
+`
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWindowCountIsCumulative(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("stage_seconds", "Stage duration.", nil)
+	var previous float64
+	for i := 0; i < 3*WINDOW_SIZE; i++ {
+		h.Observe(time.Millisecond)
+		count := windowCollector{h}.samples()[len(QUANTILES)+1].value
+		if count <= previous {
+			t.Fatalf("window count %g after %d observations is not increasing", count, i+1)
+		}
+		previous = count
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(Labels{"app": "test"}, 10)
+	stats := []pipeline.StageStats{{Name: "preprocess", Dropped: 4}, {Name: "infer", Failed: 2}}
+	p.WatchStages(func() []pipeline.StageStats { return stats })
+	p.Published.Add(7)
+	stats[0].Dropped = 5
+
+	app := fiber.New()
+	app.Get("/metrics", p.Registry.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); resp.StatusCode != 200 || got != CONTENT_TYPE {
+		t.Errorf("got status %d content type %q", resp.StatusCode, got)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(b)
+	for _, line := range []string{
+		`goxis_stage_duration_seconds_count{app="test",stage="capture"} 0`,
+		`goxis_frames_published_total{app="test"} 7`,
+		`goxis_effective_fps{app="test"} 0`,
+		`goxis_pipeline_dropped_frames_total{app="test",stage="preprocess"} 5`,
+		`goxis_pipeline_failed_frames_total{app="test",stage="infer"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s in\n%s", line, body)
+		}
+	}
+}
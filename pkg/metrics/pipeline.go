@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/Cacsjep/goxis_examples/pkg/pipeline"
+)
+
+// Pipeline holds the latency histograms of the processing steps of a frame pipeline and the frame counters.
+// Fields:
+//   - Registry: The registry of the metrics, serve it with Handler.
+//   - Capture: Age of a frame when it is handed to the pipeline.
+//   - Preprocess, Inference, Decode: Durations of the larod jobs and the decoding of the outputs.
+//   - Tracking: Duration of the work after decoding, e.g. tracking and analytics.
+//   - Overlay: Duration of updating the overlay scene.
+//   - Published: Frames which passed all pipeline stages.
+//   - FPS: Rate of the published frames.
+type Pipeline struct {
+	Registry   *Registry
+	Capture    *Histogram
+	Preprocess *Histogram
+	Inference  *Histogram
+	Decode     *Histogram
+	Tracking   *Histogram
+	Overlay    *Histogram
+	Published  *Counter
+	FPS        *Rate
+	labels     Labels
+}
+
+// NewPipeline registers the metrics of a frame pipeline in a new registry.
+// Args:
+//   - labels: Labels added to every metric, e.g. the name of the application, may be nil.
+//   - fpsWindow: Number of published frames used for the effective fps.
+func NewPipeline(labels Labels, fpsWindow int) *Pipeline {
+	registry := NewRegistry()
+	stage := func(name string) *Histogram {
+		return registry.Histogram("goxis_stage_duration_seconds", "Duration of a processing step per frame in seconds.", labels.merge(Labels{"stage": name}))
+	}
+	p := &Pipeline{
+		Registry:   registry,
+		Capture:    stage("capture"),
+		Preprocess: stage("preprocess"),
+		Inference:  stage("inference"),
+		Decode:     stage("decode"),
+		Tracking:   stage("tracking"),
+		Overlay:    stage("overlay"),
+		Published:  registry.Counter("goxis_frames_published_total", "Frames which passed all pipeline stages.", labels),
+		FPS:        NewRate(fpsWindow),
+		labels:     labels,
+	}
+	registry.GaugeFunc("goxis_effective_fps", "Published frames per second.", labels, p.FPS.PerSecond)
+	return p
+}
+
+// WatchStages registers the dropped and failed frame counters of the stages, stats is read on each scrape.
+// The stages are taken from the first call of stats, e.g. the Stats method of a created pipeline.
+func (p *Pipeline) WatchStages(stats func() []pipeline.StageStats) {
+	for i, s := range stats() {
+		labels := p.labels.merge(Labels{"stage": s.Name})
+		p.Registry.CounterFunc("goxis_pipeline_dropped_frames_total", "Frames dropped because the stage was behind.", labels, func() float64 {
+			return float64(stats()[i].Dropped)
+		})
+		p.Registry.CounterFunc("goxis_pipeline_failed_frames_total", "Frames dropped because the stage failed after all retries.", labels, func() float64 {
+			return float64(stats()[i].Failed)
+		})
+	}
+}
+
+// String returns the rolling latency quantiles of all steps and the fps for logging.
+func (p *Pipeline) String() string {
+	return fmt.Sprintf("capture: %s, preprocess: %s, inference: %s, decode: %s, tracking: %s, overlay: %s, fps: %.1f",
+		p.Capture, p.Preprocess, p.Inference, p.Decode, p.Tracking, p.Overlay, p.FPS.PerSecond())
+}
@@ -0,0 +1,120 @@
+package overlay
+
+import (
+	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+// CairoCanvas draws scene nodes on the cairo context of a render event.
+// Image files are loaded once and kept as cairo surfaces until Destroy.
+type CairoCanvas struct {
+	Ctx      *axoverlay.CairoContext
+	surfaces map[string]*axoverlay.CairoSurface
+}
+
+// NewCairoCanvas creates a canvas, Ctx is set for each render event.
+func NewCairoCanvas() *CairoCanvas {
+	return &CairoCanvas{surfaces: map[string]*axoverlay.CairoSurface{}}
+}
+
+// Clear makes the whole overlay transparent.
+func (c *CairoCanvas) Clear(width, height int) {
+	c.Ctx.DrawTransparent(width, height)
+}
+
+// Text draws a text with an optional background box.
+func (c *CairoCanvas) Text(text string, x, y float64, style scene.TextStyle) {
+	c.Ctx.SetOperator(axoverlay.OPERATOR_OVER)
+	width := c.TextWidth(text, style)
+	if style.Background.A > 0 {
+		c.Ctx.SetSourceRGBA(style.Background)
+		c.Ctx.Rectangle(x, y, width+style.Padding*2, style.Size+style.Padding*2)
+		c.Ctx.Fill()
+	}
+	c.Ctx.SetSourceRGBA(style.Color)
+	c.Ctx.MoveTo(x+style.Padding, y+style.Padding+style.Size)
+	c.Ctx.ShowText(text)
+}
+
+// TextWidth selects the font of a style and returns the advance of a text.
+func (c *CairoCanvas) TextWidth(text string, style scene.TextStyle) float64 {
+	c.Ctx.SelectFontFace(style.Font, axoverlay.FONT_SLANT_NORMAL, axoverlay.FONT_WEIGHT_NORMAL)
+	c.Ctx.SetFontSize(style.Size)
+	return c.Ctx.TextExtents(text).Xadvance
+}
+
+// Rect draws a rectangle.
+func (c *CairoCanvas) Rect(x, y, width, height float64, style scene.Style) {
+	c.Ctx.NewPath()
+	c.Ctx.Rectangle(x, y, width, height)
+	c.fillAndStroke(style, true)
+}
+
+// Path draws connected line segments.
+func (c *CairoCanvas) Path(points []scene.Point, closed bool, style scene.Style) {
+	c.Ctx.NewPath()
+	c.Ctx.MoveTo(points[0].X, points[0].Y)
+	for _, p := range points[1:] {
+		c.Ctx.LineTo(p.X, p.Y)
+	}
+	if closed {
+		c.Ctx.ClosePath()
+	}
+	c.fillAndStroke(style, closed)
+}
+
+// fillAndStroke fills and strokes the current path.
+func (c *CairoCanvas) fillAndStroke(style scene.Style, fill bool) {
+	c.Ctx.SetOperator(axoverlay.OPERATOR_OVER)
+	if fill && style.Fill.A > 0 {
+		c.Ctx.SetSourceRGBA(style.Fill)
+		c.Ctx.FillPreserve()
+	}
+	if style.Stroke.A > 0 && style.LineWidth > 0 {
+		c.Ctx.SetSourceRGBA(style.Stroke)
+		c.Ctx.SetLineWidth(style.LineWidth)
+		if len(style.Dash) > 0 {
+			c.Ctx.SetDash(style.Dash, len(style.Dash), 0)
+		} else {
+			c.Ctx.SetDash([]float64{0}, 0, 0) // Solid line, SetDash needs at least one element
+		}
+		c.Ctx.Stroke()
+	}
+	c.Ctx.NewPath()
+}
+
+// Image draws a PNG file, the surface is loaded on first use.
+func (c *CairoCanvas) Image(path string, x, y float64, align scene.Point, scale float64) error {
+	surface, err := c.surface(path)
+	if err != nil {
+		return err
+	}
+	x -= align.X * float64(surface.Width()) * scale
+	y -= align.Y * float64(surface.Height()) * scale
+	c.Ctx.SetOperator(axoverlay.OPERATOR_OVER)
+	c.Ctx.Scale(scale, scale)
+	c.Ctx.PaintSurface(surface, x/scale, y/scale)
+	c.Ctx.Scale(1/scale, 1/scale)
+	return nil
+}
+
+// surface returns the cached surface of a PNG file.
+func (c *CairoCanvas) surface(path string) (*axoverlay.CairoSurface, error) {
+	if surface, found := c.surfaces[path]; found {
+		return surface, nil
+	}
+	surface, err := axoverlay.NewCairoSurfaceFromPNG(path)
+	if err != nil {
+		return nil, err
+	}
+	c.surfaces[path] = surface
+	return surface, nil
+}
+
+// Destroy frees the cached surfaces.
+func (c *CairoCanvas) Destroy() {
+	for path, surface := range c.surfaces {
+		surface.Destroy()
+		delete(c.surfaces, path)
+	}
+}
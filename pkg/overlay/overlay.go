@@ -0,0 +1,125 @@
+// Package overlay renders a scene graph on all streams with the axoverlay cairo backend.
+//
+// The overlay covers the whole stream, the scene is drawn in the render callback scaled to the resolution
+// and rotation of each stream. Redraw is only called after the scene changed, so an application just updates its nodes.
+package overlay
+
+import (
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/axoverlay"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+// Overlay draws a scene with an axoverlay overlay provider.
+// Only one Overlay can exist per application, because axoverlay allows only one overlay provider.
+type Overlay struct {
+	Provider    *axoverlay.OverlayProvider
+	Scene       *scene.Scene
+	MinInterval time.Duration   // MinInterval is the minimum time between two redraws, changes in between are coalesced
+	OnError     func(err error) // OnError is called with errors of the render callback and redraws, nil ignores them
+	canvas      *CairoCanvas
+	started     bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// New creates the overlay provider and an overlay covering the whole stream which renders the scene.
+// Call Start to redraw the overlay when the scene changes.
+func New(s *scene.Scene) (*Overlay, error) {
+	o := &Overlay{
+		Scene:  s,
+		canvas: NewCairoCanvas(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	var err error
+	if o.Provider, err = axoverlay.NewOverlayProvider(renderCallback, adjustmentCallback, nil); err != nil {
+		return nil, err
+	}
+	if _, err = o.Provider.AddOverlay(axoverlay.NewAnchorCenterRrgbaOverlay(axoverlay.AxOverlayTopLeft, o)); err != nil {
+		o.Provider.Cleanup()
+		return nil, err
+	}
+	return o, nil
+}
+
+// Size returns the size of the overlay of a stream.
+// The stream size is not rotated, so the width and height are swapped for a rotation of 90 or 270 degrees.
+func Size(stream *axoverlay.AxOverlayStreamData) (int, int) {
+	if stream.Rotation == 90 || stream.Rotation == 270 {
+		return stream.Height, stream.Width
+	}
+	return stream.Width, stream.Height
+}
+
+// adjustmentCallback sizes the overlay to the stream in its rotation.
+func adjustmentCallback(adjustmentEvent *axoverlay.OverlayAdjustmentEvent) {
+	*adjustmentEvent.OverlayWidth, *adjustmentEvent.OverlayHeight = Size(adjustmentEvent.Stream)
+}
+
+// renderCallback renders the scene of the overlay in the resolution and rotation of the stream.
+func renderCallback(renderEvent *axoverlay.OverlayRenderEvent) {
+	o := renderEvent.Userdata.(*Overlay)
+	o.canvas.Ctx = renderEvent.CairoCtx
+	width, height := Size(renderEvent.Stream)
+	if err := o.Scene.Render(o.canvas, width, height); err != nil {
+		o.error(err)
+	}
+}
+
+// error passes an error to OnError.
+func (o *Overlay) error(err error) {
+	if o.OnError != nil {
+		o.OnError(err)
+	}
+}
+
+// Preload loads image files as cairo surfaces, so the first render of each image does not read the file.
+// Preload must be called before Start and before the event loop runs, while no render callback uses the cache.
+func (o *Overlay) Preload(paths ...string) error {
+	for _, path := range paths {
+		if _, err := o.canvas.surface(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start draws the overlay and redraws it in the background whenever the scene changes.
+func (o *Overlay) Start() {
+	o.started = true
+	go o.run()
+}
+
+func (o *Overlay) run() {
+	defer close(o.done)
+	for {
+		if err := o.Provider.Redraw(); err != nil {
+			o.error(err)
+		}
+		if o.MinInterval > 0 {
+			select {
+			case <-time.After(o.MinInterval):
+			case <-o.stop:
+				return
+			}
+		}
+		select {
+		case <-o.Scene.Changed():
+		case <-o.stop:
+			return
+		}
+	}
+}
+
+// Close stops the redraws, cleans up the overlay provider and frees the cached images.
+// Close must be called once, e.g. with AddCloseCleanFunc.
+func (o *Overlay) Close() {
+	if o.started {
+		close(o.stop)
+		<-o.done
+	}
+	o.Provider.Cleanup()
+	o.canvas.Destroy()
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import "time"
+
+// Lanes is a pool of inference lanes, e.g. preprocessing and detection model pairs with their own memory mapped tensors.
+// An item holds a lane from the preprocessing until its outputs are decoded, so the next item can be preprocessed
+// and inferred on another lane without overwriting tensors which are still in use.
+type Lanes[L any] struct {
+	free chan L
+}
+
+// NewLanes creates a pool of n lanes, create is called for every lane in order.
+// Returns the first error of create.
+func NewLanes[L any](n int, create func(i int) (L, error)) (*Lanes[L], error) {
+	l := &Lanes[L]{free: make(chan L, max(1, n))}
+	for i := 0; i < n; i++ {
+		lane, err := create(i)
+		if err != nil {
+			return nil, err
+		}
+		l.free <- lane
+	}
+	return l, nil
+}
+
+// Acquire takes a free lane from the pool, it waits while all lanes are in use.
+func (l *Lanes[L]) Acquire() L {
+	return <-l.free
+}
+
+// Release returns a lane to the pool.
+func (l *Lanes[L]) Release(lane L) {
+	l.free <- lane
+}
+
+// Size returns the number of lanes of the pool.
+func (l *Lanes[L]) Size() int {
+	return cap(l.free)
+}
+
+// Job is embedded in the items of an inference pipeline and holds the lane of an item while it is in use.
+type Job[L any] struct {
+	lane L
+	held bool
+}
+
+// Lane returns the lane of the item, it is only valid in the preprocess, infer and decode stages.
+func (j *Job[L]) Lane() L {
+	return j.lane
+}
+
+// job returns the embedded job of an item.
+func (j *Job[L]) job() *Job[L] {
+	return j
+}
+
+// laneItem is an item with an embedded Job.
+type laneItem[L any] interface {
+	job() *Job[L]
+}
+
+// InferenceStages are the application specific steps of an inference pipeline.
+// Fields:
+//   - Preprocess: Runs the preprocessing of an item on its lane.
+//   - Infer: Runs the model of the lane, the outputs stay in the tensors of the lane.
+//   - Decode: Decodes the outputs of the lane, the decoded result must not reference the tensors of the lane,
+//     because the lane is released after Decode.
+//   - Track: Uses the decoded result after the lane is released, e.g. for tracking and building the overlay. May be nil.
+//   - Publish: Publishes the result of an item, runs in its own stage.
+//   - Skip: Reports items which need no lane, e.g. frames with replayed outputs, their Preprocess and Infer are
+//     skipped and Decode is called without a lane. May be nil.
+type InferenceStages[T any] struct {
+	Preprocess func(item T) error
+	Infer      func(item T) error
+	Decode     func(item T) error
+	Track      func(item T) error
+	Publish    func(item T) error
+	Skip       func(item T) bool
+}
+
+// InferenceConfig configures the preprocess and infer stages, which hold the lanes.
+// Fields:
+//   - Policy: What happens with an item when the preprocess or infer stage is behind, the later stages block.
+//   - Retries: How often a failed preprocessing or inference is retried before the item is dropped.
+//   - RetryDelay: Wait time between the retries.
+type InferenceConfig struct {
+	Policy     DropPolicy
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// Inference is a pipeline preprocess → infer → postprocess → publish which shares a pool of lanes between the items.
+// The lane of an item is acquired by the preprocess stage and released after Decode or when the item is dropped.
+type Inference[T laneItem[L], L any] struct {
+	*Pipeline[T]
+	Lanes  *Lanes[L]
+	stages InferenceStages[T]
+}
+
+// NewInference creates an inference pipeline with the stages of an application.
+// OnDrop of the pipeline releases the lane of the dropped item, OnError can be set before Start.
+func NewInference[T laneItem[L], L any](lanes *Lanes[L], cfg InferenceConfig, stages InferenceStages[T]) *Inference[T, L] {
+	p := &Inference[T, L]{Lanes: lanes, stages: stages}
+	p.Pipeline = New(
+		Stage[T]{Name: "preprocess", Run: p.preprocess, Policy: cfg.Policy, Retries: cfg.Retries, RetryDelay: cfg.RetryDelay},
+		Stage[T]{Name: "infer", Run: p.infer, Policy: cfg.Policy, Retries: cfg.Retries, RetryDelay: cfg.RetryDelay},
+		Stage[T]{Name: "postprocess", Run: p.postprocess, Policy: Block},
+		Stage[T]{Name: "publish", Run: stages.Publish, Policy: Block},
+	)
+	p.OnDrop = func(stage string, item T) {
+		p.Release(item)
+	}
+	return p
+}
+
+// Release returns the lane of an item to the pool, it does nothing if the item holds no lane.
+func (p *Inference[T, L]) Release(item T) {
+	j := item.job()
+	if !j.held {
+		return
+	}
+	var zero L
+	p.Lanes.Release(j.lane)
+	j.lane, j.held = zero, false
+}
+
+// skip reports if an item needs no lane.
+func (p *Inference[T, L]) skip(item T) bool {
+	return p.stages.Skip != nil && p.stages.Skip(item)
+}
+
+// preprocess acquires a free lane and runs the preprocessing, a retried item keeps its lane.
+func (p *Inference[T, L]) preprocess(item T) error {
+	if p.skip(item) {
+		return nil
+	}
+	if j := item.job(); !j.held {
+		j.lane, j.held = p.Lanes.Acquire(), true
+	}
+	return p.stages.Preprocess(item)
+}
+
+// infer runs the model of the lane.
+func (p *Inference[T, L]) infer(item T) error {
+	if p.skip(item) {
+		return nil
+	}
+	return p.stages.Infer(item)
+}
+
+// postprocess decodes the outputs, releases the lane and runs Track.
+func (p *Inference[T, L]) postprocess(item T) error {
+	if err := p.stages.Decode(item); err != nil {
+		return err
+	}
+	p.Release(item)
+	if p.stages.Track == nil {
+		return nil
+	}
+	return p.stages.Track(item)
+}
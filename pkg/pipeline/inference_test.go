@@ -0,0 +1,189 @@
+package pipeline
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testLane counts the items using it, more than one means a tensor is overwritten while in use.
+type testLane struct {
+	id    int
+	users atomic.Int32
+}
+
+// testJob is an item of the test pipeline.
+type testJob struct {
+	Job[*testLane]
+	n        int
+	replayed bool
+	lane     *testLane // lane is the lane seen by Infer
+	released bool      // released reports if the lane was returned before Track
+}
+
+func newTestLanes(t *testing.T, n int) *Lanes[*testLane] {
+	t.Helper()
+	lanes, err := NewLanes(n, func(i int) (*testLane, error) { return &testLane{id: i}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lanes
+}
+
+func TestNewLanesError(t *testing.T) {
+	failed := errors.New("model failed")
+	_, err := NewLanes(3, func(i int) (int, error) {
+		if i == 1 {
+			return 0, failed
+		}
+		return i, nil
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("got %v, want %v", err, failed)
+	}
+}
+
+func TestInference(t *testing.T) {
+	const frames = 50
+	lanes := newTestLanes(t, 2)
+	var (
+		mu        sync.Mutex
+		published []*testJob
+		overlap   atomic.Bool
+	)
+	use := func(job *testJob) error {
+		if job.Lane().users.Add(1) > 1 {
+			overlap.Store(true)
+		}
+		job.Lane().users.Add(-1)
+		return nil
+	}
+	p := NewInference(lanes, InferenceConfig{Policy: Block}, InferenceStages[*testJob]{
+		Preprocess: use,
+		Infer: func(job *testJob) error {
+			job.lane = job.Lane()
+			return use(job)
+		},
+		Decode: func(job *testJob) error {
+			if !job.replayed && job.Lane() != job.lane {
+				t.Errorf("frame %d changed the lane", job.n)
+			}
+			return nil
+		},
+		Track: func(job *testJob) error {
+			job.released = job.Lane() == nil
+			return nil
+		},
+		Publish: func(job *testJob) error {
+			mu.Lock()
+			published = append(published, job)
+			mu.Unlock()
+			return nil
+		},
+		Skip: func(job *testJob) bool { return job.replayed },
+	})
+	p.Start()
+	for i := 0; i < frames; i++ {
+		p.Push(&testJob{n: i, replayed: i%10 == 0})
+	}
+	p.Stop()
+
+	if len(published) != frames {
+		t.Fatalf("published %d frames, want %d", len(published), frames)
+	}
+	for _, job := range published {
+		if !job.released {
+			t.Errorf("frame %d held its lane after decode", job.n)
+		}
+		if job.replayed != (job.lane == nil) {
+			t.Errorf("frame %d replayed %t, used lane %v", job.n, job.replayed, job.lane)
+		}
+	}
+	if overlap.Load() {
+		t.Error("a lane was used by two frames at the same time")
+	}
+	if len(lanes.free) != lanes.Size() {
+		t.Errorf("%d of %d lanes returned", len(lanes.free), lanes.Size())
+	}
+}
+
+func TestInferenceReleasesDroppedLanes(t *testing.T) {
+	lanes := newTestLanes(t, 2)
+	var attempts atomic.Int32
+	p := NewInference(lanes, InferenceConfig{Policy: Block, Retries: 2}, InferenceStages[*testJob]{
+		Preprocess: func(job *testJob) error { return nil },
+		Infer: func(job *testJob) error {
+			if job.n%2 == 0 {
+				return errors.New("inference failed")
+			}
+			if job.n == 1 {
+				attempts.Add(1)
+				if attempts.Load() < 3 {
+					return errors.New("retry")
+				}
+			}
+			return nil
+		},
+		Decode: func(job *testJob) error {
+			if job.n == 3 {
+				return errors.New("decode failed")
+			}
+			return nil
+		},
+		Publish: func(job *testJob) error { return nil },
+	})
+	var failed atomic.Int32
+	p.OnError = func(stage string, job *testJob, err error) {
+		failed.Add(1)
+	}
+	p.Start()
+	for i := 0; i < 10; i++ {
+		p.Push(&testJob{n: i})
+	}
+	p.Stop()
+
+	stats := p.Stats()
+	if stats[1].Failed != 5 || stats[2].Failed != 1 || stats[3].Processed != 4 {
+		t.Errorf("got stats %v", stats)
+	}
+	if stats[1].Retries != 5*2+2 {
+		t.Errorf("got %d retries, want 12", stats[1].Retries)
+	}
+	if failed.Load() != 6 {
+		t.Errorf("got %d errors, want 6", failed.Load())
+	}
+	if len(lanes.free) != lanes.Size() {
+		t.Errorf("%d of %d lanes returned", len(lanes.free), lanes.Size())
+	}
+}
+
+func TestInferenceDropOldest(t *testing.T) {
+	lanes := newTestLanes(t, 1)
+	block := make(chan struct{})
+	p := NewInference(lanes, InferenceConfig{Policy: DropOldest}, InferenceStages[*testJob]{
+		Preprocess: func(job *testJob) error { return nil },
+		Infer: func(job *testJob) error {
+			if job.n == 0 {
+				<-block
+			}
+			return nil
+		},
+		Decode:  func(job *testJob) error { return nil },
+		Publish: func(job *testJob) error { return nil },
+	})
+	p.Start()
+	for i := 0; i < 20; i++ {
+		p.Push(&testJob{n: i})
+	}
+	close(block)
+	p.Stop()
+
+	stats := p.Stats()
+	if stats[0].Dropped+stats[1].Dropped == 0 {
+		t.Errorf("no frames dropped: %v", stats)
+	}
+	if len(lanes.free) != lanes.Size() {
+		t.Errorf("%d of %d lanes returned", len(lanes.free), lanes.Size())
+	}
+}
@@ -0,0 +1,226 @@
+// Package pipeline runs the processing of video frames as stages connected by bounded queues.
+//
+// Each stage runs in its own goroutine, so e.g. the preprocessing of the next frame, the inference of the
+// current frame and the tracking of the previous frame run at the same time. When a stage is behind, its
+// input queue fills up and the drop policy of the stage decides whether the producer waits or frames are
+// dropped. A failing stage retries the item and then drops it, the pipeline itself keeps running.
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy decides what happens with an item when the input queue of a stage is full.
+type DropPolicy int
+
+const (
+	Block      DropPolicy = iota // Wait until the stage has room, slows down the previous stage
+	DropNewest                   // Drop the incoming item, the queued items are processed
+	DropOldest                   // Drop the oldest queued item in favor of the incoming one, keeps the latency low
+)
+
+// String returns a readable name of the policy.
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop newest"
+	case DropOldest:
+		return "drop oldest"
+	default:
+		return "block"
+	}
+}
+
+// Stage is a processing step of the pipeline.
+// Fields:
+//   - Name: Name of the stage, used in errors and statistics.
+//   - Run: Processes an item, the item is passed to the next stage if no error is returned.
+//   - Queue: Capacity of the input queue, at least 1.
+//   - Policy: What happens with an item when the input queue is full.
+//   - Retries: How often a failed item is retried before it is dropped.
+//   - RetryDelay: Wait time between the retries.
+type Stage[T any] struct {
+	Name       string
+	Run        func(item T) error
+	Queue      int
+	Policy     DropPolicy
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// StageStats holds the counters of a stage.
+type StageStats struct {
+	Name      string
+	Processed uint64 // Items passed to the next stage
+	Dropped   uint64 // Items dropped because the input queue was full
+	Failed    uint64 // Items dropped because all retries failed
+	Retries   uint64 // Retries of failed items
+}
+
+// String returns a short summary of the counters.
+func (s StageStats) String() string {
+	return fmt.Sprintf("%s: processed %d, dropped %d, failed %d, retries %d", s.Name, s.Processed, s.Dropped, s.Failed, s.Retries)
+}
+
+// stage is a running stage with its input queue.
+type stage[T any] struct {
+	Stage[T]
+	queue     chan T
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+	retries   atomic.Uint64
+}
+
+// Pipeline connects stages by bounded queues.
+// Fields:
+//   - OnError: Called when an item failed all retries of a stage, may be nil.
+//   - OnDrop: Called for every item which does not pass all stages, dropped by the drop policy or an error,
+//     e.g. to release buffers held by the item. May be nil.
+//
+// The callbacks are called from the stage goroutines and must be set before Start.
+type Pipeline[T any] struct {
+	OnError func(stage string, item T, err error)
+	OnDrop  func(stage string, item T)
+	stages  []*stage[T]
+	wg      sync.WaitGroup
+	started bool
+}
+
+// New creates a pipeline of the stages, items pass the stages in the given order.
+func New[T any](stages ...Stage[T]) *Pipeline[T] {
+	p := &Pipeline[T]{}
+	for _, s := range stages {
+		p.stages = append(p.stages, &stage[T]{Stage: s, queue: make(chan T, max(1, s.Queue))})
+	}
+	return p
+}
+
+// Start starts a goroutine per stage.
+func (p *Pipeline[T]) Start() {
+	if p.started {
+		return
+	}
+	p.started = true
+	for i, s := range p.stages {
+		var next *stage[T]
+		if i+1 < len(p.stages) {
+			next = p.stages[i+1]
+		}
+		p.wg.Add(1)
+		go p.run(s, next)
+	}
+}
+
+// Push adds an item to the input queue of the first stage, following the drop policy of the first stage.
+// Returns false if the item was dropped.
+func (p *Pipeline[T]) Push(item T) bool {
+	if len(p.stages) == 0 {
+		return false
+	}
+	return p.push(p.stages[0], item)
+}
+
+// Stop closes the input of the pipeline and waits until the queued items are processed.
+// Push must not be called after Stop.
+func (p *Pipeline[T]) Stop() {
+	if !p.started || len(p.stages) == 0 {
+		return
+	}
+	close(p.stages[0].queue)
+	p.wg.Wait()
+	p.started = false
+}
+
+// Stats returns the counters of all stages in order.
+func (p *Pipeline[T]) Stats() []StageStats {
+	stats := make([]StageStats, 0, len(p.stages))
+	for _, s := range p.stages {
+		stats = append(stats, StageStats{
+			Name:      s.Name,
+			Processed: s.processed.Load(),
+			Dropped:   s.dropped.Load(),
+			Failed:    s.failed.Load(),
+			Retries:   s.retries.Load(),
+		})
+	}
+	return stats
+}
+
+// run processes the items of a stage until its queue is closed, then closes the queue of the next stage.
+func (p *Pipeline[T]) run(s *stage[T], next *stage[T]) {
+	defer p.wg.Done()
+	if next != nil {
+		defer close(next.queue)
+	}
+	for item := range s.queue {
+		if err := p.process(s, item); err != nil {
+			s.failed.Add(1)
+			if p.OnError != nil {
+				p.OnError(s.Name, item, err)
+			}
+			p.drop(s, item)
+			continue
+		}
+		s.processed.Add(1)
+		if next != nil {
+			p.push(next, item)
+		}
+	}
+}
+
+// process runs the stage with retries, the last error is returned.
+func (p *Pipeline[T]) process(s *stage[T], item T) error {
+	err := s.Run(item)
+	for attempt := 0; err != nil && attempt < s.Retries; attempt++ {
+		s.retries.Add(1)
+		if s.RetryDelay > 0 {
+			time.Sleep(s.RetryDelay)
+		}
+		err = s.Run(item)
+	}
+	return err
+}
+
+// push adds an item to the queue of a stage following its drop policy.
+func (p *Pipeline[T]) push(s *stage[T], item T) bool {
+	switch s.Policy {
+	case DropNewest:
+		select {
+		case s.queue <- item:
+			return true
+		default:
+			s.dropped.Add(1)
+			p.drop(s, item)
+			return false
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.queue <- item:
+				return true
+			default:
+			}
+			// The stage may have taken the oldest item in the meantime, then the send is retried
+			select {
+			case oldest := <-s.queue:
+				s.dropped.Add(1)
+				p.drop(s, oldest)
+			default:
+			}
+		}
+	default:
+		s.queue <- item
+		return true
+	}
+}
+
+// drop reports an item leaving the pipeline early.
+func (p *Pipeline[T]) drop(s *stage[T], item T) {
+	if p.OnDrop != nil {
+		p.OnDrop(s.Name, item)
+	}
+}
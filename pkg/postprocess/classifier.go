@@ -0,0 +1,175 @@
+package postprocess
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+)
+
+// CLASSIFIER_POSTPROCESS is the name of the post processor for classification models.
+const CLASSIFIER_POSTPROCESS = "classifier"
+
+// CLASSIFIER_TYPES are the supported output types of classification models.
+var CLASSIFIER_TYPES = []tflite.TensorType{tflite.Uint8, tflite.Int8, tflite.Float32}
+
+func init() {
+	Register(CLASSIFIER_POSTPROCESS, NewClassifier)
+}
+
+// Classifier dequantizes the class scores, optionally applies a softmax and returns the top K classes.
+// The outputs are concatenated in order, so a model with one output per class (e.g. person and car)
+// is handled like a model with a single score vector.
+// With smoothing the scores of all classes are averaged across frames before the threshold and the top K
+// are applied, so a Classifier keeps state and must only be used for a single stream.
+type Classifier struct {
+	outputs   []tflite.Tensor
+	threshold float32
+	topK      int
+	softmax   bool
+	smoothing float32
+	scores    []float32        // Reused dequantized scores of all classes
+	smoothed  []float32        // Moving average of the scores, empty until the first frame
+	current   []float32        // Scores of all classes of the last frame, smoothed if enabled
+	classes   []Classification // Reused result buffer
+}
+
+// NewClassifier creates the classifier post processor, it returns an error if an output has an unsupported type.
+func NewClassifier(cfg Config) (PostProcessor, error) {
+	if len(cfg.Outputs) == 0 {
+		return nil, fmt.Errorf("model has no outputs")
+	}
+	n := 0
+	for i, t := range cfg.Outputs {
+		if err := (tflite.TensorSpec{Types: CLASSIFIER_TYPES}).Check(t); err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+		n += t.Elements()
+	}
+	return &Classifier{
+		outputs:   cfg.Outputs,
+		threshold: cfg.Threshold,
+		topK:      cfg.TopK,
+		softmax:   cfg.Softmax,
+		smoothing: cfg.Smoothing,
+		scores:    make([]float32, n),
+		smoothed:  make([]float32, 0, n),
+		classes:   make([]Classification, 0, n),
+	}, nil
+}
+
+// Process returns the classes with a score above the threshold, sorted by score.
+func (c *Classifier) Process(outputs [][]byte) (Result, error) {
+	if err := checkSizes(outputs, c.outputs); err != nil {
+		return Result{}, err
+	}
+	scores := c.scores[:0]
+	for i, t := range c.outputs {
+		scores = appendDequantized(scores, t, outputs[i])
+	}
+	if c.softmax {
+		softmax(scores)
+	}
+	if c.smoothing > 0 && c.smoothing < 1 {
+		scores = c.smooth(scores)
+	}
+	c.current = scores
+
+	c.classes = c.classes[:0]
+	for class, score := range scores {
+		if score >= c.threshold {
+			c.classes = append(c.classes, Classification{Class: class, Score: score})
+		}
+	}
+	sort.SliceStable(c.classes, func(i, j int) bool {
+		return c.classes[i].Score > c.classes[j].Score
+	})
+	if c.topK > 0 && len(c.classes) > c.topK {
+		c.classes = c.classes[:c.topK]
+	}
+	return Result{Classifications: c.classes}, nil
+}
+
+// smooth updates the moving average with the scores of the current frame, the first frame initializes it.
+func (c *Classifier) smooth(scores []float32) []float32 {
+	if len(c.smoothed) == 0 {
+		c.smoothed = append(c.smoothed, scores...)
+		return c.smoothed
+	}
+	for i, score := range scores {
+		c.smoothed[i] += c.smoothing * (score - c.smoothed[i])
+	}
+	return c.smoothed
+}
+
+// Scores returns the scores of all classes of the last frame indexed by class, smoothed if enabled.
+// Unlike the result of Process it is not cut by the threshold and top K, e.g. for rules on classes
+// which are rarely in the top K. The slice is reused by the next Process, nil before the first frame.
+func (c *Classifier) Scores() []float32 {
+	return c.current
+}
+
+// Reset clears the moving average, e.g. after the stream was restarted.
+func (c *Classifier) Reset() {
+	c.smoothed = c.smoothed[:0]
+	c.current = nil
+}
+
+// NumClasses returns the number of classes of the model outputs.
+func (c *Classifier) NumClasses() int {
+	return len(c.scores)
+}
+
+// String describes the post processor.
+func (c *Classifier) String() string {
+	return fmt.Sprintf("classifier, %d classes, top %d, softmax %t, smoothing %.2f, threshold %.2f", len(c.scores), c.topK, c.softmax, c.smoothing, c.threshold)
+}
+
+// appendDequantized appends the real values of a tensor.
+// Quantized tensors use their scale and zero point, uint8 tensors without quantization are scaled to [0, 1].
+func appendDequantized(dst []float32, t tflite.Tensor, data []byte) []float32 {
+	n := t.Elements()
+	if t.Type == tflite.Float32 {
+		for i := 0; i < n; i++ {
+			dst = append(dst, float32At(data, i))
+		}
+		return dst
+	}
+
+	scale, zeroPoint, ok := t.Quantized()
+	if !ok {
+		scale, zeroPoint = 1.0/255, 0
+		if t.Type == tflite.Int8 {
+			zeroPoint = -128
+		}
+	}
+	for i := 0; i < n; i++ {
+		q := int(data[i])
+		if t.Type == tflite.Int8 {
+			q = int(int8(data[i]))
+		}
+		dst = append(dst, scale*float32(q-zeroPoint))
+	}
+	return dst
+}
+
+// softmax converts logits into probabilities in place.
+func softmax(values []float32) {
+	if len(values) == 0 {
+		return
+	}
+	maxValue := values[0]
+	for _, v := range values[1:] {
+		maxValue = max(maxValue, v)
+	}
+	var sum float64
+	for i, v := range values {
+		e := math.Exp(float64(v - maxValue))
+		values[i] = float32(e)
+		sum += e
+	}
+	for i := range values {
+		values[i] = float32(float64(values[i]) / sum)
+	}
+}
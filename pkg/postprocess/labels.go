@@ -0,0 +1,58 @@
+package postprocess
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Labels are the class names of a model, indexed by class.
+type Labels []string
+
+// LoadLabels reads a labels file with one class name per line.
+// Surrounding whitespace and trailing empty lines are ignored.
+func LoadLabels(path string) (Labels, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var labels Labels
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		labels = append(labels, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for len(labels) > 0 && labels[len(labels)-1] == "" {
+		labels = labels[:len(labels)-1]
+	}
+	return labels, nil
+}
+
+// LabelsPath returns the path of the labels file bundled alongside a model,
+// the model path with a .txt extension, e.g. yolov5n.tflite -> yolov5n.txt.
+func LabelsPath(modelPath string) string {
+	return strings.TrimSuffix(modelPath, filepath.Ext(modelPath)) + ".txt"
+}
+
+// Name returns the name of a class, or "class N" if the class has no label.
+func (l Labels) Name(class int) string {
+	if class >= 0 && class < len(l) {
+		return l[class]
+	}
+	return fmt.Sprintf("class %d", class)
+}
+
+// Check returns an error if the number of labels does not match the number of classes of a model,
+// e.g. because the labels file of another model is bundled next to it.
+func (l Labels) Check(numClasses int) error {
+	if len(l) != numClasses {
+		return fmt.Errorf("model has %d classes but the labels file has %d labels", numClasses, len(l))
+	}
+	return nil
+}
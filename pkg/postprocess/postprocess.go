@@ -0,0 +1,155 @@
+// Package postprocess converts the raw output tensors of a model into typed detections or classifications.
+//
+// Post processors are registered by name, so the examples select the decoding of a model by a name like
+// "ssd", "yolo" or "classifier" instead of hand written getDResult and InferenceOutputRead functions.
+// A post processor is configured from the tflite metadata of the model outputs and fails on creation if the
+// outputs do not fit, not on the first frame.
+package postprocess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// Detection is a detected object, it implements tracker.Detection.
+type Detection struct {
+	Box   tracker.Box // Box in normalized model input coordinates
+	Score float32     // Confidence of the detection
+	Class int         // Class index of the detection
+}
+
+// GetBox, GetScore and GetClass implement tracker.Detection
+func (d Detection) GetBox() tracker.Box { return d.Box }
+func (d Detection) GetScore() float32   { return d.Score }
+func (d Detection) GetClass() int       { return d.Class }
+
+// Classification is the score of a single class.
+type Classification struct {
+	Class int     // Class index
+	Score float32 // Probability or dequantized score of the class
+}
+
+// Result holds the typed output of a post processor, detection heads fill Detections and classifier heads Classifications.
+type Result struct {
+	Detections      []Detection
+	Classifications []Classification
+}
+
+// PostProcessor converts the raw output tensors of a model.
+type PostProcessor interface {
+	// Process converts the raw output tensors, in the order of the model outputs.
+	// The returned slices are only valid until the next call of Process.
+	Process(outputs [][]byte) (Result, error)
+	// String describes the post processor and its configuration for logging.
+	String() string
+}
+
+// Config configures a post processor, not every field is used by every post processor.
+// Fields:
+//   - Outputs: The output tensors of the model from the tflite metadata, see tflite.ReadFile.
+//   - Threshold: Minimum score of a detection or classification.
+//   - InputWidth, InputHeight: Model input size, used by yolo if the model outputs pixel coordinates.
+//   - IOUThreshold: Overlap above which a detection is suppressed by the yolo NMS.
+//   - MaxDetections: Maximum number of detections per frame, 0 keeps all.
+//   - TopK: Number of returned classifications, 0 returns all classes.
+//   - Softmax: Apply a softmax to the classifier scores, for models which output logits.
+//   - Smoothing: Weight of the newest frame in the exponential moving average of the classifier scores,
+//     e.g. 0.3 to suppress flickering between classes, 0 or 1 disables the smoothing.
+type Config struct {
+	Outputs       []tflite.Tensor
+	Threshold     float32
+	InputWidth    int
+	InputHeight   int
+	IOUThreshold  float32
+	MaxDetections int
+	TopK          int
+	Softmax       bool
+	Smoothing     float32
+}
+
+// Factory creates a post processor from the config, it returns an error if the model outputs do not fit.
+type Factory func(cfg Config) (PostProcessor, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a post processor available by name.
+// It panics if the name is already registered or the factory is nil, like database/sql.Register.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("postprocess: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("postprocess: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Names returns the sorted names of the registered post processors.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the post processor registered under the name.
+// Args:
+//   - name: Name of the post processor, e.g. SSD_POSTPROCESS.
+//   - cfg: The configuration, Outputs must be set.
+//
+// Returns:
+//
+//	PostProcessor: The configured post processor.
+//	error: If the name is unknown or the model outputs do not fit the post processor.
+func New(name string, cfg Config) (PostProcessor, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown post processor %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	p, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s post processor: %w", name, err)
+	}
+	return p, nil
+}
+
+// expectOutputs checks the output tensors against the specs.
+func expectOutputs(outputs []tflite.Tensor, specs []tflite.TensorSpec) error {
+	return (&tflite.Model{Outputs: outputs}).Expect(nil, specs)
+}
+
+// checkSizes returns an error if an output buffer is smaller than its tensor.
+func checkSizes(outputs [][]byte, tensors []tflite.Tensor) error {
+	if len(outputs) < len(tensors) {
+		return fmt.Errorf("got %d output tensors, expected %d", len(outputs), len(tensors))
+	}
+	for i, t := range tensors {
+		if len(outputs[i]) < t.Size() {
+			return fmt.Errorf("output %d has %d bytes, tensor %s needs %d", i, len(outputs[i]), t, t.Size())
+		}
+	}
+	return nil
+}
+
+// float32At reads the i-th little endian float32 of a tensor.
+func float32At(b []byte, i int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+}
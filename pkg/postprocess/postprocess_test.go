@@ -0,0 +1,343 @@
+package postprocess
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// floats returns the little endian bytes of float32 values, like a float32 output tensor.
+func floats(values ...float32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return b
+}
+
+// tensor returns a tensor description with an optional per tensor quantization.
+func tensor(typ tflite.TensorType, scale float32, zeroPoint int64, shape ...int) tflite.Tensor {
+	t := tflite.Tensor{Name: "output", Type: typ, Shape: shape}
+	if scale > 0 {
+		t.Quantization = tflite.Quantization{Scale: []float32{scale}, ZeroPoint: []int64{zeroPoint}}
+	}
+	return t
+}
+
+// ssdOutputs are the outputs of an SSD model with 3 boxes.
+var ssdOutputs = []tflite.Tensor{
+	tensor(tflite.Float32, 0, 0, 1, 3, 4),
+	tensor(tflite.Float32, 0, 0, 1, 3),
+	tensor(tflite.Float32, 0, 0, 1, 3),
+	tensor(tflite.Float32, 0, 0, 1),
+}
+
+// ssdBuffers returns the output buffers of three boxes, the count is reported by the model.
+func ssdBuffers(count float32) [][]byte {
+	return [][]byte{
+		floats(
+			0.1, 0.2, 0.3, 0.4,
+			0.5, 0.5, 0.75, 0.75,
+			0, 0, 1, 1,
+		),
+		floats(0, 2, 16),
+		floats(0.9, 0.6, 0.2),
+		floats(count),
+	}
+}
+
+// yoloOutputs is a yolov5 output with 8 predictions and 2 classes, quantized with a scale of 0.01.
+var yoloOutputs = []tflite.Tensor{tensor(tflite.Uint8, 0.01, 0, 1, 8, 7)}
+
+// yoloBuffer holds two overlapping predictions of class 0, one of class 1, one with a low objectness and empty predictions.
+// Values are percent: center x, center y, width, height, objectness, class 0 score, class 1 score.
+var yoloBuffer = []byte{
+	50, 50, 20, 20, 90, 100, 0,
+	51, 50, 20, 20, 80, 100, 0,
+	20, 20, 10, 10, 100, 10, 80,
+	80, 80, 10, 10, 10, 100, 0,
+	0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0,
+}
+
+// yolov8Outputs is a yolov8 output with 2 classes and 8 predictions, it has no objectness.
+var yolov8Outputs = []tflite.Tensor{tensor(tflite.Uint8, 0.01, 0, 1, 6, 8)}
+
+// yolov8Buffer is yoloBuffer transposed to the yolov8 layout, the class scores are multiplied with the objectness.
+func yolov8Buffer() []byte {
+	b := make([]byte, len(yoloBuffer)/7*6)
+	for i := 0; i < 8; i++ {
+		p := yoloBuffer[7*i : 7*i+7]
+		for j, v := range []byte{p[0], p[1], p[2], p[3], byte(int(p[4]) * int(p[5]) / 100), byte(int(p[4]) * int(p[6]) / 100)} {
+			b[j*8+i] = v
+		}
+	}
+	return b
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		post    string
+		cfg     Config
+		outputs [][]byte
+		frames  int // Number of times the outputs are processed, default 1
+		want    Result
+		wantErr string
+	}{
+		{
+			name:    "ssd threshold",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs, Threshold: 0.5},
+			outputs: ssdBuffers(3),
+			want: Result{Detections: []Detection{
+				{Box: tracker.Box{Top: 0.1, Left: 0.2, Bottom: 0.3, Right: 0.4}, Score: 0.9, Class: 0},
+				{Box: tracker.Box{Top: 0.5, Left: 0.5, Bottom: 0.75, Right: 0.75}, Score: 0.6, Class: 2},
+			}},
+		},
+		{
+			name:    "ssd count limits the boxes",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs, Threshold: 0.1},
+			outputs: ssdBuffers(1),
+			want: Result{Detections: []Detection{
+				{Box: tracker.Box{Top: 0.1, Left: 0.2, Bottom: 0.3, Right: 0.4}, Score: 0.9, Class: 0},
+			}},
+		},
+		{
+			name:    "ssd count larger than the boxes",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs},
+			outputs: ssdBuffers(4),
+			wantErr: "model reports 4 detections, but has only 3 boxes",
+		},
+		{
+			name:    "ssd negative count",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs},
+			outputs: ssdBuffers(-1),
+			wantErr: "model reports -1 detections",
+		},
+		{
+			name:    "ssd missing output",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs},
+			outputs: ssdBuffers(3)[:3],
+			wantErr: "got 3 output tensors, expected 4",
+		},
+		{
+			name:    "ssd short buffer",
+			post:    SSD_POSTPROCESS,
+			cfg:     Config{Outputs: ssdOutputs},
+			outputs: append(ssdBuffers(3)[:2], floats(0.9, 0.6), floats(3)),
+			wantErr: "output 2 has 8 bytes",
+		},
+		{
+			name:    "yolo decode and nms",
+			post:    YOLO_POSTPROCESS,
+			cfg:     Config{Outputs: yoloOutputs, Threshold: 0.3, IOUThreshold: 0.5},
+			outputs: [][]byte{yoloBuffer},
+			want: Result{Detections: []Detection{
+				{Box: tracker.Box{Top: 0.4, Left: 0.4, Bottom: 0.6, Right: 0.6}, Score: 0.9, Class: 0},
+				{Box: tracker.Box{Top: 0.15, Left: 0.15, Bottom: 0.25, Right: 0.25}, Score: 0.8, Class: 1},
+			}},
+		},
+		{
+			name:    "yolo max detections",
+			post:    YOLO_POSTPROCESS,
+			cfg:     Config{Outputs: yoloOutputs, Threshold: 0.3, IOUThreshold: 0.5, MaxDetections: 1},
+			outputs: [][]byte{yoloBuffer},
+			want: Result{Detections: []Detection{
+				{Box: tracker.Box{Top: 0.4, Left: 0.4, Bottom: 0.6, Right: 0.6}, Score: 0.9, Class: 0},
+			}},
+		},
+		{
+			name:    "yolov8 decode and nms",
+			post:    YOLO_POSTPROCESS,
+			cfg:     Config{Outputs: yolov8Outputs, Threshold: 0.3, IOUThreshold: 0.5},
+			outputs: [][]byte{yolov8Buffer()},
+			want: Result{Detections: []Detection{
+				{Box: tracker.Box{Top: 0.4, Left: 0.4, Bottom: 0.6, Right: 0.6}, Score: 0.9, Class: 0},
+				{Box: tracker.Box{Top: 0.15, Left: 0.15, Bottom: 0.25, Right: 0.25}, Score: 0.8, Class: 1},
+			}},
+		},
+		{
+			name:    "yolo short buffer",
+			post:    YOLO_POSTPROCESS,
+			cfg:     Config{Outputs: yoloOutputs, Threshold: 0.3},
+			outputs: [][]byte{yoloBuffer[:55]},
+			wantErr: "needs 56 bytes",
+		},
+		{
+			name:    "yolo missing output",
+			post:    YOLO_POSTPROCESS,
+			cfg:     Config{Outputs: yoloOutputs, Threshold: 0.3},
+			outputs: nil,
+			wantErr: "needs 56 bytes",
+		},
+		{
+			name:    "classifier softmax top k",
+			post:    CLASSIFIER_POSTPROCESS,
+			cfg:     Config{Outputs: []tflite.Tensor{tensor(tflite.Float32, 0, 0, 1, 4)}, TopK: 2, Softmax: true},
+			outputs: [][]byte{floats(0, float32(math.Log(3)), float32(math.Log(6)), -100)},
+			want: Result{Classifications: []Classification{
+				{Class: 2, Score: 0.6},
+				{Class: 1, Score: 0.3},
+			}},
+		},
+		{
+			name:    "classifier quantized threshold",
+			post:    CLASSIFIER_POSTPROCESS,
+			cfg:     Config{Outputs: []tflite.Tensor{tensor(tflite.Uint8, 0.5, 10, 1, 3)}, Threshold: 10},
+			outputs: [][]byte{{30, 40, 19}},
+			want: Result{Classifications: []Classification{
+				{Class: 1, Score: 15},
+				{Class: 0, Score: 10},
+			}},
+		},
+		{
+			name: "classifier int8 outputs per class are concatenated",
+			post: CLASSIFIER_POSTPROCESS,
+			cfg: Config{Outputs: []tflite.Tensor{
+				tensor(tflite.Int8, 1.0/256, -128, 1, 1),
+				tensor(tflite.Int8, 1.0/256, -128, 1, 1),
+			}},
+			outputs: [][]byte{{byte(0x80)}, {0}},
+			want: Result{Classifications: []Classification{
+				{Class: 1, Score: 0.5},
+				{Class: 0, Score: 0},
+			}},
+		},
+		{
+			name:    "classifier smoothing",
+			post:    CLASSIFIER_POSTPROCESS,
+			cfg:     Config{Outputs: []tflite.Tensor{tensor(tflite.Float32, 0, 0, 1, 2)}, Smoothing: 0.5},
+			outputs: [][]byte{floats(1, 0)},
+			frames:  2,
+			want: Result{Classifications: []Classification{
+				{Class: 0, Score: 1},
+				{Class: 1, Score: 0},
+			}},
+		},
+		{
+			name:    "classifier short buffer",
+			post:    CLASSIFIER_POSTPROCESS,
+			cfg:     Config{Outputs: []tflite.Tensor{tensor(tflite.Float32, 0, 0, 1, 4)}},
+			outputs: [][]byte{floats(1, 2, 3)},
+			wantErr: "output 0 has 12 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.post, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Result
+			for frame := 0; frame < max(tt.frames, 1); frame++ {
+				got, err = p.Process(tt.outputs)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !resultEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if names := Names(); !slices.Equal(names, []string{CLASSIFIER_POSTPROCESS, SSD_POSTPROCESS, YOLO_POSTPROCESS}) {
+		t.Errorf("registered %v", names)
+	}
+	tests := []struct {
+		name    string
+		post    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "unknown name", post: "detr", wantErr: `unknown post processor "detr"`},
+		{name: "ssd with yolo outputs", post: SSD_POSTPROCESS, cfg: Config{Outputs: yoloOutputs}, wantErr: "expected 4 outputs"},
+		{
+			name: "ssd box counts differ",
+			post: SSD_POSTPROCESS,
+			cfg: Config{Outputs: []tflite.Tensor{
+				tensor(tflite.Float32, 0, 0, 1, 3, 4),
+				tensor(tflite.Float32, 0, 0, 1, 2),
+				tensor(tflite.Float32, 0, 0, 1, 3),
+				tensor(tflite.Float32, 0, 0, 1),
+			}},
+			wantErr: "different box counts",
+		},
+		{name: "yolo float output", post: YOLO_POSTPROCESS, cfg: Config{Outputs: []tflite.Tensor{tensor(tflite.Float32, 0, 0, 1, 4, 7)}}, wantErr: "expected type"},
+		{name: "yolo without quantization", post: YOLO_POSTPROCESS, cfg: Config{Outputs: []tflite.Tensor{tensor(tflite.Uint8, 0, 0, 1, 4, 7)}}, wantErr: "no per tensor quantization"},
+		{name: "classifier without outputs", post: CLASSIFIER_POSTPROCESS, wantErr: "model has no outputs"},
+		{name: "classifier int32 output", post: CLASSIFIER_POSTPROCESS, cfg: Config{Outputs: []tflite.Tensor{tensor(tflite.Int32, 0, 0, 1, 4)}}, wantErr: "expected type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.post, tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// resultEqual compares results with a tolerance for the dequantized floats.
+func resultEqual(a, b Result) bool {
+	near := func(x, y float32) bool { return math.Abs(float64(x-y)) < 1e-5 }
+	if len(a.Detections) != len(b.Detections) || len(a.Classifications) != len(b.Classifications) {
+		return false
+	}
+	for i, d := range a.Detections {
+		e := b.Detections[i]
+		if d.Class != e.Class || !near(d.Score, e.Score) ||
+			!near(d.Box.Top, e.Box.Top) || !near(d.Box.Left, e.Box.Left) || !near(d.Box.Bottom, e.Box.Bottom) || !near(d.Box.Right, e.Box.Right) {
+			return false
+		}
+	}
+	for i, c := range a.Classifications {
+		if c.Class != b.Classifications[i].Class || !near(c.Score, b.Classifications[i].Score) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestClassifierScores(t *testing.T) {
+	p, err := NewClassifier(Config{Outputs: []tflite.Tensor{tensor(tflite.Float32, 0, 0, 1, 4)}, Threshold: 0.5, TopK: 1, Smoothing: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := p.(*Classifier)
+	if c.Scores() != nil {
+		t.Errorf("got scores %v before the first frame", c.Scores())
+	}
+	for _, frame := range [][]float32{{0.75, 0.5, 0.25, 0}, {0.25, 0, 0.5, 0}} {
+		if _, err := c.Process([][]byte{floats(frame...)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Classes below the threshold and outside the top K keep their smoothed score
+	if want := []float32{0.5, 0.25, 0.375, 0}; !slices.Equal(c.Scores(), want) {
+		t.Errorf("got scores %v, want %v", c.Scores(), want)
+	}
+	c.Reset()
+	if c.Scores() != nil {
+		t.Errorf("got scores %v after reset", c.Scores())
+	}
+}
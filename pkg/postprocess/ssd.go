@@ -0,0 +1,83 @@
+package postprocess
+
+import (
+	"fmt"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// SSD_POSTPROCESS is the name of the post processor for models with the tflite detection postprocess operator.
+const SSD_POSTPROCESS = "ssd"
+
+// SSD_OUTPUTS are the outputs of models with the tflite detection postprocess operator,
+// the number of boxes depends on the model.
+var SSD_OUTPUTS = []tflite.TensorSpec{
+	{Types: []tflite.TensorType{tflite.Float32}, Shape: []int{1, -1, 4}}, // Locations: [1, N, 4]
+	{Types: []tflite.TensorType{tflite.Float32}, Shape: []int{1, -1}},    // Classes: [1, N]
+	{Types: []tflite.TensorType{tflite.Float32}, Shape: []int{1, -1}},    // Scores: [1, N]
+	{Types: []tflite.TensorType{tflite.Float32}, Shape: []int{1}},        // Number of detections: [1]
+}
+
+func init() {
+	Register(SSD_POSTPROCESS, NewSSD)
+}
+
+// SSD reads the outputs of the tflite detection postprocess operator, e.g. of ssd_mobilenet_v2_coco_quant_postprocess.
+// The boxes are already decoded and suppressed by the model, so only the score threshold is applied.
+type SSD struct {
+	outputs    []tflite.Tensor
+	boxes      int         // Maximum number of boxes of the model
+	threshold  float32     // Minimum score of a detection
+	detections []Detection // Reused result buffer
+}
+
+// NewSSD creates the SSD post processor, it returns an error if the outputs are not the four postprocess outputs.
+func NewSSD(cfg Config) (PostProcessor, error) {
+	if err := expectOutputs(cfg.Outputs, SSD_OUTPUTS); err != nil {
+		return nil, err
+	}
+	boxes := cfg.Outputs[0].Shape[1]
+	if cfg.Outputs[1].Shape[1] != boxes || cfg.Outputs[2].Shape[1] != boxes {
+		return nil, fmt.Errorf("locations, classes and scores have different box counts: %v, %v, %v",
+			cfg.Outputs[0].Shape, cfg.Outputs[1].Shape, cfg.Outputs[2].Shape)
+	}
+	return &SSD{outputs: cfg.Outputs, boxes: boxes, threshold: cfg.Threshold}, nil
+}
+
+// Process reads the detections with a score above the threshold.
+func (s *SSD) Process(outputs [][]byte) (Result, error) {
+	if err := checkSizes(outputs, s.outputs); err != nil {
+		return Result{}, err
+	}
+	locations, classes, scores := outputs[0], outputs[1], outputs[2]
+
+	count := int(float32At(outputs[3], 0))
+	if count < 0 || count > s.boxes {
+		return Result{}, fmt.Errorf("model reports %d detections, but has only %d boxes", count, s.boxes)
+	}
+
+	s.detections = s.detections[:0]
+	for i := 0; i < count; i++ {
+		score := float32At(scores, i)
+		if score < s.threshold {
+			continue
+		}
+		s.detections = append(s.detections, Detection{
+			Box: tracker.Box{
+				Top:    float32At(locations, 4*i),
+				Left:   float32At(locations, 4*i+1),
+				Bottom: float32At(locations, 4*i+2),
+				Right:  float32At(locations, 4*i+3),
+			},
+			Score: score,
+			Class: int(float32At(classes, i)),
+		})
+	}
+	return Result{Detections: s.detections}, nil
+}
+
+// String describes the post processor.
+func (s *SSD) String() string {
+	return fmt.Sprintf("ssd postprocess, %d boxes, threshold %.2f", s.boxes, s.threshold)
+}
@@ -0,0 +1,90 @@
+package postprocess
+
+import (
+	"fmt"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+	"github.com/Cacsjep/goxis_examples/pkg/yolo"
+)
+
+// YOLO_POSTPROCESS is the name of the post processor for quantized yolov5 and yolov8 models.
+const YOLO_POSTPROCESS = "yolo"
+
+// YOLO_OUTPUTS is the single quantized output of yolov5 ([1, N, 5+C]) and yolov8 ([1, 4+C, N]) models.
+var YOLO_OUTPUTS = []tflite.TensorSpec{
+	{Types: []tflite.TensorType{tflite.Uint8, tflite.Int8}, Shape: []int{1, -1, -1}},
+}
+
+func init() {
+	Register(YOLO_POSTPROCESS, NewYOLO)
+}
+
+// YOLO decodes the raw yolo output tensor and suppresses overlapping detections with a class aware NMS.
+// Fields:
+//   - Decoder: The decoder configured from the output tensor, e.g. for the number of classes.
+//   - NMS: The non-maximum suppression, its method can be changed after creation.
+type YOLO struct {
+	Decoder    *yolo.Decoder
+	NMS        *yolo.NMS
+	output     tflite.Tensor
+	detections []Detection // Reused result buffer
+}
+
+// NewYOLO creates the yolo post processor from the shape and quantization of the output tensor.
+func NewYOLO(cfg Config) (PostProcessor, error) {
+	if err := expectOutputs(cfg.Outputs, YOLO_OUTPUTS); err != nil {
+		return nil, err
+	}
+	output := cfg.Outputs[0]
+	scale, zeroPoint, ok := output.Quantized()
+	if !ok {
+		return nil, fmt.Errorf("output tensor %s has no per tensor quantization", output)
+	}
+	dataType := yolo.Uint8
+	if output.Type == tflite.Int8 {
+		dataType = yolo.Int8
+	}
+	decoder, err := yolo.NewDecoder(yolo.Config{
+		Shape:        output.Shape,
+		DataType:     dataType,
+		Quantization: yolo.Quantization{Scale: scale, ZeroPoint: zeroPoint},
+		Threshold:    cfg.Threshold,
+		InputWidth:   cfg.InputWidth,
+		InputHeight:  cfg.InputHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &YOLO{
+		Decoder: decoder,
+		NMS:     yolo.NewNMS(cfg.IOUThreshold, cfg.MaxDetections),
+		output:  output,
+	}, nil
+}
+
+// Process decodes the output tensor and applies the NMS.
+func (y *YOLO) Process(outputs [][]byte) (Result, error) {
+	if len(outputs) == 0 || len(outputs[0]) < y.Decoder.TensorSize() {
+		return Result{}, fmt.Errorf("output tensor %s needs %d bytes", y.output, y.Decoder.TensorSize())
+	}
+	y.detections = y.detections[:0]
+	for _, det := range y.NMS.Apply(y.Decoder.Decode(outputs[0])) {
+		y.detections = append(y.detections, Detection{Box: det.Box, Score: det.Confidence, Class: det.Class})
+	}
+	return Result{Detections: y.detections}, nil
+}
+
+// NumClasses returns the number of classes of the model.
+func (y *YOLO) NumClasses() int {
+	return y.Decoder.NumClasses()
+}
+
+// String describes the post processor.
+func (y *YOLO) String() string {
+	return fmt.Sprintf("yolo %s layout, %d predictions, %d classes, output %s",
+		y.Decoder.Layout(),
+		y.Decoder.NumPredictions(),
+		y.Decoder.NumClasses(),
+		y.output,
+	)
+}
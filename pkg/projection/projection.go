@@ -0,0 +1,167 @@
+// Package projection maps boxes between the model input and the video stream.
+//
+// A model input rarely has the aspect ratio of the stream, so the frame is either center cropped to the
+// model aspect ratio (objects at the edges are lost) or letterboxed, scaled to fit and padded (the whole frame
+// is seen). The Projection records the crop and padding, so boxes predicted in model coordinates can be mapped
+// back to normalized stream coordinates before tracking and overlay drawing.
+package projection
+
+import (
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// LETTERBOX_PADDING is the gray value used for the padding, like upstream YOLO.
+const LETTERBOX_PADDING = 114
+
+// Mode is the way the stream is fitted into the model input.
+type Mode int
+
+const (
+	CenterCrop Mode = iota // Crop the center of the stream to the model aspect ratio, the edges are not seen
+	Letterbox              // Scale the whole stream to fit the model input and pad the rest
+)
+
+// String returns a readable name of the mode.
+func (m Mode) String() string {
+	if m == Letterbox {
+		return "letterbox"
+	}
+	return "center crop"
+}
+
+// Rect is a rectangle in pixels.
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// Projection describes how a stream is fitted into the model input.
+// Fields:
+//   - Mode: The fitting mode.
+//   - StreamWidth, StreamHeight: Resolution of the stream.
+//   - ModelWidth, ModelHeight: Resolution of the model input.
+//   - Crop: Area of the stream in stream pixels which is fed to the model, the whole stream for Letterbox.
+//   - Content: Area of the model input in model pixels which contains the image, the whole input for CenterCrop.
+type Projection struct {
+	Mode         Mode
+	StreamWidth  int
+	StreamHeight int
+	ModelWidth   int
+	ModelHeight  int
+	Crop         Rect
+	Content      Rect
+}
+
+// New computes the projection of a stream into a model input.
+// The crop matches axlarod.CreateCropMap for the ResizeResolution, so the projection fits the frame provider post processor.
+func New(mode Mode, streamWidth, streamHeight, modelWidth, modelHeight int) Projection {
+	p := Projection{
+		Mode:         mode,
+		StreamWidth:  streamWidth,
+		StreamHeight: streamHeight,
+		ModelWidth:   modelWidth,
+		ModelHeight:  modelHeight,
+		Content:      Rect{Width: modelWidth, Height: modelHeight},
+	}
+
+	if mode == Letterbox {
+		scale := min(float64(modelWidth)/float64(streamWidth), float64(modelHeight)/float64(streamHeight))
+		// Even sizes, the preprocessing does not support odd output sizes
+		p.Content.Width = min(modelWidth, int(float64(streamWidth)*scale)&^1)
+		p.Content.Height = min(modelHeight, int(float64(streamHeight)*scale)&^1)
+		p.Content.X, p.Content.Y = (modelWidth-p.Content.Width)/2, (modelHeight-p.Content.Height)/2
+	}
+
+	// The preprocessing crops the stream to the aspect ratio of its output, for Letterbox this is
+	// (up to rounding) the whole stream
+	p.Crop = centerCrop(streamWidth, streamHeight, p.Content.Width, p.Content.Height)
+	return p
+}
+
+// centerCrop computes the centered area of the stream with the aspect ratio of the given size,
+// the same way as axvdo.CalculateCropDimensions.
+func centerCrop(streamWidth, streamHeight, width, height int) Rect {
+	ratio := float64(width) / float64(height)
+	cropW := float64(streamWidth)
+	cropH := cropW / ratio
+	if cropH > float64(streamHeight) {
+		cropH = float64(streamHeight)
+		cropW = cropH * ratio
+	}
+	crop := Rect{Width: int(cropW), Height: int(cropH)}
+	crop.X, crop.Y = (streamWidth-crop.Width)/2, (streamHeight-crop.Height)/2
+	return crop
+}
+
+// ResizeResolution returns the output resolution of the preprocessing (the scaled crop area),
+// the model input size for CenterCrop and the size without padding for Letterbox.
+func (p Projection) ResizeResolution() (int, int) {
+	return p.Content.Width, p.Content.Height
+}
+
+// ToStream maps a normalized box of the model input into normalized stream coordinates.
+// The box is clamped to the stream, so boxes reaching into the letterbox padding end at the frame border.
+func (p Projection) ToStream(b tracker.Box) tracker.Box {
+	mapX := func(x float32) float32 {
+		content := (x*float32(p.ModelWidth) - float32(p.Content.X)) / float32(p.Content.Width)
+		return clamp((float32(p.Crop.X)+content*float32(p.Crop.Width))/float32(p.StreamWidth), 0, 1)
+	}
+	mapY := func(y float32) float32 {
+		content := (y*float32(p.ModelHeight) - float32(p.Content.Y)) / float32(p.Content.Height)
+		return clamp((float32(p.Crop.Y)+content*float32(p.Crop.Height))/float32(p.StreamHeight), 0, 1)
+	}
+	return tracker.Box{Top: mapY(b.Top), Left: mapX(b.Left), Bottom: mapY(b.Bottom), Right: mapX(b.Right)}
+}
+
+// ToCrop maps a normalized stream box into normalized coordinates of the crop area,
+// which are the coordinates of the preprocessed image before padding.
+func (p Projection) ToCrop(b tracker.Box) tracker.Box {
+	mapX := func(x float32) float32 {
+		return (x*float32(p.StreamWidth) - float32(p.Crop.X)) / float32(p.Crop.Width)
+	}
+	mapY := func(y float32) float32 {
+		return (y*float32(p.StreamHeight) - float32(p.Crop.Y)) / float32(p.Crop.Height)
+	}
+	return tracker.Box{Top: mapY(b.Top), Left: mapX(b.Left), Bottom: mapY(b.Bottom), Right: mapX(b.Right)}
+}
+
+// ToPixels scales a normalized stream box to a resolution, e.g. the overlay stream.
+func ToPixels(b tracker.Box, width, height int) tracker.Box {
+	return tracker.Box{
+		Top:    b.Top * float32(height),
+		Left:   b.Left * float32(width),
+		Bottom: b.Bottom * float32(height),
+		Right:  b.Right * float32(width),
+	}
+}
+
+// Pad fills a whole interleaved RGB model input with the letterbox padding.
+// It only needs to be called once, Fill only overwrites the content area.
+func (p Projection) Pad(dst []byte) {
+	for i := range dst {
+		dst[i] = LETTERBOX_PADDING
+	}
+}
+
+// Fill copies the preprocessed interleaved RGB image into the content area of the model input.
+// Returns false if one of the buffers is too small.
+func (p Projection) Fill(dst, src []byte) bool {
+	rowSize := p.Content.Width * 3
+	modelRowSize := p.ModelWidth * 3
+	if len(src) < rowSize*p.Content.Height || len(dst) < modelRowSize*p.ModelHeight {
+		return false
+	}
+	if p.Content.Width == p.ModelWidth && p.Content.Height == p.ModelHeight {
+		copy(dst, src[:rowSize*p.Content.Height])
+		return true
+	}
+	offset := p.Content.Y*modelRowSize + p.Content.X*3
+	for y := 0; y < p.Content.Height; y++ {
+		copy(dst[offset+y*modelRowSize:], src[y*rowSize:(y+1)*rowSize])
+	}
+	return true
+}
+
+// clamp limits a value to the range [lo, hi].
+func clamp(v, lo, hi float32) float32 {
+	return max(lo, min(v, hi))
+}
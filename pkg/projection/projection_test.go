@@ -0,0 +1,182 @@
+package projection
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// boxEqual compares boxes with a tolerance for the float32 math.
+func boxEqual(a, b tracker.Box) bool {
+	near := func(x, y float32) bool { return math.Abs(float64(x-y)) < 1e-5 }
+	return near(a.Top, b.Top) && near(a.Left, b.Left) && near(a.Bottom, b.Bottom) && near(a.Right, b.Right)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Projection
+		crop    Rect
+		content Rect
+		resizeW int
+		resizeH int
+	}{
+		{
+			name:    "letterbox 16:9 into 640x640",
+			p:       New(Letterbox, 1920, 1080, 640, 640),
+			crop:    Rect{Width: 1920, Height: 1080},
+			content: Rect{X: 0, Y: 140, Width: 640, Height: 360}, // 140 rows of padding above and below
+			resizeW: 640, resizeH: 360,
+		},
+		{
+			name:    "letterbox rounds to even sizes",
+			p:       New(Letterbox, 1280, 720, 416, 416),
+			crop:    Rect{Width: 1280, Height: 720},
+			content: Rect{X: 0, Y: 91, Width: 416, Height: 234},
+			resizeW: 416, resizeH: 234,
+		},
+		{
+			name:    "center crop 16:9 into 300x300",
+			p:       New(CenterCrop, 1920, 1080, 300, 300),
+			crop:    Rect{X: 420, Y: 0, Width: 1080, Height: 1080},
+			content: Rect{Width: 300, Height: 300},
+			resizeW: 300, resizeH: 300,
+		},
+		{
+			name:    "center crop of a portrait stream",
+			p:       New(CenterCrop, 1080, 1920, 300, 300),
+			crop:    Rect{X: 0, Y: 420, Width: 1080, Height: 1080},
+			content: Rect{Width: 300, Height: 300},
+			resizeW: 300, resizeH: 300,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.p.Crop != tt.crop || tt.p.Content != tt.content {
+				t.Errorf("got crop %+v content %+v, want crop %+v content %+v", tt.p.Crop, tt.p.Content, tt.crop, tt.content)
+			}
+			if w, h := tt.p.ResizeResolution(); w != tt.resizeW || h != tt.resizeH {
+				t.Errorf("got resize resolution %dx%d, want %dx%d", w, h, tt.resizeW, tt.resizeH)
+			}
+		})
+	}
+}
+
+func TestToStream(t *testing.T) {
+	letterbox := New(Letterbox, 1920, 1080, 640, 640)
+	crop := New(CenterCrop, 1920, 1080, 300, 300)
+	tests := []struct {
+		name  string
+		p     Projection
+		model tracker.Box
+		want  tracker.Box
+	}{
+		{
+			name:  "letterbox content area is the whole stream",
+			p:     letterbox,
+			model: tracker.Box{Top: 140.0 / 640, Left: 0, Bottom: 500.0 / 640, Right: 1},
+			want:  tracker.Box{Top: 0, Left: 0, Bottom: 1, Right: 1},
+		},
+		{
+			name:  "letterbox box in the middle",
+			p:     letterbox,
+			model: tracker.Box{Top: 0.5, Left: 0.25, Bottom: 320.0/640 + 90.0/640, Right: 0.75},
+			want:  tracker.Box{Top: 0.5, Left: 0.25, Bottom: 0.75, Right: 0.75},
+		},
+		{
+			name:  "letterbox box reaching into the padding is clamped",
+			p:     letterbox,
+			model: tracker.Box{Top: 100.0 / 640, Left: 0.1, Bottom: 600.0 / 640, Right: 0.2},
+			want:  tracker.Box{Top: 0, Left: 0.1, Bottom: 1, Right: 0.2},
+		},
+		{
+			name:  "letterbox box completely on the padding",
+			p:     letterbox,
+			model: tracker.Box{Top: 0, Left: 0.1, Bottom: 100.0 / 640, Right: 0.2},
+			want:  tracker.Box{Top: 0, Left: 0.1, Bottom: 0, Right: 0.2},
+		},
+		{
+			name:  "center crop edges",
+			p:     crop,
+			model: tracker.Box{Top: 0, Left: 0, Bottom: 1, Right: 1},
+			want:  tracker.Box{Top: 0, Left: 420.0 / 1920, Bottom: 1, Right: 1500.0 / 1920},
+		},
+		{
+			name:  "center crop center",
+			p:     crop,
+			model: tracker.Box{Top: 0.25, Left: 0.25, Bottom: 0.75, Right: 0.75},
+			want:  tracker.Box{Top: 0.25, Left: 690.0 / 1920, Bottom: 0.75, Right: 1230.0 / 1920},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.ToStream(tt.model); !boxEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	boxes := []tracker.Box{
+		{Top: 0, Left: 0, Bottom: 1, Right: 1},
+		{Top: 0.1, Left: 0.2, Bottom: 0.3, Right: 0.4},
+		{Top: 0.5, Left: 0.5, Bottom: 0.5, Right: 0.5},
+	}
+	// Without padding the model input is the crop area, so ToCrop undoes ToStream
+	crop := New(CenterCrop, 1920, 1080, 300, 300)
+	for _, b := range boxes {
+		if got := crop.ToCrop(crop.ToStream(b)); !boxEqual(got, b) {
+			t.Errorf("center crop round trip of %+v got %+v", b, got)
+		}
+	}
+
+	// For letterbox the crop is the whole stream, so a stream box is a crop box
+	letterbox := New(Letterbox, 1920, 1080, 640, 640)
+	for _, b := range boxes {
+		if got := letterbox.ToCrop(b); !boxEqual(got, b) {
+			t.Errorf("letterbox ToCrop of %+v got %+v", b, got)
+		}
+	}
+
+	// Stream boxes outside of the center crop are not clamped by ToCrop
+	if got := crop.ToCrop(tracker.Box{Top: 0, Left: 0, Bottom: 1, Right: 1}); !boxEqual(got, tracker.Box{Top: 0, Left: -420.0 / 1080, Bottom: 1, Right: 1500.0 / 1080}) {
+		t.Errorf("ToCrop of the whole stream got %+v", got)
+	}
+}
+
+func TestToPixels(t *testing.T) {
+	p := New(CenterCrop, 1920, 1080, 300, 300)
+	got := ToPixels(p.ToStream(tracker.Box{Top: 0, Left: 0, Bottom: 1, Right: 1}), 1920, 1080)
+	if want := (tracker.Box{Top: 0, Left: 420, Bottom: 1080, Right: 1500}); math.Abs(float64(got.Left-want.Left)) > 1e-3 ||
+		math.Abs(float64(got.Right-want.Right)) > 1e-3 || got.Top != want.Top || got.Bottom != want.Bottom {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFill(t *testing.T) {
+	p := New(Letterbox, 8, 4, 8, 8)
+	dst := make([]byte, 8*8*3)
+	p.Pad(dst)
+	src := make([]byte, p.Content.Width*p.Content.Height*3)
+	for i := range src {
+		src[i] = 1
+	}
+	if !p.Fill(dst, src) {
+		t.Fatal("buffers too small")
+	}
+	// 2 rows of padding above and below the 4 rows of content
+	for y := 0; y < 8; y++ {
+		want := byte(LETTERBOX_PADDING)
+		if y >= 2 && y < 6 {
+			want = 1
+		}
+		if row := dst[y*8*3 : (y+1)*8*3]; row[0] != want || row[len(row)-1] != want {
+			t.Errorf("row %d starts with %d, want %d", y, row[0], want)
+		}
+	}
+	if p.Fill(dst[:10], src) {
+		t.Error("filled a too small model input")
+	}
+}
@@ -0,0 +1,181 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+// DrawTransparent makes an area from the top left corner transparent, like the cairo context.
+func (c *Canvas) DrawTransparent(width, height int) {
+	draw.Draw(c.Img, image.Rect(0, 0, width, height), image.Transparent, image.Point{}, draw.Src)
+}
+
+// DrawRect strokes a rectangle, it replaces the pixels like the cairo context, which uses OPERATOR_SOURCE.
+func (c *Canvas) DrawRect(x float64, y float64, width float64, height float64, color color.RGBA, linewidth float64) {
+	m := c.newMask()
+	m.stroke(rectPoints(x, y, width, height), true, linewidth, nil)
+	c.paint(m, color, OperatorSource)
+}
+
+// DrawText draws a text with its top left corner at x, y in a font size.
+func (c *Canvas) DrawText(text string, x float64, y float64, size float64, font_name string, color color.RGBA) {
+	c.showText(text, x, y+Ascent(size), size, color)
+}
+
+// DrawBoundingBoxRect draws a rectangle filled with the color multiplied by alpha and a dashed outline.
+func (c *Canvas) DrawBoundingBoxRect(x float64, y float64, width float64, height float64, color color.RGBA, linewidth float64, alpha float64) {
+	fillColor := color
+	fillColor.A = uint8(float64(color.A) * alpha)
+	c.Rect(x, y, width, height, scene.Style{Stroke: color, Fill: fillColor, LineWidth: linewidth, Dash: []float64{4, 4}})
+}
+
+// DrawBoundingBox draws a bounding box with a label at its top left corner and,
+// if the box is wider than minBoxSizeRenderW, its size at the top right corner.
+func (c *Canvas) DrawBoundingBox(x float64, y float64, width float64, height float64, rectColor color.RGBA, label string, labelColor color.RGBA, labelSize float64, labelFont string, minBoxSizeRenderW int) {
+	rectLinewidth := float64(3)
+	c.DrawBoundingBoxRect(x, y, width, height, rectColor, rectLinewidth, 0.3)
+	c.DrawBoundingBoxLabel(label, x-(rectLinewidth/2), y-(rectLinewidth/2), 7, labelSize, labelFont, labelColor, rectColor)
+	if width > float64(minBoxSizeRenderW) {
+		c.DrawBoundingBoxSize(x, y, width, height, 7, labelSize, labelFont, labelColor, rectColor)
+	}
+}
+
+// DrawBoundingBoxLabel draws a text on a background box with its top left corner at x, y.
+func (c *Canvas) DrawBoundingBoxLabel(text string, x float64, y float64, padding float64, size float64, font_name string, textColor color.RGBA, bgColor color.RGBA) {
+	rectHeight := size + padding
+	c.fillRect(x, y, TextWidth(text, size)+padding*2, rectHeight, bgColor)
+	c.showText(text, x+padding, y-padding+rectHeight, size, textColor)
+}
+
+// DrawBoundingBoxSize draws the size of a box in pixels on a background box at its top right corner.
+func (c *Canvas) DrawBoundingBoxSize(x float64, y float64, width float64, height float64, padding float64, size float64, font_name string, textColor color.RGBA, bgColor color.RGBA) {
+	text := fmt.Sprintf("%dx%d", int(width), int(height))
+	rectWidth := TextWidth(text, size) + padding*2
+	rectHeight := size + padding
+	rectX := x + width - rectWidth
+	c.fillRect(rectX, y, rectWidth, rectHeight, bgColor)
+	c.showText(text, rectX+padding, y-padding+rectHeight, size, textColor)
+}
+
+// PaintSurface draws an image with its top left corner at x, y.
+func (c *Canvas) PaintSurface(s image.Image, x, y float64) {
+	c.drawImage(s, x, y, 1)
+}
+
+// Clear makes the whole canvas transparent.
+func (c *Canvas) Clear(width, height int) {
+	c.DrawTransparent(width, height)
+}
+
+// Text draws a text of a scene with an optional background box.
+func (c *Canvas) Text(text string, x, y float64, style scene.TextStyle) {
+	c.fillRect(x, y, TextWidth(text, style.Size)+style.Padding*2, style.Size+style.Padding*2, style.Background)
+	c.showText(text, x+style.Padding, y+style.Padding+style.Size, style.Size, style.Color)
+}
+
+// TextWidth returns the width of a text of a scene in pixels.
+func (c *Canvas) TextWidth(text string, style scene.TextStyle) float64 {
+	return TextWidth(text, style.Size)
+}
+
+// Rect draws a rectangle of a scene.
+func (c *Canvas) Rect(x, y, width, height float64, style scene.Style) {
+	c.Path(rectPoints(x, y, width, height), true, style)
+}
+
+// Path draws connected line segments of a scene, closed paths are filled.
+func (c *Canvas) Path(points []scene.Point, closed bool, style scene.Style) {
+	if closed && style.Fill.A > 0 {
+		m := c.newMask()
+		m.polygon(points)
+		c.paint(m, style.Fill, OperatorOver)
+	}
+	if style.Stroke.A > 0 && style.LineWidth > 0 {
+		m := c.newMask()
+		m.stroke(points, closed, style.LineWidth, style.Dash)
+		c.paint(m, style.Stroke, OperatorOver)
+	}
+}
+
+// Image draws a PNG file of a scene, the file is decoded on first use and cached.
+func (c *Canvas) Image(path string, x, y float64, align scene.Point, scale float64) error {
+	img, found := c.images[path]
+	if !found {
+		var err error
+		if img, err = ReadPNG(path); err != nil {
+			return err
+		}
+		c.images[path] = img
+	}
+	b := img.Bounds()
+	x -= align.X * float64(b.Dx()) * scale
+	y -= align.Y * float64(b.Dy()) * scale
+	c.drawImage(img, x, y, scale)
+	return nil
+}
+
+// drawImage draws an image scaled with the nearest neighbour at x, y.
+func (c *Canvas) drawImage(img image.Image, x, y, scale float64) {
+	b := img.Bounds()
+	m := c.newMask()
+	m.rect(x, y, float64(b.Dx())*scale, float64(b.Dy())*scale)
+	m.each(func(px, py int) {
+		sx := b.Min.X + int((float64(px)+0.5-x)/scale)
+		sy := b.Min.Y + int((float64(py)+0.5-y)/scale)
+		n := color.NRGBAModel.Convert(img.At(sx, sy)).(color.NRGBA)
+		c.set(px, py, color.RGBA{R: n.R, G: n.G, B: n.B, A: n.A}, OperatorOver)
+	})
+}
+
+// fillRect fills a rectangle, colors with zero alpha are skipped.
+func (c *Canvas) fillRect(x, y, width, height float64, col color.RGBA) {
+	if col.A == 0 {
+		return
+	}
+	m := c.newMask()
+	m.rect(x, y, width, height)
+	c.paint(m, col, OperatorOver)
+}
+
+// showText draws a text with the baseline of the first character at x, y, like cairo show text.
+func (c *Canvas) showText(text string, x, baseline, size float64, col color.RGBA) {
+	pixel := size / glyphEm
+	top := baseline - Ascent(size)
+	m := c.newMask()
+	for _, r := range text {
+		for column, bits := range glyph(r) {
+			for row := 0; row < glyphRows; row++ {
+				if bits&(1<<row) != 0 {
+					m.rect(x+float64(column)*pixel, top+float64(row)*pixel, pixel, pixel)
+				}
+			}
+		}
+		x += glyphAdvance * pixel
+	}
+	c.paint(m, col, OperatorOver)
+}
+
+// rectPoints returns the corners of a rectangle.
+func rectPoints(x, y, width, height float64) []scene.Point {
+	return []scene.Point{{X: x, Y: y}, {X: x + width, Y: y}, {X: x + width, Y: y + height}, {X: x, Y: y + height}}
+}
+
+// ReadPNG decodes a PNG file.
+func ReadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
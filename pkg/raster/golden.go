@@ -0,0 +1,74 @@
+package raster
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WritePNG encodes the canvas as PNG file.
+func (c *Canvas) WritePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, c.Img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Diff returns the number of pixels which differ by more than tolerance in a channel.
+// Images of different sizes differ in all pixels of the larger one.
+func Diff(a, b image.Image, tolerance uint8) int {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return max(ab.Dx()*ab.Dy(), bb.Dx()*bb.Dy())
+	}
+	diff := 0
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			r1, g1, b1, a1 := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			for _, d := range [4][2]uint32{{r1, r2}, {g1, g2}, {b1, b2}, {a1, a2}} {
+				if absDiff(d[0]>>8, d[1]>>8) > uint32(tolerance) {
+					diff++
+					break
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// MatchGolden compares the canvas with a golden PNG, e.g. testdata/overlay.png in a test.
+// The golden file is written instead if update is set or it does not exist yet, then review and commit it.
+// Returns an error if the images differ in any pixel by more than tolerance.
+func (c *Canvas) MatchGolden(path string, tolerance uint8, update bool) error {
+	golden, err := ReadPNG(path)
+	if update || errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return c.WritePNG(path)
+	}
+	if err != nil {
+		return err
+	}
+	if diff := Diff(c.Img, golden, tolerance); diff > 0 {
+		return fmt.Errorf("%d pixels differ from the golden image %s", diff, path)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package raster is a pure Go software rasterizer for overlays on an image.RGBA.
+//
+// A Canvas implements the drawing operations the examples use on the axoverlay cairo context, like
+// DrawTransparent, DrawRect, DrawText, DrawBoundingBox and PaintSurface, with the same arguments, and the
+// scene.Canvas interface, so a scene or render code can be drawn without a camera, e.g. in a test which
+// compares the result to a golden PNG, or to serve a preview image of the overlay from a webserver.
+//
+// Shapes are filled at pixel centers without anti-aliasing and text uses a built in 5x7 pixel font scaled
+// to the font size, so the output is deterministic but not identical to cairo. Font names are ignored.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+// Operator is the compositing of a drawing operation.
+type Operator int
+
+const (
+	OperatorOver   Operator = iota // Blend the color over the image, like cairo OPERATOR_OVER
+	OperatorSource                 // Replace the pixels with the color, like cairo OPERATOR_SOURCE
+)
+
+// Canvas draws on an image.RGBA, it is not safe for concurrent use.
+// Colors are non premultiplied like the colors passed to cairo, the image is premultiplied like every image.RGBA.
+type Canvas struct {
+	Img    *image.RGBA
+	images map[string]image.Image
+	mask   *mask // mask is reused by all shapes, it is cleared when painted
+}
+
+// New creates a transparent canvas.
+func New(width, height int) *Canvas {
+	return &Canvas{Img: image.NewRGBA(image.Rect(0, 0, width, height)), images: map[string]image.Image{}}
+}
+
+// Width returns the width of the canvas in pixels.
+func (c *Canvas) Width() int {
+	return c.Img.Bounds().Dx()
+}
+
+// Height returns the height of the canvas in pixels.
+func (c *Canvas) Height() int {
+	return c.Img.Bounds().Dy()
+}
+
+// mask marks the pixels covered by a shape, so overlapping parts of a shape are blended only once.
+type mask struct {
+	width, height int
+	covered       []bool
+	bounds        image.Rectangle // bounds contains all covered pixels
+}
+
+// newMask returns the empty mask of the canvas.
+func (c *Canvas) newMask() *mask {
+	if c.mask == nil || c.mask.width != c.Width() || c.mask.height != c.Height() {
+		c.mask = &mask{width: c.Width(), height: c.Height(), covered: make([]bool, c.Width()*c.Height())}
+	}
+	return c.mask
+}
+
+// cover marks the pixels from x0 to x1 of a row as covered.
+func (m *mask) cover(y, x0, x1 int) {
+	if x0 >= x1 {
+		return
+	}
+	for x := x0; x < x1; x++ {
+		m.covered[y*m.width+x] = true
+	}
+	m.bounds = m.bounds.Union(image.Rect(x0, y, x1, y+1))
+}
+
+// rect covers the pixels with their center inside a rectangle.
+func (m *mask) rect(x, y, width, height float64) {
+	if width < 0 {
+		x, width = x+width, -width
+	}
+	if height < 0 {
+		y, height = y+height, -height
+	}
+	x0, x1 := clampPixel(x, m.width), clampPixel(x+width, m.width)
+	y0, y1 := clampPixel(y, m.height), clampPixel(y+height, m.height)
+	for py := y0; py < y1; py++ {
+		m.cover(py, x0, x1)
+	}
+}
+
+// clampPixel returns the first pixel with its center at or after v, clamped to 0..size.
+func clampPixel(v float64, size int) int {
+	return int(math.Max(0, math.Min(float64(size), math.Ceil(v-0.5))))
+}
+
+// polygon covers the pixels with their center inside a polygon with the nonzero winding rule, like cairo.
+func (m *mask) polygon(points []scene.Point) {
+	if len(points) < 3 {
+		return
+	}
+	minY, maxY := points[0].Y, points[0].Y
+	for _, p := range points[1:] {
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	type crossing struct {
+		x       float64
+		winding int
+	}
+	var crossings []crossing
+	for py := clampPixel(minY, m.height); py < clampPixel(maxY, m.height); py++ {
+		cy := float64(py) + 0.5
+		crossings = crossings[:0]
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			winding := 1
+			if a.Y > b.Y {
+				a, b = b, a
+				winding = -1
+			}
+			// Half open, so a vertex on the scanline is counted once
+			if cy < a.Y || cy >= b.Y {
+				continue
+			}
+			crossings = append(crossings, crossing{x: a.X + (cy-a.Y)*(b.X-a.X)/(b.Y-a.Y), winding: winding})
+		}
+		for i := 1; i < len(crossings); i++ {
+			for j := i; j > 0 && crossings[j].x < crossings[j-1].x; j-- {
+				crossings[j], crossings[j-1] = crossings[j-1], crossings[j]
+			}
+		}
+		winding := 0
+		for i, cr := range crossings {
+			winding += cr.winding
+			if winding != 0 && i+1 < len(crossings) {
+				m.cover(py, clampPixel(cr.x, m.width), clampPixel(crossings[i+1].x, m.width))
+			}
+		}
+	}
+}
+
+// line covers a straight line of a width, extend lengthens both ends by half the width like a square cap.
+func (m *mask) line(a, b scene.Point, width float64, extend bool) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 || width <= 0 {
+		return
+	}
+	ux, uy := dx/length, dy/length
+	if extend {
+		a = scene.Point{X: a.X - ux*width/2, Y: a.Y - uy*width/2}
+		b = scene.Point{X: b.X + ux*width/2, Y: b.Y + uy*width/2}
+	}
+	nx, ny := -uy*width/2, ux*width/2
+	m.polygon([]scene.Point{
+		{X: a.X + nx, Y: a.Y + ny},
+		{X: b.X + nx, Y: b.Y + ny},
+		{X: b.X - nx, Y: b.Y - ny},
+		{X: a.X - nx, Y: a.Y - ny},
+	})
+}
+
+// stroke covers the outline of a path, the dash pattern continues over the corners.
+// Closed paths without dashes get square ends at the corners, so the corners of a rectangle are filled.
+func (m *mask) stroke(points []scene.Point, closed bool, width float64, dash []float64) {
+	if closed {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+	dashLength := 0.0
+	for _, d := range dash {
+		dashLength += d
+	}
+	if dashLength <= 0 {
+		for i := 1; i < len(points); i++ {
+			m.line(points[i-1], points[i], width, closed)
+		}
+		return
+	}
+
+	dashIndex, dashLeft, on := 0, dash[0], true
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		for pos := 0.0; pos < length; {
+			step := math.Min(dashLeft, length-pos)
+			if on {
+				from, to := pos/length, (pos+step)/length
+				m.line(
+					scene.Point{X: a.X + (b.X-a.X)*from, Y: a.Y + (b.Y-a.Y)*from},
+					scene.Point{X: a.X + (b.X-a.X)*to, Y: a.Y + (b.Y-a.Y)*to},
+					width, false,
+				)
+			}
+			pos += step
+			dashLeft -= step
+			if dashLeft <= 0 {
+				dashIndex = (dashIndex + 1) % len(dash)
+				dashLeft, on = dash[dashIndex], !on
+			}
+		}
+	}
+}
+
+// paint composites a color on the covered pixels and clears the mask.
+func (c *Canvas) paint(m *mask, col color.RGBA, op Operator) {
+	m.each(func(x, y int) {
+		if op == OperatorSource || col.A > 0 {
+			c.set(x, y, col, op)
+		}
+	})
+}
+
+// each calls fn for the covered pixels and clears the mask.
+func (m *mask) each(fn func(x, y int)) {
+	for y := m.bounds.Min.Y; y < m.bounds.Max.Y; y++ {
+		for x := m.bounds.Min.X; x < m.bounds.Max.X; x++ {
+			if i := y*m.width + x; m.covered[i] {
+				m.covered[i] = false
+				fn(x, y)
+			}
+		}
+	}
+	m.bounds = image.Rectangle{}
+}
+
+// set composites a non premultiplied color on a pixel.
+func (c *Canvas) set(x, y int, col color.RGBA, op Operator) {
+	a := uint32(col.A)
+	r, g, b := uint32(col.R)*a/255, uint32(col.G)*a/255, uint32(col.B)*a/255
+	i := c.Img.PixOffset(x, y)
+	pix := c.Img.Pix[i : i+4 : i+4]
+	if op == OperatorOver {
+		inv := 255 - a
+		r += uint32(pix[0]) * inv / 255
+		g += uint32(pix[1]) * inv / 255
+		b += uint32(pix[2]) * inv / 255
+		a += uint32(pix[3]) * inv / 255
+	}
+	pix[0], pix[1], pix[2], pix[3] = uint8(r), uint8(g), uint8(b), uint8(a)
+}
@@ -0,0 +1,152 @@
+package raster
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+)
+
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+var (
+	red   = color.RGBA{R: 244, G: 67, B: 54, A: 255}
+	green = color.RGBA{R: 76, G: 175, B: 80, A: 255}
+	blue  = color.RGBA{R: 33, G: 150, B: 243, A: 255}
+	amber = color.RGBA{R: 255, G: 193, B: 7, A: 255}
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// testScene returns a scene with every node type except images, meant for a 640x360 stream.
+func testScene() *scene.Scene {
+	s := scene.New()
+	s.Reference = scene.Point{X: 640, Y: 360}
+	fill := green
+	fill.A = 70
+	s.Set("zone", scene.Group{Nodes: []scene.Node{
+		scene.Polygon{
+			Points: []scene.Point{{X: 0.05, Y: 0.5}, {X: 0.45, Y: 0.5}, {X: 0.45, Y: 0.95}, {X: 0.05, Y: 0.95}},
+			Style:  scene.Style{Stroke: green, Fill: fill, LineWidth: 2},
+		},
+		scene.Text{Pos: scene.Point{X: 0.05, Y: 0.5}, Text: "entrance: 1", TextStyle: scene.TextStyle{Size: 14, Color: green, Padding: 4}},
+	}})
+	s.Set("line", scene.Polyline{
+		Points: []scene.Point{{X: 0.5, Y: 0}, {X: 0.5, Y: 1}},
+		Style:  scene.Style{Stroke: amber, LineWidth: 4, Dash: []float64{10, 6}},
+	})
+	// The label of a box at the top edge is clamped into the canvas
+	s.Set("objects", scene.Group{Offset: scene.Point{X: 0.1}, Nodes: []scene.Node{
+		scene.Box(scene.Point{X: 0.5, Y: 0}, scene.Point{X: 0.2, Y: 0.3}, blue, "ID-1 CAR 80%", scene.TextStyle{Size: 12, Color: white, Padding: 3}),
+		scene.Box(scene.Point{X: 0.2, Y: 0.55}, scene.Point{X: 0.1, Y: 0.35}, blue, "ID-2 PERSON 90%", scene.TextStyle{Size: 12, Color: white, Padding: 3}),
+	}})
+	s.Set("dots", scene.Group{Nodes: []scene.Node{
+		scene.Dot{Pos: scene.Point{X: 0.9, Y: 0.9}, Size: 3, Color: red},
+		scene.Dot{Pos: scene.Point{X: 0.92, Y: 0.9}, Size: 3, Color: red},
+		scene.Dot{Pos: scene.Point{X: 0.94, Y: 0.9}, Size: 3, Color: red},
+	}})
+	s.Set("title", scene.Text{Pos: scene.Point{X: 0.02, Y: 0.02}, Text: "Goxis", TextStyle: scene.TextStyle{Size: 20, Color: white, Background: red, Padding: 5}})
+	return s
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		draw          func(c *Canvas) error
+	}{
+		{
+			name: "rect", width: 64, height: 48,
+			draw: func(c *Canvas) error {
+				c.DrawRect(8, 8, 48, 32, red, 2)
+				c.DrawRect(20, 16, 24, 16, blue, 5)
+				return nil
+			},
+		},
+		{
+			name: "text", width: 160, height: 48,
+			draw: func(c *Canvas) error {
+				c.DrawText("Hello 123", 4, 4, 14, "sans", white)
+				c.DrawText("scaled text", 4, 26, 18, "serif", amber)
+				return nil
+			},
+		},
+		{
+			name: "bounding_box", width: 240, height: 120,
+			draw: func(c *Canvas) error {
+				// The first box is wide enough for its size label, the second one is not
+				c.DrawBoundingBox(10, 30, 160, 60, blue, "PERSON 90%", white, 10, "sans", 100)
+				c.DrawBoundingBox(190, 60, 40, 50, red, "CAR", white, 10, "sans", 100)
+				return nil
+			},
+		},
+		{
+			name: "scene", width: 640, height: 360,
+			draw: func(c *Canvas) error { return testScene().Render(c, 640, 360) },
+		},
+		{
+			// The same scene on a smaller stream, the style sizes are scaled down with the reference
+			name: "scene_small", width: 320, height: 180,
+			draw: func(c *Canvas) error { return testScene().Render(c, 320, 180) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.width, tt.height)
+			if err := tt.draw(c); err != nil {
+				t.Fatal(err)
+			}
+			// Translucent pixels are rounded when the premultiplied image is stored as non premultiplied PNG
+			if err := c.MatchGolden(filepath.Join("testdata", tt.name+".png"), 1, *update); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	a.SetRGBA(1, 1, color.RGBA{R: 10, A: 255})
+	b.SetRGBA(1, 1, color.RGBA{R: 12, A: 255})
+	b.SetRGBA(2, 2, color.RGBA{B: 100, A: 255})
+
+	if diff := Diff(a, b, 0); diff != 2 {
+		t.Errorf("got %d different pixels without tolerance, want 2", diff)
+	}
+	if diff := Diff(a, b, 2); diff != 1 {
+		t.Errorf("got %d different pixels with tolerance 2, want 1", diff)
+	}
+	if diff := Diff(a, image.NewRGBA(image.Rect(0, 0, 5, 4)), 255); diff != 20 {
+		t.Errorf("got %d different pixels of different sizes, want 20", diff)
+	}
+}
+
+func TestMatchGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "rect.png")
+	c := New(32, 32)
+	c.DrawRect(4, 4, 24, 24, red, 2)
+
+	// A missing golden is written
+	if err := c.MatchGolden(path, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MatchGolden(path, 0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	c.DrawRect(10, 10, 12, 12, blue, 2)
+	if err := c.MatchGolden(path, 0, false); err == nil || !strings.Contains(err.Error(), "pixels differ") {
+		t.Fatalf("got %v, want differing pixels", err)
+	}
+	// Update replaces the golden
+	if err := c.MatchGolden(path, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MatchGolden(path, 0, false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package recording writes video frames into rotating segment files.
+//
+// H.265 frames are written as Annex-B elementary stream, each segment starts with a key frame including
+// the parameter sets, so every .h265 file can be played on its own, e.g. with ffplay. Raw YUV frames are
+// written one file per frame in the layout of the replay package, so a recording can be replayed directly.
+package recording
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Format is the format of the recorded frames.
+type Format int
+
+const (
+	H265 Format = iota // Annex-B elementary stream, segments start at key frames
+	YUV                // Raw frames, one file per frame
+)
+
+// Ext returns the file extension of the segments.
+func (f Format) Ext() string {
+	if f == H265 {
+		return ".h265"
+	}
+	return ".yuv"
+}
+
+// ErrDone is returned by Write once a total limit of the recording is reached.
+var ErrDone = errors.New("recording limit reached")
+
+// Config configures a recording, a zero limit is unlimited.
+// Fields:
+//   - Dir: Directory of the segments, it is created if it does not exist.
+//   - Format: Format of the frames.
+//   - SegmentFrames: Frames per segment, YUV recordings always use one frame per segment.
+//   - SegmentDuration: Duration of a segment.
+//   - MaxSegments: Number of kept segments, the oldest segment is removed when a new one starts, for YUV this is the number of kept frames.
+//   - MaxFrames: Total number of recorded frames.
+//   - MaxDuration: Total duration of the recording.
+type Config struct {
+	Dir             string
+	Format          Format
+	SegmentFrames   int
+	SegmentDuration time.Duration
+	MaxSegments     int
+	MaxFrames       int
+	MaxDuration     time.Duration
+}
+
+// Writer writes frames into rotating segments, it is not safe for concurrent use.
+type Writer struct {
+	cfg      Config
+	file     *os.File
+	segments []string  // Paths of the kept segments, the last one is open
+	index    int       // Index of the next segment
+	frames   int       // Frames in the current segment
+	started  time.Time // Time of the first frame in the current segment
+	first    time.Time // Time of the first recorded frame
+	total    int       // Recorded frames
+	done     bool
+}
+
+// NewWriter creates the recording directory and a writer.
+func NewWriter(cfg Config) (*Writer, error) {
+	if cfg.Format == YUV {
+		cfg.SegmentFrames = 1
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{cfg: cfg}, nil
+}
+
+// Write writes a frame.
+// H.265 frames before the first key frame are skipped, and a full segment is continued until the next key frame.
+// Args:
+//   - data: The frame data, H.265 key frames must include the parameter sets.
+//   - keyFrame: True for H.265 IDR frames, YUV frames are always key frames.
+//   - t: The capture time of the frame.
+//
+// Returns:
+//
+//	error: ErrDone once a total limit is reached, or an error if writing fails.
+func (w *Writer) Write(data []byte, keyFrame bool, t time.Time) error {
+	if w.done {
+		return ErrDone
+	}
+	if w.cfg.Format == YUV {
+		keyFrame = true
+	}
+	if w.first.IsZero() && !keyFrame {
+		return nil
+	}
+	if w.first.IsZero() {
+		w.first = t
+	}
+	if (w.cfg.MaxFrames > 0 && w.total >= w.cfg.MaxFrames) || (w.cfg.MaxDuration > 0 && t.Sub(w.first) >= w.cfg.MaxDuration) {
+		w.done = true
+		return errors.Join(ErrDone, w.closeFile())
+	}
+
+	if keyFrame && (w.file == nil || w.segmentFull(t)) {
+		if err := w.rotate(data, t); err != nil {
+			return err
+		}
+	}
+	if _, err := w.file.Write(data); err != nil {
+		return err
+	}
+	w.frames++
+	w.total++
+	return nil
+}
+
+// segmentFull returns true if the current segment reached its frame count or duration.
+func (w *Writer) segmentFull(t time.Time) bool {
+	return (w.cfg.SegmentFrames > 0 && w.frames >= w.cfg.SegmentFrames) ||
+		(w.cfg.SegmentDuration > 0 && t.Sub(w.started) >= w.cfg.SegmentDuration)
+}
+
+// rotate closes the current segment, removes the oldest segments above MaxSegments and opens the next segment.
+func (w *Writer) rotate(data []byte, t time.Time) error {
+	if w.cfg.Format == H265 && !IsAnnexB(data) {
+		return fmt.Errorf("h265 frame is not an Annex-B byte stream")
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	for w.cfg.MaxSegments > 0 && len(w.segments) >= w.cfg.MaxSegments {
+		if err := os.Remove(w.segments[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		w.segments = w.segments[1:]
+	}
+
+	path := filepath.Join(w.cfg.Dir, fmt.Sprintf("%06d%s", w.index, w.cfg.Format.Ext()))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.segments = append(w.segments, path)
+	w.index++
+	w.frames = 0
+	w.started = t
+	return nil
+}
+
+// closeFile closes the current segment if one is open.
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Close closes the current segment, later writes return ErrDone.
+func (w *Writer) Close() error {
+	w.done = true
+	return w.closeFile()
+}
+
+// Done returns true once a total limit is reached or the writer is closed.
+func (w *Writer) Done() bool {
+	return w.done
+}
+
+// Frames returns the number of recorded frames.
+func (w *Writer) Frames() int {
+	return w.total
+}
+
+// Segments returns the paths of the kept segments, the last one is the current segment.
+func (w *Writer) Segments() []string {
+	return w.segments
+}
+
+// IsAnnexB returns true if the data starts with an Annex-B start code.
+func IsAnnexB(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0, 0, 0, 1}) || bytes.HasPrefix(data, []byte{0, 0, 1})
+}
@@ -0,0 +1,184 @@
+package recording
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var (
+	start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	idr   = []byte{0, 0, 0, 1, 'I'} // Key frame with start code
+	delta = []byte{0, 0, 0, 1, 'P'}
+)
+
+// frame is a frame written in a test, one per second.
+type frame struct {
+	key bool
+}
+
+// write writes the frames one second apart and returns the error of each write.
+func write(t *testing.T, w *Writer, frames []frame) []error {
+	t.Helper()
+	var errs []error
+	for i, f := range frames {
+		data := delta
+		if f.key {
+			data = idr
+		}
+		errs = append(errs, w.Write(data, f.key, start.Add(time.Duration(i)*time.Second)))
+	}
+	return errs
+}
+
+// contents returns the names and contents of the files in the directory.
+func contents(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Only the frame type of each frame, without start codes
+		var types []byte
+		for i := 4; i < len(b); i += 5 {
+			types = append(types, b[i])
+		}
+		files[e.Name()] = string(types)
+	}
+	return files
+}
+
+func TestWriterRotation(t *testing.T) {
+	K, P := frame{key: true}, frame{}
+	tests := []struct {
+		name string
+		cfg  Config
+		in   []frame
+		want map[string]string
+	}{
+		{
+			name: "frames before the first key frame are skipped",
+			cfg:  Config{},
+			in:   []frame{P, P, K, P},
+			want: map[string]string{"000000.h265": "IP"},
+		},
+		{
+			name: "segment frames rotate on the next key frame",
+			cfg:  Config{SegmentFrames: 2},
+			in:   []frame{K, P, P, K, P, K},
+			want: map[string]string{"000000.h265": "IPP", "000001.h265": "IP", "000002.h265": "I"},
+		},
+		{
+			name: "segment duration rotates on the next key frame",
+			cfg:  Config{SegmentDuration: 2 * time.Second},
+			in:   []frame{K, K, P, P, K, P},
+			want: map[string]string{"000000.h265": "IIPP", "000001.h265": "IP"},
+		},
+		{
+			name: "old segments are removed",
+			cfg:  Config{SegmentFrames: 1, MaxSegments: 2},
+			in:   []frame{K, K, P, K, K},
+			want: map[string]string{"000002.h265": "I", "000003.h265": "I"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Dir = t.TempDir()
+			w, err := NewWriter(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, err := range write(t, w, tt.in) {
+				if err != nil {
+					t.Fatalf("frame %d: %s", i, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got := contents(t, tt.cfg.Dir)
+			if len(got) != len(tt.want) {
+				t.Errorf("got segments %v, want %v", got, tt.want)
+			}
+			for name, types := range tt.want {
+				if got[name] != types {
+					t.Errorf("segment %s: got frames %q, want %q", name, got[name], types)
+				}
+			}
+			if n := len(w.Segments()); n != len(tt.want) {
+				t.Errorf("writer keeps %d segments, want %d", n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestWriterYUV(t *testing.T) {
+	// Every YUV frame is a segment, SegmentFrames and the key frame flag are ignored
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir, Format: YUV, SegmentFrames: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := w.Write([]byte{byte(i)}, false, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	var names []string
+	for _, path := range w.Segments() {
+		names = append(names, filepath.Base(path))
+	}
+	if want := []string{"000000.yuv", "000001.yuv", "000002.yuv"}; !slices.Equal(names, want) {
+		t.Errorf("got segments %v, want %v", names, want)
+	}
+}
+
+func TestWriterLimits(t *testing.T) {
+	K, P := frame{key: true}, frame{}
+	tests := []struct {
+		name   string
+		cfg    Config
+		frames int // Recorded frames before ErrDone
+	}{
+		{name: "max frames", cfg: Config{MaxFrames: 3}, frames: 3},
+		{name: "max duration", cfg: Config{MaxDuration: 2 * time.Second}, frames: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Dir = t.TempDir()
+			w, err := NewWriter(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := write(t, w, []frame{K, P, P, P, K})
+			for i, err := range errs {
+				if wantDone := i >= tt.frames; errors.Is(err, ErrDone) != wantDone || (!wantDone && err != nil) {
+					t.Errorf("frame %d: got error %v, want done %t", i, err, wantDone)
+				}
+			}
+			if !w.Done() || w.Frames() != tt.frames {
+				t.Errorf("done %t after %d frames, want done after %d frames", w.Done(), w.Frames(), tt.frames)
+			}
+		})
+	}
+}
+
+func TestWriterNotAnnexB(t *testing.T) {
+	w, err := NewWriter(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]byte{'I'}, true, start); err == nil {
+		t.Error("no error for a key frame without start code")
+	}
+}
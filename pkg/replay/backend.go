@@ -0,0 +1,119 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoOutputs is returned by Recorded if no outputs were recorded for a frame.
+var ErrNoOutputs = errors.New("no recorded outputs")
+
+// Backend returns the raw output tensors of a model for a frame, a stand-in for the larod inference.
+// The outputs are in the order of the model outputs, like the memory mapped tensors passed to a post processor.
+type Backend interface {
+	Outputs(frame *Frame) ([][]byte, error)
+}
+
+// Recorded is a Backend which returns the output tensors recorded next to the frames,
+// e.g. 000001.out0 and 000001.out1 for the frame 000001.yuv.
+type Recorded struct {
+	Dir string
+}
+
+// Outputs reads the recorded output tensors of a frame.
+// Returns ErrNoOutputs if nothing was recorded for the frame.
+func (r Recorded) Outputs(frame *Frame) ([][]byte, error) {
+	var outputs [][]byte
+	for i := 0; ; i++ {
+		data, err := os.ReadFile(outputPath(r.Dir, frame.Name, i))
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, data)
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("frame %s: %w", frame.Name, ErrNoOutputs)
+	}
+	return outputs, nil
+}
+
+// outputPath returns the path of a recorded output tensor.
+func outputPath(dir, name string, output int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.out%d", name, output))
+}
+
+// Recorder writes frames and their output tensors in the layout read by Source and Recorded.
+// The frames are named by their sequence number, e.g. 000042.yuv with 000042.out0.
+type Recorder struct {
+	Dir   string
+	Limit int // Maximum number of recorded frames, 0 records all frames
+	count int
+}
+
+// NewRecorder creates a recorder, the directory is created if it does not exist.
+func NewRecorder(dir string, limit int) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder{Dir: dir, Limit: limit}, nil
+}
+
+// Record writes a frame and its output tensors, frames above the limit are skipped.
+func (r *Recorder) Record(frame *Frame, outputs [][]byte) error {
+	if r.Done() {
+		return nil
+	}
+	name := fmt.Sprintf("%06d", frame.SequenceNbr)
+	if err := os.WriteFile(filepath.Join(r.Dir, name+"."+frame.Format.String()), frame.Data, 0o644); err != nil {
+		return err
+	}
+	for i, output := range outputs {
+		if err := os.WriteFile(outputPath(r.Dir, name, i), output, 0o644); err != nil {
+			return err
+		}
+	}
+	r.count++
+	return nil
+}
+
+// Count returns the number of recorded frames.
+func (r *Recorder) Count() int {
+	return r.count
+}
+
+// Done returns true once the limit is reached.
+func (r *Recorder) Done() bool {
+	return r.Limit > 0 && r.count >= r.Limit
+}
+
+// Run replays all frames of the source and passes each frame with the outputs of the backend to handle.
+// This drives the post processing, tracking and analytics of an example like the capture loop, e.g. in a test.
+// A nil backend passes nil outputs.
+// Returns the first error of a frame, the backend or handle.
+func Run(src *Source, backend Backend, handle func(frame *Frame, outputs [][]byte) error) error {
+	src.Start()
+	defer src.Stop()
+
+	for frame := range src.Frames {
+		if frame.Error != nil {
+			return frame.Error
+		}
+		var outputs [][]byte
+		if backend != nil {
+			var err error
+			if outputs, err = backend.Outputs(frame); err != nil {
+				return err
+			}
+		}
+		if err := handle(frame, outputs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package replay feeds recorded frames and model outputs through the processing of the examples without a camera.
+//
+// A Source replays a directory of raw YUV (NV12) or RGB dumps and PNGs as a stand-in for the frame channel of
+// the frame provider, and the Recorded backend returns the output tensors recorded next to each frame instead of
+// running the larod inference. With a Recorder the frames and outputs are captured on the camera, so the
+// post processing, tracking and analytics can be replayed with `go test` on a plain Linux box.
+package replay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+)
+
+// Format is the pixel format of a frame.
+type Format int
+
+const (
+	YUV Format = iota // NV12, a full resolution Y plane followed by an interleaved half resolution UV plane
+	RGB               // Interleaved 8 bit RGB
+)
+
+// String returns a readable name of the format.
+func (f Format) String() string {
+	switch f {
+	case YUV:
+		return "yuv"
+	case RGB:
+		return "rgb"
+	}
+	return "unknown"
+}
+
+// Size returns the number of bytes of a frame with the given size.
+func (f Format) Size(width, height int) int {
+	if f == YUV {
+		return width*height + 2*((width+1)/2)*((height+1)/2)
+	}
+	return width * height * 3
+}
+
+// Frame is a replayed video frame.
+type Frame struct {
+	Name        string    // File name without extension, also the name of the recorded outputs
+	SequenceNbr uint      // Position in the replay, continues counting when the replay loops
+	Timestamp   time.Time // Time when the frame was replayed
+	Width       int
+	Height      int
+	Format      Format
+	Data        []byte
+	Error       error // Error if the frame could not be loaded, like the Error of a vdo frame
+}
+
+// ImageToRGB converts an image into interleaved RGB.
+func ImageToRGB(img image.Image) []byte {
+	b := img.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+		}
+	}
+	return rgb
+}
+
+// RGBToNV12 converts interleaved RGB into NV12 with the full range BT.601 coefficients of JPEG.
+// The chroma of each 2x2 block is taken from its top left pixel.
+func RGBToNV12(rgb []byte, width, height int) ([]byte, error) {
+	if len(rgb) != RGB.Size(width, height) {
+		return nil, fmt.Errorf("rgb frame has %d bytes, expected %d for %dx%d", len(rgb), RGB.Size(width, height), width, height)
+	}
+	nv12 := make([]byte, YUV.Size(width, height))
+	uv := nv12[width*height:]
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 3
+			yy, cb, cr := color.RGBToYCbCr(rgb[i], rgb[i+1], rgb[i+2])
+			nv12[y*width+x] = yy
+			if x%2 == 0 && y%2 == 0 {
+				j := (y/2)*2*((width+1)/2) + x
+				uv[j], uv[j+1] = cb, cr
+			}
+		}
+	}
+	return nv12, nil
+}
+
+// NV12ToRGB converts NV12 into interleaved RGB with the full range BT.601 coefficients of JPEG.
+func NV12ToRGB(nv12 []byte, width, height int) ([]byte, error) {
+	if len(nv12) != YUV.Size(width, height) {
+		return nil, fmt.Errorf("yuv frame has %d bytes, expected %d for %dx%d", len(nv12), YUV.Size(width, height), width, height)
+	}
+	rgb := make([]byte, RGB.Size(width, height))
+	uv := nv12[width*height:]
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			j := (y/2)*2*((width+1)/2) + x/2*2
+			r, g, b := color.YCbCrToRGB(nv12[y*width+x], uv[j], uv[j+1])
+			i := (y*width + x) * 3
+			rgb[i], rgb[i+1], rgb[i+2] = r, g, b
+		}
+	}
+	return rgb, nil
+}
+
+// convert converts frame data between the formats.
+func convert(data []byte, from, to Format, width, height int) ([]byte, error) {
+	switch {
+	case from == to:
+		if len(data) != from.Size(width, height) {
+			return nil, fmt.Errorf("%s frame has %d bytes, expected %d for %dx%d", from, len(data), from.Size(width, height), width, height)
+		}
+		return data, nil
+	case from == RGB:
+		return RGBToNV12(data, width, height)
+	default:
+		return NV12ToRGB(data, width, height)
+	}
+}
@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// blocks returns an RGB frame where every 2x2 block has its own color, so the chroma subsampling keeps it.
+func blocks(width, height int) []byte {
+	rgb := make([]byte, RGB.Size(width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 3
+			bx, by := byte(x/2), byte(y/2)
+			rgb[i], rgb[i+1], rgb[i+2] = 40+60*bx, 200-50*by, 90+30*bx+20*by
+		}
+	}
+	return rgb
+}
+
+// near compares frames with a tolerance for the rounding of the YCbCr conversion.
+func near(a, b []byte, tolerance int) int {
+	for i := range a {
+		if d := int(a[i]) - int(b[i]); d > tolerance || d < -tolerance {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestNV12RoundTrip(t *testing.T) {
+	// Odd sizes have a chroma sample for the last column and row which covers only half a block
+	for _, size := range [][2]int{{4, 4}, {6, 2}, {3, 3}, {5, 3}, {1, 1}} {
+		width, height := size[0], size[1]
+		rgb := blocks(width, height)
+		nv12, err := RGBToNV12(rgb, width, height)
+		if err != nil {
+			t.Fatalf("%dx%d: %s", width, height, err)
+		}
+		if len(nv12) != YUV.Size(width, height) {
+			t.Fatalf("%dx%d: got %d bytes, want %d", width, height, len(nv12), YUV.Size(width, height))
+		}
+		back, err := NV12ToRGB(nv12, width, height)
+		if err != nil {
+			t.Fatalf("%dx%d: %s", width, height, err)
+		}
+		if i := near(back, rgb, 2); i >= 0 {
+			t.Errorf("%dx%d: pixel %d got %v, want %v", width, height, i/3, back[i/3*3:i/3*3+3], rgb[i/3*3:i/3*3+3])
+		}
+	}
+}
+
+func TestRGBToNV12Chroma(t *testing.T) {
+	// 3x3 with a different color in each pixel of the first block, the chroma is taken from its top left pixel
+	rgb := blocks(3, 3)
+	copy(rgb[3:6], []byte{255, 0, 0})
+	copy(rgb[9:12], []byte{0, 0, 255})
+	nv12, err := RGBToNV12(rgb, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uv := nv12[9:]
+	if len(uv) != 8 {
+		t.Fatalf("got %d chroma bytes, want 2x2 samples", len(uv))
+	}
+	for block, pixel := range []int{0, 2, 6, 8} {
+		_, cb, cr := color.RGBToYCbCr(rgb[pixel*3], rgb[pixel*3+1], rgb[pixel*3+2])
+		if uv[2*block] != cb || uv[2*block+1] != cr {
+			t.Errorf("block %d: got cb %d cr %d, want %d %d of pixel %d", block, uv[2*block], uv[2*block+1], cb, cr, pixel)
+		}
+	}
+	// The luma is kept per pixel
+	if y, _, _ := color.RGBToYCbCr(255, 0, 0); nv12[1] != y {
+		t.Errorf("got luma %d of the red pixel, want %d", nv12[1], y)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rgb := blocks(3, 3)
+	same, err := convert(rgb, RGB, RGB, 3, 3)
+	if err != nil || &same[0] != &rgb[0] {
+		t.Errorf("frame in the same format is not passed through: %v", err)
+	}
+	nv12, err := convert(rgb, RGB, YUV, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := convert(nv12, YUV, RGB, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := near(back, rgb, 2); i >= 0 {
+		t.Errorf("byte %d: got %d, want %d", i, back[i], rgb[i])
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		from, to Format
+		wantErr  string
+	}{
+		{name: "rgb", data: rgb[:10], from: RGB, to: RGB, wantErr: "rgb frame has 10 bytes, expected 27 for 3x3"},
+		{name: "rgb to yuv", data: rgb[:10], from: RGB, to: YUV, wantErr: "rgb frame has 10 bytes"},
+		{name: "yuv to rgb", data: nv12[:16], from: YUV, to: RGB, wantErr: "yuv frame has 16 bytes, expected 17 for 3x3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := convert(tt.data, tt.from, tt.to, 3, 3); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Cacsjep/goxis_examples/pkg/analytics"
+	"github.com/Cacsjep/goxis_examples/pkg/postprocess"
+	"github.com/Cacsjep/goxis_examples/pkg/tflite"
+)
+
+// TestClassifyReplay records NV12 frames with the uint8 scores of a person and car classifier, and replays them
+// through the classifier post processing and a class rule like the classify example.
+func TestClassifyReplay(t *testing.T) {
+	const width, height = 6, 4
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	person := []byte{0, 0, 230, 230, 230, 230, 20, 230}
+	for i, score := range person {
+		nv12, err := RGBToNV12(blocks(width, height), width, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame := &Frame{SequenceNbr: uint(i), Width: width, Height: height, Format: YUV, Data: nv12}
+		if err := rec.Record(frame, [][]byte{{score, 25}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post, err := postprocess.New(postprocess.CLASSIFIER_POSTPROCESS, postprocess.Config{
+		Outputs:   []tflite.Tensor{{Name: "scores", Type: tflite.Uint8, Shape: []int{1, 2}, Quantization: tflite.Quantization{Scale: []float32{1.0 / 255}}}},
+		Threshold: 0.5,
+		TopK:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	classifier := post.(*postprocess.Classifier)
+	rule, err := analytics.ParseClassRule("person > 80% for 2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The frames are converted to RGB like the output of the preprocessing model
+	src, err := NewSource(dir, Config{Width: width, Height: height, Format: RGB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var top, changes []int
+	if err := Run(src, Recorded{Dir: dir}, func(f *Frame, outputs [][]byte) error {
+		if len(f.Data) != RGB.Size(width, height) {
+			t.Fatalf("frame %s has %d bytes", f.Name, len(f.Data))
+		}
+		result, err := classifier.Process(outputs)
+		if err != nil {
+			return err
+		}
+		class := -1
+		if len(result.Classifications) > 0 {
+			class = result.Classifications[0].Class
+		}
+		top = append(top, class)
+		if rule.Update(classifier.Scores()[0], start.Add(time.Duration(f.SequenceNbr)*time.Second)) {
+			changes = append(changes, int(f.SequenceNbr))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{-1, -1, 0, 0, 0, 0, -1, 0}; !slices.Equal(top, want) {
+		t.Errorf("got top classes %v, want %v", top, want)
+	}
+	// Active 2s after the score rose in frame 2, inactive on the drop in frame 6, frame 7 is too short
+	if want := []int{4, 6}; !slices.Equal(changes, want) || rule.Active() {
+		t.Errorf("got rule changes in frames %v, active %t, want %v and inactive", changes, rule.Active(), want)
+	}
+}
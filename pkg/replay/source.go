@@ -0,0 +1,188 @@
+package replay
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SOURCE_BUFFER is the number of loaded frames waiting in the Frames channel of a Source.
+var SOURCE_BUFFER = 4
+
+// Config configures a Source.
+// Fields:
+//   - Width, Height: Size of the frames, raw dumps must have this size and PNGs must match it.
+//   - Format: Format of the replayed frames, dumps in the other format and PNGs are converted.
+//   - FPS: Replayed frames per second, 0 replays as fast as the frames are consumed.
+//   - Loop: Start again with the first frame after the last one.
+type Config struct {
+	Width  int
+	Height int
+	Format Format
+	FPS    int
+	Loop   bool
+}
+
+// Source replays the frames of a directory in file name order, e.g. 000001.yuv, 000002.png, ...
+// Files with the extensions .yuv (NV12), .rgb (interleaved RGB) and .png are frames, other files are ignored.
+type Source struct {
+	Frames   chan *Frame // Frames delivers the replayed frames, it is closed after the last frame or Stop
+	dir      string
+	files    []string
+	cfg      Config
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSource creates a source for the frames of a directory.
+// Returns an error if the directory can not be read or has no frames.
+func NewSource(dir string, cfg Config) (*Source, error) {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("invalid replay size %dx%d", cfg.Width, cfg.Height)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Source{
+		Frames: make(chan *Frame, SOURCE_BUFFER),
+		dir:    dir,
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	// ReadDir returns the entries sorted by file name
+	for _, e := range entries {
+		if _, ok := frameFormat(e.Name()); ok && !e.IsDir() {
+			s.files = append(s.files, e.Name())
+		}
+	}
+	if len(s.files) == 0 {
+		return nil, fmt.Errorf("no .yuv, .rgb or .png frames in %s", dir)
+	}
+	return s, nil
+}
+
+// Len returns the number of frames of the directory.
+func (s *Source) Len() int {
+	return len(s.files)
+}
+
+// Start starts replaying the frames into the Frames channel.
+func (s *Source) Start() {
+	go s.run()
+}
+
+// Stop stops the replay and waits until the Frames channel is closed, it can be called more than once.
+// Frames which are still in the channel can be read after Stop.
+func (s *Source) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *Source) run() {
+	defer close(s.done)
+	defer close(s.Frames)
+
+	var tick <-chan time.Time
+	if s.cfg.FPS > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(s.cfg.FPS))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var seq uint
+	for {
+		for _, name := range s.files {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-s.stop:
+					return
+				}
+			}
+			frame, err := Load(filepath.Join(s.dir, name), s.cfg)
+			if err != nil {
+				frame = &Frame{Name: strings.TrimSuffix(name, filepath.Ext(name)), Error: err}
+			}
+			frame.SequenceNbr = seq
+			frame.Timestamp = time.Now()
+			seq++
+
+			select {
+			case s.Frames <- frame:
+			case <-s.stop:
+				return
+			}
+		}
+		if !s.cfg.Loop {
+			return
+		}
+	}
+}
+
+// frameFormat returns the format of a frame file by its extension.
+func frameFormat(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yuv":
+		return YUV, true
+	case ".rgb", ".png":
+		return RGB, true
+	}
+	return 0, false
+}
+
+// Load reads a single frame file and converts it into the format of the config.
+// Returns an error if the file is not a frame or does not have the configured size.
+func Load(path string, cfg Config) (*Frame, error) {
+	format, ok := frameFormat(path)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a .yuv, .rgb or .png frame", path)
+	}
+
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		data, err = loadPNG(path, cfg.Width, cfg.Height)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err = convert(data, format, cfg.Format, cfg.Width, cfg.Height); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	return &Frame{
+		Name:   strings.TrimSuffix(name, filepath.Ext(name)),
+		Width:  cfg.Width,
+		Height: cfg.Height,
+		Format: cfg.Format,
+		Data:   data,
+	}, nil
+}
+
+// loadPNG decodes a PNG into interleaved RGB, the image must have the given size.
+func loadPNG(path string, width, height int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		return nil, fmt.Errorf("%s: image is %dx%d, expected %dx%d", path, b.Dx(), b.Dy(), width, height)
+	}
+	return ImageToRGB(img), nil
+}
@@ -0,0 +1,164 @@
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Text is a text with its top left corner at Pos.
+// A clamped text is moved into the canvas with its background box, e.g. the label of a box at the edge of the stream.
+type Text struct {
+	Pos   Point
+	Text  string
+	Clamp bool
+	TextStyle
+}
+
+// Draw draws the text.
+func (t Text) Draw(c Canvas, v Viewport) error {
+	if t.Text == "" {
+		return nil
+	}
+	style := t.TextStyle.Scaled(v)
+	x, y := v.Pixel(t.Pos)
+	if t.Clamp {
+		x, y = v.Clamp(x, y, c.TextWidth(t.Text, style)+style.Padding*2, style.Size+style.Padding*2)
+	}
+	c.Text(t.Text, x, y, style)
+	return nil
+}
+
+// Rect is a rectangle with its top left corner at Pos and a normalized Size.
+type Rect struct {
+	Pos  Point
+	Size Point
+	Style
+}
+
+// Draw draws the rectangle.
+func (r Rect) Draw(c Canvas, v Viewport) error {
+	x, y := v.Pixel(r.Pos)
+	c.Rect(x, y, r.Size.X*float64(v.Width), r.Size.Y*float64(v.Height), r.Style.Scaled(v))
+	return nil
+}
+
+// Dot is a filled square centered at Pos with a pixel size, e.g. a point of a pixel array.
+// Size is in pixels of the reference resolution like the style sizes, so a dot keeps its size in pixels
+// where a normalized Rect would shrink below a pixel on a small stream. A dot is at least one pixel and
+// snapped to the pixel grid, so it stays sharp.
+type Dot struct {
+	Pos   Point
+	Size  float64
+	Color color.RGBA
+}
+
+// Draw draws the dot.
+func (d Dot) Draw(c Canvas, v Viewport) error {
+	size := math.Max(1, math.Round(v.Size(d.Size)))
+	x, y := v.Pixel(d.Pos)
+	c.Rect(math.Round(x-size/2), math.Round(y-size/2), size, size, Style{Fill: d.Color})
+	return nil
+}
+
+// Polygon is a closed shape, the last point is connected with the first one.
+type Polygon struct {
+	Points []Point
+	Style
+}
+
+// Draw draws the polygon, polygons with less than 3 points are skipped.
+func (p Polygon) Draw(c Canvas, v Viewport) error {
+	if len(p.Points) < 3 {
+		return nil
+	}
+	c.Path(v.Pixels(p.Points), true, p.Style.Scaled(v))
+	return nil
+}
+
+// Polyline is an open line through its points, the fill of its style is not used.
+type Polyline struct {
+	Points []Point
+	Style
+}
+
+// Draw draws the line, lines with less than 2 points are skipped.
+func (p Polyline) Draw(c Canvas, v Viewport) error {
+	if len(p.Points) < 2 {
+		return nil
+	}
+	c.Path(v.Pixels(p.Points), false, p.Style.Scaled(v))
+	return nil
+}
+
+// Image is an image file, e.g. a PNG, drawn in its own pixel size multiplied by Scale and the scale of the viewport.
+// Fields:
+//   - Pos: Normalized position of the image.
+//   - Align: Point of the image placed at Pos relative to its size, e.g. 0.5, 1 for the bottom center.
+//   - Path: Path of the image file.
+//   - Scale: Scale of the image, 0 draws it in its own size.
+type Image struct {
+	Pos   Point
+	Align Point
+	Path  string
+	Scale float64
+}
+
+// Draw draws the image.
+func (i Image) Draw(c Canvas, v Viewport) error {
+	if i.Path == "" {
+		return nil
+	}
+	scale := i.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	x, y := v.Pixel(i.Pos)
+	if err := c.Image(i.Path, x, y, i.Align, v.Size(scale)); err != nil {
+		return fmt.Errorf("image %s: %w", i.Path, err)
+	}
+	return nil
+}
+
+// Group is a list of nodes drawn in order and moved by a normalized Offset, a hidden group is not drawn.
+type Group struct {
+	Offset Point
+	Hidden bool
+	Nodes  []Node
+}
+
+// Draw draws the nodes of the group.
+func (g Group) Draw(c Canvas, v Viewport) error {
+	if g.Hidden {
+		return nil
+	}
+	v.Offset.X += g.Offset.X
+	v.Offset.Y += g.Offset.Y
+	var errs []error
+	for _, node := range g.Nodes {
+		if err := node.Draw(c, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Box returns a bounding box like the DrawBoundingBox of axoverlay, a dashed rectangle with a translucent fill
+// and the label on the box color at its top left corner. The label is clamped, so it stays readable
+// when the box touches or crosses the edge of the stream.
+// Args:
+//   - pos: Normalized top left corner of the box.
+//   - size: Normalized size of the box.
+//   - boxColor: Color of the outline and the label background.
+//   - label: Text of the label, empty draws no label.
+//   - labelStyle: Style of the label, the background is always the box color.
+func Box(pos, size Point, boxColor color.RGBA, label string, labelStyle TextStyle) Group {
+	fill := boxColor
+	fill.A = uint8(float64(boxColor.A) * 0.3)
+	labelStyle.Background = boxColor
+	return Group{Nodes: []Node{
+		Rect{Pos: pos, Size: size, Style: Style{Stroke: boxColor, Fill: fill, LineWidth: 3, Dash: []float64{4, 4}}},
+		Text{Pos: pos, Text: label, Clamp: true, TextStyle: labelStyle},
+	}}
+}
@@ -0,0 +1,283 @@
+// Package scene is a retained mode overlay scene graph in normalized coordinates.
+//
+// An application builds its overlay from text, rect, dot, polygon, polyline, image and group nodes and puts them
+// into a Scene under a key, from any goroutine. The scene is drawn on a Canvas for each stream, the
+// normalized coordinates are scaled to the stream resolution, so the same scene fits every stream.
+// With a Reference resolution the pixel sizes of the styles, like font sizes, line widths and paddings,
+// are scaled as well, so a label covers the same part of a 4K stream and of a 1280x720 stream. Font sizes and
+// line widths are not scaled below MIN_FONT_SIZE and MIN_LINE_WIDTH, so labels stay readable on a 480x270 stream.
+// A Scene tracks its changes, so a redraw is only needed when a node was actually replaced by a different one,
+// and hands its nodes to the render callback as immutable snapshot, see the snapshot package.
+package scene
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"reflect"
+
+	"github.com/Cacsjep/goxis_examples/pkg/snapshot"
+)
+
+var (
+	DEFAULT_REFERENCE = Point{X: 1920, Y: 1080} // Reference resolution of the style sizes of the examples, see Scene.Reference
+	MIN_FONT_SIZE     = 9.0                     // Smallest font size in pixels a scaled text style gets, so labels stay readable on small streams
+	MIN_LINE_WIDTH    = 1.0                     // Smallest line width in pixels a scaled style gets, so lines do not fade out on small streams
+)
+
+// Point is a position, normalized from 0 to 1 in nodes and in pixels when passed to a Canvas.
+type Point struct {
+	X, Y float64
+}
+
+// Style is the stroke and fill of a shape, a color with zero alpha is not drawn.
+// Sizes are in pixels of the reference resolution of the scene, see Scene.Reference.
+// Fields:
+//   - Stroke: Color of the outline.
+//   - Fill: Color of the area, only used by closed shapes.
+//   - LineWidth: Width of the outline in pixels, 0 draws no outline.
+//   - Dash: Dash pattern of the outline in pixels, empty draws a solid line.
+type Style struct {
+	Stroke    color.RGBA
+	Fill      color.RGBA
+	LineWidth float64
+	Dash      []float64
+}
+
+// TextStyle is the look of a text.
+// Sizes are in pixels of the reference resolution of the scene, see Scene.Reference.
+// Fields:
+//   - Size: Font size in pixels.
+//   - Font: Font family, e.g. sans or serif.
+//   - Color: Color of the text.
+//   - Background: Color of a box behind the text, zero alpha draws no box.
+//   - Padding: Space between the text and the border of the background box in pixels.
+type TextStyle struct {
+	Size       float64
+	Font       string
+	Color      color.RGBA
+	Background color.RGBA
+	Padding    float64
+}
+
+// Scaled returns the style with the line width and dash pattern in pixels of a viewport.
+func (s Style) Scaled(v Viewport) Style {
+	s.LineWidth = v.LineWidth(s.LineWidth)
+	if len(s.Dash) > 0 {
+		// Nodes are immutable, so the dash pattern is copied
+		dash := make([]float64, len(s.Dash))
+		for i, d := range s.Dash {
+			dash[i] = v.Size(d)
+		}
+		s.Dash = dash
+	}
+	return s
+}
+
+// Scaled returns the style with the font size and padding in pixels of a viewport.
+// The padding is scaled like the font size, so a label keeps its proportions when the font size is at its minimum.
+func (s TextStyle) Scaled(v Viewport) TextStyle {
+	size := v.FontSize(s.Size)
+	if s.Size > 0 {
+		s.Padding *= size / s.Size
+	}
+	s.Size = size
+	return s
+}
+
+// Canvas draws the nodes of a scene in pixel coordinates, e.g. on the cairo context of an overlay.
+type Canvas interface {
+	// Clear makes the whole canvas transparent.
+	Clear(width, height int)
+	// Text draws a text with its top left corner at x, y.
+	Text(text string, x, y float64, style TextStyle)
+	// TextWidth returns the width of a text in pixels without the padding.
+	TextWidth(text string, style TextStyle) float64
+	// Rect draws a rectangle with its top left corner at x, y.
+	Rect(x, y, width, height float64, style Style)
+	// Path draws connected line segments, closed connects the last point with the first one and fills the area.
+	Path(points []Point, closed bool, style Style)
+	// Image draws an image file, align is the point of the image placed at x, y relative to its size,
+	// e.g. 0.5, 1 for the bottom center. The image is scaled by scale.
+	Image(path string, x, y float64, align Point, scale float64) error
+}
+
+// Viewport maps the normalized coordinates of the nodes to the pixels of a canvas.
+type Viewport struct {
+	Width, Height int
+	Offset        Point   // Normalized offset added by the parent groups
+	Scale         float64 // Scale of the pixel sizes of the styles, 0 draws them unscaled
+}
+
+// Size returns a size of a style in pixels of the canvas.
+func (v Viewport) Size(size float64) float64 {
+	if v.Scale == 0 {
+		return size
+	}
+	return size * v.Scale
+}
+
+// FontSize returns a font size in pixels of the canvas, it is not scaled below MIN_FONT_SIZE.
+func (v Viewport) FontSize(size float64) float64 {
+	return atLeast(v.Size(size), size, MIN_FONT_SIZE)
+}
+
+// LineWidth returns a line width in pixels of the canvas, it is not scaled below MIN_LINE_WIDTH.
+func (v Viewport) LineWidth(width float64) float64 {
+	return atLeast(v.Size(width), width, MIN_LINE_WIDTH)
+}
+
+// atLeast returns a scaled size, but at least the minimum, a size which is already smaller than the minimum
+// is kept, so 0 stays 0.
+func atLeast(scaled, size, minimum float64) float64 {
+	return math.Max(scaled, math.Min(size, minimum))
+}
+
+// Clamp moves a box of a pixel size at x, y into the canvas, a box larger than the canvas starts at 0.
+func (v Viewport) Clamp(x, y, width, height float64) (float64, float64) {
+	return math.Max(0, math.Min(x, float64(v.Width)-width)), math.Max(0, math.Min(y, float64(v.Height)-height))
+}
+
+// Pixel returns the pixel position of a normalized point.
+func (v Viewport) Pixel(p Point) (float64, float64) {
+	return (p.X + v.Offset.X) * float64(v.Width), (p.Y + v.Offset.Y) * float64(v.Height)
+}
+
+// Pixels returns the pixel positions of normalized points.
+func (v Viewport) Pixels(points []Point) []Point {
+	pixels := make([]Point, len(points))
+	for i, p := range points {
+		pixels[i].X, pixels[i].Y = v.Pixel(p)
+	}
+	return pixels
+}
+
+// Node is an element of a scene.
+// Nodes are values, a node passed to a Scene must not be modified afterwards, it is replaced by a new node instead.
+type Node interface {
+	Draw(c Canvas, v Viewport) error
+}
+
+// layer is a top level node of a scene with its key.
+type layer struct {
+	key  string
+	node Node
+}
+
+// Scene holds the top level nodes of an overlay by key, drawn in the order the keys were first set.
+// Every change publishes a new immutable list of the nodes, so Render draws a consistent state without
+// waiting for writers, and writers never wait for a render callback.
+// All methods are safe for concurrent use, Reference is set before the scene is rendered.
+type Scene struct {
+	Reference Point // Reference is the resolution the style sizes are meant for, e.g. 1920, 1080, zero draws them unscaled
+	layers    *snapshot.Value[[]layer]
+}
+
+// New creates an empty scene.
+func New() *Scene {
+	return &Scene{layers: snapshot.New[[]layer](nil)}
+}
+
+// Set puts a node into the scene, a node with the same key is replaced in place.
+// Returns true if the scene changed, setting an equal node does not mark the scene as changed.
+func (s *Scene) Set(key string, node Node) bool {
+	changed := false
+	s.layers.Update(func(layers []layer) ([]layer, bool) {
+		i := index(layers, key)
+		if i >= 0 && reflect.DeepEqual(layers[i].node, node) {
+			return layers, false
+		}
+		// Copy on write, a render callback may still draw the current layers
+		next := make([]layer, len(layers), len(layers)+1)
+		copy(next, layers)
+		if i >= 0 {
+			next[i].node = node
+		} else {
+			next = append(next, layer{key: key, node: node})
+		}
+		changed = true
+		return next, true
+	})
+	return changed
+}
+
+// Get returns the node of a key.
+func (s *Scene) Get(key string) (Node, bool) {
+	layers := s.layers.Load().Value
+	if i := index(layers, key); i >= 0 {
+		return layers[i].node, true
+	}
+	return nil, false
+}
+
+// Remove removes the node of a key.
+// Returns true if the scene changed.
+func (s *Scene) Remove(key string) bool {
+	changed := false
+	s.layers.Update(func(layers []layer) ([]layer, bool) {
+		i := index(layers, key)
+		if i < 0 {
+			return layers, false
+		}
+		next := make([]layer, 0, len(layers)-1)
+		next = append(next, layers[:i]...)
+		next = append(next, layers[i+1:]...)
+		changed = true
+		return next, true
+	})
+	return changed
+}
+
+// Clear removes all nodes.
+func (s *Scene) Clear() {
+	s.layers.Update(func(layers []layer) ([]layer, bool) {
+		return nil, len(layers) > 0
+	})
+}
+
+// index returns the index of the layer of a key, -1 if there is none.
+func index(layers []layer, key string) int {
+	for i, l := range layers {
+		if l.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Version returns a counter which is increased on every change of the scene.
+func (s *Scene) Version() uint64 {
+	return s.layers.Load().Version
+}
+
+// Changed receives a value after the scene changed, changes until the value is received are coalesced.
+// Use it to call Redraw of the overlay provider only when something changed.
+func (s *Scene) Changed() <-chan struct{} {
+	return s.layers.Changed()
+}
+
+// Render clears the canvas and draws the latest state of the scene scaled to the given size.
+// A node which fails to draw, e.g. a missing image, does not stop the other nodes.
+// Returns the errors of the nodes joined.
+func (s *Scene) Render(c Canvas, width, height int) error {
+	c.Clear(width, height)
+	v := Viewport{Width: width, Height: height, Scale: s.scale(width, height)}
+	var errs []error
+	for _, l := range s.layers.Load().Value {
+		if err := l.node.Draw(c, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scale returns the scale of the style sizes for a canvas size, the ratio of the short side to the short side
+// of the reference resolution, so a stream rotated to portrait uses the same sizes as in landscape.
+// Returns 0 without reference resolution.
+func (s *Scene) scale(width, height int) float64 {
+	reference := math.Min(s.Reference.X, s.Reference.Y)
+	if reference <= 0 {
+		return 0
+	}
+	return float64(min(width, height)) / reference
+}
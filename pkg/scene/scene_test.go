@@ -0,0 +1,203 @@
+package scene
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// rect is a rectangle drawn on a recordCanvas with its fill.
+type rect struct {
+	x, y, width, height float64
+	fill                color.RGBA
+}
+
+// recordCanvas records the rectangles drawn on it.
+type recordCanvas struct {
+	rects []rect
+}
+
+func (c *recordCanvas) Clear(width, height int)                              { c.rects = nil }
+func (c *recordCanvas) Text(text string, x, y float64, style TextStyle)      {}
+func (c *recordCanvas) TextWidth(text string, style TextStyle) float64       { return 0 }
+func (c *recordCanvas) Path(points []Point, closed bool, style Style)        {}
+func (c *recordCanvas) Image(string, float64, float64, Point, float64) error { return nil }
+func (c *recordCanvas) Rect(x, y, width, height float64, style Style) {
+	c.rects = append(c.rects, rect{x, y, width, height, style.Fill})
+}
+
+func TestDot(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		name          string
+		reference     Point
+		width, height int
+		want          rect
+	}{
+		{name: "reference resolution", reference: Point{X: 1920, Y: 1080}, width: 1920, height: 1080, want: rect{959, 539, 3, 3, red}},
+		{name: "scaled up", reference: Point{X: 1920, Y: 1080}, width: 3840, height: 2160, want: rect{1917, 1077, 6, 6, red}},
+		{name: "at least one pixel", reference: Point{X: 1920, Y: 1080}, width: 480, height: 270, want: rect{240, 135, 1, 1, red}},
+		{name: "without reference", width: 480, height: 270, want: rect{239, 134, 3, 3, red}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.Reference = tt.reference
+			s.Set("dot", Dot{Pos: Point{X: 0.5, Y: 0.5}, Size: 3, Color: red})
+			c := &recordCanvas{}
+			if err := s.Render(c, tt.width, tt.height); err != nil {
+				t.Fatal(err)
+			}
+			if len(c.rects) != 1 || c.rects[0] != tt.want {
+				t.Errorf("got %+v, want %+v", c.rects, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentWritersAndRender(t *testing.T) {
+	const (
+		writers = 8
+		updates = 300
+		readers = 4
+	)
+	s := New()
+	s.Reference = Point{X: 1920, Y: 1080}
+
+	var (
+		wg      sync.WaitGroup
+		done    atomic.Bool
+		changes atomic.Uint64
+	)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &recordCanvas{}
+			var last uint64
+			for !done.Load() {
+				version := s.Version()
+				if version < last {
+					t.Errorf("version went back from %d to %d", last, version)
+					return
+				}
+				last = version
+				if err := s.Render(c, 1920, 1080); err != nil {
+					t.Error(err)
+					return
+				}
+				// Each writer has at most one node in the scene
+				if len(c.rects) > writers {
+					t.Errorf("rendered %d rects of %d writers", len(c.rects), writers)
+					return
+				}
+			}
+		}()
+	}
+
+	var writersWg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersWg.Add(1)
+		go func() {
+			defer writersWg.Done()
+			key := fmt.Sprintf("writer-%d", w)
+			for i := 0; i < updates; i++ {
+				if i%10 == 5 && s.Remove(key) {
+					changes.Add(1)
+				}
+				// Every node is different from the previous one, so every set changes the scene
+				if !s.Set(key, Rect{Pos: Point{X: float64(i) / updates}, Size: Point{X: 0.1, Y: 0.1}}) {
+					t.Errorf("%s: set %d did not change the scene", key, i)
+				}
+				changes.Add(1)
+				// Setting the same node again is no change
+				if s.Set(key, Rect{Pos: Point{X: float64(i) / updates}, Size: Point{X: 0.1, Y: 0.1}}) {
+					t.Errorf("%s: equal set %d changed the scene", key, i)
+				}
+			}
+		}()
+	}
+	writersWg.Wait()
+	done.Store(true)
+	wg.Wait()
+
+	if s.Version() != changes.Load() {
+		t.Errorf("got version %d after %d changes", s.Version(), changes.Load())
+	}
+	// No update is lost, every writer's last node is in the scene
+	for w := 0; w < writers; w++ {
+		key := fmt.Sprintf("writer-%d", w)
+		node, ok := s.Get(key)
+		if rect, isRect := node.(Rect); !ok || !isRect || rect.Pos.X != float64(updates-1)/updates {
+			t.Errorf("%s: got %+v, want the last update", key, node)
+		}
+	}
+	c := &recordCanvas{}
+	if err := s.Render(c, 1920, 1080); err != nil || len(c.rects) != writers {
+		t.Errorf("rendered %d rects, %v, want %d", len(c.rects), err, writers)
+	}
+}
+
+func TestScaledMinimum(t *testing.T) {
+	tests := []struct {
+		name      string
+		scale     float64
+		style     Style
+		text      TextStyle
+		wantStyle Style
+		wantText  TextStyle
+	}{
+		{
+			name:      "scaled up",
+			scale:     2,
+			style:     Style{LineWidth: 3, Dash: []float64{4, 4}},
+			text:      TextStyle{Size: 12, Padding: 3},
+			wantStyle: Style{LineWidth: 6, Dash: []float64{8, 8}},
+			wantText:  TextStyle{Size: 24, Padding: 6},
+		},
+		{
+			// 1920x1080 sizes on a 480x270 stream, the padding keeps the proportion of the font size
+			name:      "scaled down to the minimum",
+			scale:     0.25,
+			style:     Style{LineWidth: 3, Dash: []float64{4, 4}},
+			text:      TextStyle{Size: 24, Padding: 6},
+			wantStyle: Style{LineWidth: 1, Dash: []float64{1, 1}},
+			wantText:  TextStyle{Size: 9, Padding: 2.25},
+		},
+		{
+			name:      "sizes below the minimum are kept",
+			scale:     0.25,
+			style:     Style{LineWidth: 0.5},
+			text:      TextStyle{Size: 6, Padding: 2},
+			wantStyle: Style{LineWidth: 0.5},
+			wantText:  TextStyle{Size: 6, Padding: 2},
+		},
+		{
+			name:      "zero is not drawn",
+			scale:     0.25,
+			wantStyle: Style{},
+			wantText:  TextStyle{},
+		},
+		{
+			name:      "unscaled",
+			style:     Style{LineWidth: 3},
+			text:      TextStyle{Size: 6, Padding: 2},
+			wantStyle: Style{LineWidth: 3},
+			wantText:  TextStyle{Size: 6, Padding: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Viewport{Width: 480, Height: 270, Scale: tt.scale}
+			if got := tt.style.Scaled(v); got.LineWidth != tt.wantStyle.LineWidth || !slices.Equal(got.Dash, tt.wantStyle.Dash) {
+				t.Errorf("got style %+v, want %+v", got, tt.wantStyle)
+			}
+			if got := tt.text.Scaled(v); got != tt.wantText {
+				t.Errorf("got text style %+v, want %+v", got, tt.wantText)
+			}
+		})
+	}
+}
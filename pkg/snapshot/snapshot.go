@@ -0,0 +1,74 @@
+// Package snapshot hands immutable values from writers to concurrent readers with an atomic pointer swap.
+//
+// A writer, e.g. the inference goroutine, publishes a new value for every frame instead of modifying the
+// current one, and a reader, e.g. the overlay render callback on the glib main loop, loads the latest value
+// without locking. A loaded value stays valid and unchanged while the writer already publishes the next one,
+// so published values, including the slices and maps they reference, must never be modified afterwards.
+package snapshot
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Frame is a published value.
+// Fields:
+//   - Version: Increases by one with each publish, the initial value has version 0.
+//   - Time: Time of the publish.
+//   - Value: The published value.
+type Frame[T any] struct {
+	Version uint64
+	Time    time.Time
+	Value   T
+}
+
+// Value holds the latest published frame of a value, it is safe for concurrent use.
+type Value[T any] struct {
+	current atomic.Pointer[Frame[T]]
+	mu      sync.Mutex // mu serializes the writers, so the versions are published in order
+	changed chan struct{}
+}
+
+// New creates a value with an initial frame.
+func New[T any](initial T) *Value[T] {
+	v := &Value[T]{changed: make(chan struct{}, 1)}
+	v.current.Store(&Frame[T]{Time: time.Now(), Value: initial})
+	return v
+}
+
+// Load returns the latest frame, it never blocks.
+func (v *Value[T]) Load() *Frame[T] {
+	return v.current.Load()
+}
+
+// Publish replaces the current frame with a new value.
+// Returns the published frame.
+func (v *Value[T]) Publish(value T) *Frame[T] {
+	return v.Update(func(T) (T, bool) { return value, true })
+}
+
+// Update publishes a value derived from the current value, writers are serialized so no update is lost.
+// The current value must not be modified, update returns a modified copy and false if nothing changed.
+// Returns the current frame, which is the new one if update changed the value.
+func (v *Value[T]) Update(update func(current T) (T, bool)) *Frame[T] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	current := v.current.Load()
+	value, changed := update(current.Value)
+	if !changed {
+		return current
+	}
+	frame := &Frame[T]{Version: current.Version + 1, Time: time.Now(), Value: value}
+	v.current.Store(frame)
+	select {
+	case v.changed <- struct{}{}:
+	default:
+	}
+	return frame
+}
+
+// Changed receives a value after a publish, publishes until the value is received are coalesced.
+func (v *Value[T]) Changed() <-chan struct{} {
+	return v.changed
+}
@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// counter is a published value which counts its own updates, so a reader can check that a frame is consistent.
+type counter struct {
+	updates uint64
+	writers map[int]int // updates per writer, copied on every update
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	const (
+		writers = 8
+		updates = 500
+		readers = 4
+	)
+	v := New(counter{writers: map[int]int{}})
+
+	var (
+		wg   sync.WaitGroup
+		done atomic.Bool
+	)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for !done.Load() {
+				frame := v.Load()
+				if frame.Version < last {
+					t.Errorf("version went back from %d to %d", last, frame.Version)
+					return
+				}
+				last = frame.Version
+				// Every update publishes a new frame, so the version is the number of updates of the value
+				sum := 0
+				for _, n := range frame.Value.writers {
+					sum += n
+				}
+				if frame.Value.updates != frame.Version || uint64(sum) != frame.Version {
+					t.Errorf("frame %d has %d updates and %d writer updates", frame.Version, frame.Value.updates, sum)
+					return
+				}
+			}
+		}()
+	}
+
+	var writersWg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersWg.Add(1)
+		go func() {
+			defer writersWg.Done()
+			for i := 0; i < updates; i++ {
+				v.Update(func(current counter) (counter, bool) {
+					next := counter{updates: current.updates + 1, writers: make(map[int]int, len(current.writers)+1)}
+					for k, n := range current.writers {
+						next.writers[k] = n
+					}
+					next.writers[w]++
+					return next, true
+				})
+			}
+		}()
+	}
+	writersWg.Wait()
+	done.Store(true)
+	wg.Wait()
+
+	frame := v.Load()
+	if frame.Version != writers*updates || frame.Value.updates != writers*updates {
+		t.Fatalf("got version %d with %d updates, want %d", frame.Version, frame.Value.updates, writers*updates)
+	}
+	for w := 0; w < writers; w++ {
+		if frame.Value.writers[w] != updates {
+			t.Errorf("writer %d has %d updates, want %d", w, frame.Value.writers[w], updates)
+		}
+	}
+}
+
+func TestUpdateUnchanged(t *testing.T) {
+	v := New(1)
+	first := v.Load()
+	if frame := v.Update(func(current int) (int, bool) { return current, false }); frame != first || frame.Version != 0 {
+		t.Fatalf("unchanged update published version %d", frame.Version)
+	}
+	select {
+	case <-v.Changed():
+		t.Fatal("unchanged update signaled a change")
+	default:
+	}
+
+	v.Publish(2)
+	v.Publish(3)
+	// Publishes until the change is received are coalesced
+	<-v.Changed()
+	select {
+	case <-v.Changed():
+		t.Fatal("coalesced publishes signaled twice")
+	default:
+	}
+	if frame := v.Load(); frame.Version != 2 || frame.Value != 3 {
+		t.Fatalf("got version %d value %d, want version 2 value 3", frame.Version, frame.Value)
+	}
+}
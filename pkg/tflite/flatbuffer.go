@@ -0,0 +1,186 @@
+package tflite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// table is a flatbuffer table inside the model buffer.
+// Only the parts of the flatbuffer format needed to read the model metadata are implemented,
+// every access is bounds checked, so a corrupted file results in an error instead of a panic.
+type table struct {
+	pos    int // Position of the table
+	vtable int // Position of the vtable
+	vsize  int // Size of the vtable in bytes
+}
+
+// reader reads flatbuffer tables and remembers the first error.
+type reader struct {
+	buf []byte
+	err error
+}
+
+// fail records the first error.
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+// check reports if n bytes can be read at pos.
+func (r *reader) check(pos, n int) bool {
+	if pos < 0 || n < 0 || pos+n > len(r.buf) {
+		r.fail("flatbuffer access out of range at %d+%d (size %d)", pos, n, len(r.buf))
+		return false
+	}
+	return true
+}
+
+func (r *reader) uint16(pos int) int {
+	if !r.check(pos, 2) {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(r.buf[pos:]))
+}
+
+func (r *reader) uint32(pos int) uint32 {
+	if !r.check(pos, 4) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(r.buf[pos:])
+}
+
+func (r *reader) int32(pos int) int32 {
+	return int32(r.uint32(pos))
+}
+
+func (r *reader) int64(pos int) int64 {
+	if !r.check(pos, 8) {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(r.buf[pos:]))
+}
+
+func (r *reader) float32(pos int) float32 {
+	return math.Float32frombits(r.uint32(pos))
+}
+
+// indirect follows the unsigned offset stored at pos.
+func (r *reader) indirect(pos int) int {
+	return pos + int(r.uint32(pos))
+}
+
+// table reads the table at pos.
+func (r *reader) table(pos int) table {
+	vtable := pos - int(r.int32(pos))
+	return table{pos: pos, vtable: vtable, vsize: r.uint16(vtable)}
+}
+
+// root reads the root table of the buffer.
+func (r *reader) root() table {
+	return r.table(r.indirect(0))
+}
+
+// field returns the position of a field of the table, or 0 if the field is not set.
+func (r *reader) field(t table, index int) int {
+	entry := 4 + 2*index
+	if entry+2 > t.vsize {
+		return 0
+	}
+	offset := r.uint16(t.vtable + entry)
+	if offset == 0 {
+		return 0
+	}
+	return t.pos + offset
+}
+
+// tableField reads a sub table, ok is false if the field is not set.
+func (r *reader) tableField(t table, index int) (table, bool) {
+	pos := r.field(t, index)
+	if pos == 0 {
+		return table{}, false
+	}
+	return r.table(r.indirect(pos)), true
+}
+
+// vector returns the position of the first element and the length of a vector field.
+func (r *reader) vector(t table, index int) (int, int) {
+	pos := r.field(t, index)
+	if pos == 0 {
+		return 0, 0
+	}
+	vec := r.indirect(pos)
+	n := int(r.uint32(vec))
+	if !r.check(vec+4, n) {
+		return 0, 0
+	}
+	return vec + 4, n
+}
+
+// tables reads a vector of tables.
+func (r *reader) tables(t table, index int) []table {
+	start, n := r.vector(t, index)
+	tables := make([]table, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		tables = append(tables, r.table(r.indirect(start+4*i)))
+	}
+	return tables
+}
+
+// ints reads a vector of int32.
+func (r *reader) ints(t table, index int) []int {
+	start, n := r.vector(t, index)
+	values := make([]int, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		values = append(values, int(r.int32(start+4*i)))
+	}
+	return values
+}
+
+// int64s reads a vector of int64.
+func (r *reader) int64s(t table, index int) []int64 {
+	start, n := r.vector(t, index)
+	values := make([]int64, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		values = append(values, r.int64(start+8*i))
+	}
+	return values
+}
+
+// float32s reads a vector of float32.
+func (r *reader) float32s(t table, index int) []float32 {
+	start, n := r.vector(t, index)
+	values := make([]float32, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		values = append(values, r.float32(start+4*i))
+	}
+	return values
+}
+
+// string reads a string field, empty if the field is not set.
+func (r *reader) string(t table, index int) string {
+	start, n := r.vector(t, index)
+	if n == 0 {
+		return ""
+	}
+	return string(r.buf[start : start+n])
+}
+
+// scalarUint32 reads an uint32 field with a default value.
+func (r *reader) scalarUint32(t table, index int, def uint32) uint32 {
+	pos := r.field(t, index)
+	if pos == 0 {
+		return def
+	}
+	return r.uint32(pos)
+}
+
+// scalarInt8 reads an int8 field with a default value.
+func (r *reader) scalarInt8(t table, index int, def int8) int8 {
+	pos := r.field(t, index)
+	if pos == 0 || !r.check(pos, 1) {
+		return def
+	}
+	return int8(r.buf[pos])
+}
@@ -0,0 +1,265 @@
+// Package tflite reads the metadata of a .tflite model without the TensorFlow Lite runtime.
+//
+// It reports the input and output tensors of the main subgraph with their names, shapes, types and
+// quantization, so tensor buffers can be sized from the model and a model that does not match what the
+// application expects fails fast with a clear error instead of producing garbage.
+package tflite
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TFLITE_FILE_IDENTIFIER is the flatbuffer file identifier of tflite models.
+const TFLITE_FILE_IDENTIFIER = "TFL3"
+
+// TensorType is the element type of a tensor, the values match the tflite schema.
+type TensorType int8
+
+const (
+	Float32    TensorType = 0
+	Float16    TensorType = 1
+	Int32      TensorType = 2
+	Uint8      TensorType = 3
+	Int64      TensorType = 4
+	String     TensorType = 5
+	Bool       TensorType = 6
+	Int16      TensorType = 7
+	Complex64  TensorType = 8
+	Int8       TensorType = 9
+	Float64    TensorType = 10
+	Complex128 TensorType = 11
+	Uint64     TensorType = 12
+	Resource   TensorType = 13
+	Variant    TensorType = 14
+	Uint32     TensorType = 15
+	Uint16     TensorType = 16
+)
+
+// typeInfos holds the name and element size of the tensor types, 0 for types without a fixed size.
+var typeInfos = map[TensorType]struct {
+	name string
+	size int
+}{
+	Float32:    {"float32", 4},
+	Float16:    {"float16", 2},
+	Int32:      {"int32", 4},
+	Uint8:      {"uint8", 1},
+	Int64:      {"int64", 8},
+	String:     {"string", 0},
+	Bool:       {"bool", 1},
+	Int16:      {"int16", 2},
+	Complex64:  {"complex64", 8},
+	Int8:       {"int8", 1},
+	Float64:    {"float64", 8},
+	Complex128: {"complex128", 16},
+	Uint64:     {"uint64", 8},
+	Resource:   {"resource", 0},
+	Variant:    {"variant", 0},
+	Uint32:     {"uint32", 4},
+	Uint16:     {"uint16", 2},
+}
+
+// String returns the name of the tensor type.
+func (t TensorType) String() string {
+	if info, ok := typeInfos[t]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("type(%d)", int8(t))
+}
+
+// Size returns the size of a single element in bytes, 0 for types without a fixed size.
+func (t TensorType) Size() int {
+	return typeInfos[t].size
+}
+
+// Quantization holds the quantization parameters of a tensor, real value = Scale * (quantized value - ZeroPoint).
+// Per tensor quantized tensors have a single scale and zero point, per axis quantized tensors one per channel.
+type Quantization struct {
+	Scale     []float32
+	ZeroPoint []int64
+}
+
+// Tensor describes an input or output tensor of a model.
+type Tensor struct {
+	Name         string
+	Shape        []int
+	Type         TensorType
+	Quantization Quantization
+}
+
+// Elements returns the number of elements of the tensor.
+func (t Tensor) Elements() int {
+	n := 1
+	for _, d := range t.Shape {
+		n *= d
+	}
+	return n
+}
+
+// Size returns the size of the tensor in bytes.
+func (t Tensor) Size() int {
+	return t.Elements() * t.Type.Size()
+}
+
+// Quantized reports if the tensor has per tensor quantization parameters.
+// Returns the scale and zero point of the tensor.
+func (t Tensor) Quantized() (float32, int, bool) {
+	if len(t.Quantization.Scale) != 1 {
+		return 0, 0, false
+	}
+	zeroPoint := 0
+	if len(t.Quantization.ZeroPoint) > 0 {
+		zeroPoint = int(t.Quantization.ZeroPoint[0])
+	}
+	return t.Quantization.Scale[0], zeroPoint, true
+}
+
+// String returns a short description like `"images" uint8[1 640 640 3] scale 0.0039 zero point 0`.
+func (t Tensor) String() string {
+	s := fmt.Sprintf("%q %s%v", t.Name, t.Type, t.Shape)
+	if scale, zeroPoint, ok := t.Quantized(); ok {
+		s += fmt.Sprintf(" scale %g zero point %d", scale, zeroPoint)
+	}
+	return s
+}
+
+// Model holds the metadata of a tflite model.
+type Model struct {
+	Version     uint32
+	Description string
+	Inputs      []Tensor // Inputs of the main subgraph in order
+	Outputs     []Tensor // Outputs of the main subgraph in order
+}
+
+// ReadFile reads the metadata of a .tflite model file.
+func ReadFile(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	model, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return model, nil
+}
+
+// Parse reads the metadata of a tflite model from the model flatbuffer.
+// Returns an error if the buffer is not a valid tflite model.
+func Parse(data []byte) (*Model, error) {
+	if len(data) < 8 || string(data[4:8]) != TFLITE_FILE_IDENTIFIER {
+		return nil, fmt.Errorf("not a tflite model, missing %s identifier", TFLITE_FILE_IDENTIFIER)
+	}
+
+	// Model: 0 version, 1 operator_codes, 2 subgraphs, 3 description
+	r := &reader{buf: data}
+	root := r.root()
+	model := &Model{
+		Version:     r.scalarUint32(root, 0, 0),
+		Description: r.string(root, 3),
+	}
+	subgraphs := r.tables(root, 2)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(subgraphs) == 0 {
+		return nil, fmt.Errorf("model has no subgraph")
+	}
+
+	// SubGraph: 0 tensors, 1 inputs, 2 outputs
+	graph := subgraphs[0]
+	tensors := r.tables(graph, 0)
+	tensor := func(index int) Tensor {
+		if index < 0 || index >= len(tensors) {
+			r.fail("tensor index %d out of range (%d tensors)", index, len(tensors))
+			return Tensor{}
+		}
+		return r.tensor(tensors[index])
+	}
+	for _, index := range r.ints(graph, 1) {
+		model.Inputs = append(model.Inputs, tensor(index))
+	}
+	for _, index := range r.ints(graph, 2) {
+		model.Outputs = append(model.Outputs, tensor(index))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return model, nil
+}
+
+// tensor reads a tensor table.
+func (r *reader) tensor(t table) Tensor {
+	// Tensor: 0 shape, 1 type, 2 buffer, 3 name, 4 quantization
+	tensor := Tensor{
+		Shape: r.ints(t, 0),
+		Type:  TensorType(r.scalarInt8(t, 1, 0)),
+		Name:  r.string(t, 3),
+	}
+	// QuantizationParameters: 0 min, 1 max, 2 scale, 3 zero_point
+	if q, ok := r.tableField(t, 4); ok {
+		tensor.Quantization = Quantization{Scale: r.float32s(q, 2), ZeroPoint: r.int64s(q, 3)}
+	}
+	return tensor
+}
+
+// TensorSpec describes what an application expects from a tensor.
+// Fields:
+//   - Types: Allowed tensor types, nil allows all types.
+//   - Shape: Expected shape, -1 allows any size of a dimension, nil allows all shapes.
+type TensorSpec struct {
+	Types []TensorType
+	Shape []int
+}
+
+// Check returns an error if the tensor does not match the spec.
+func (s TensorSpec) Check(t Tensor) error {
+	if s.Types != nil {
+		allowed := false
+		for _, typ := range s.Types {
+			allowed = allowed || typ == t.Type
+		}
+		if !allowed {
+			return fmt.Errorf("tensor %s: expected type %v, got %s", t, s.Types, t.Type)
+		}
+	}
+	if s.Shape != nil {
+		match := len(s.Shape) == len(t.Shape)
+		for i := 0; match && i < len(s.Shape); i++ {
+			match = s.Shape[i] < 0 || s.Shape[i] == t.Shape[i]
+		}
+		if !match {
+			return fmt.Errorf("tensor %s: expected shape %v, got %v", t, s.Shape, t.Shape)
+		}
+	}
+	return nil
+}
+
+// Expect checks the inputs and outputs of the model against the specs.
+// Returns a single error listing all mismatches, or nil if the model matches.
+func (m *Model) Expect(inputs, outputs []TensorSpec) error {
+	var problems []string
+	check := func(kind string, specs []TensorSpec, tensors []Tensor) {
+		if len(specs) != len(tensors) {
+			problems = append(problems, fmt.Sprintf("expected %d %ss, model has %d", len(specs), kind, len(tensors)))
+			return
+		}
+		for i, spec := range specs {
+			if err := spec.Check(tensors[i]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %d: %s", kind, i, err.Error()))
+			}
+		}
+	}
+	if inputs != nil {
+		check("input", inputs, m.Inputs)
+	}
+	if outputs != nil {
+		check("output", outputs, m.Outputs)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("model does not match: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
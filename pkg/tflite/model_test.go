@@ -0,0 +1,82 @@
+package tflite
+
+import (
+	"math"
+	"os"
+	"slices"
+	"testing"
+)
+
+const yolov5nPath = "../../axlarod/yolov5/yolov5n.tflite"
+
+func TestReadFileYolov5n(t *testing.T) {
+	model, err := ReadFile(yolov5nPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Inputs) != 1 || len(model.Outputs) != 1 {
+		t.Fatalf("got %d inputs and %d outputs, want 1 and 1", len(model.Inputs), len(model.Outputs))
+	}
+
+	input := model.Inputs[0]
+	if input.Type != Uint8 || !slices.Equal(input.Shape, []int{1, 640, 640, 3}) {
+		t.Errorf("input %s, want uint8[1 640 640 3]", input)
+	}
+	if input.Size() != 640*640*3 {
+		t.Errorf("input size %d, want %d", input.Size(), 640*640*3)
+	}
+
+	output := model.Outputs[0]
+	if output.Type != Uint8 || !slices.Equal(output.Shape, []int{1, 25200, 85}) {
+		t.Errorf("output %s, want uint8[1 25200 85]", output)
+	}
+	scale, zeroPoint, ok := output.Quantized()
+	if !ok || math.Abs(float64(scale)-0.0041905) > 1e-6 || zeroPoint != 0 {
+		t.Errorf("output quantization %v %d %v, want scale 0.0041905 zero point 0", scale, zeroPoint, ok)
+	}
+
+	if err := model.Expect(
+		[]TensorSpec{{Types: []TensorType{Uint8}, Shape: []int{1, 640, 640, 3}}},
+		[]TensorSpec{{Types: []TensorType{Uint8, Int8}, Shape: []int{1, -1, 85}}},
+	); err != nil {
+		t.Errorf("Expect: %v", err)
+	}
+	if err := model.Expect(nil, []TensorSpec{{Types: []TensorType{Float32}}}); err == nil {
+		t.Error("Expect accepted a float32 output")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	data, err := os.ReadFile(yolov5nPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "missing identifier", data: []byte("not a tflite model")},
+		{name: "identifier only", data: data[:8]},
+		{name: "truncated header", data: data[:64]},
+		{name: "truncated tables", data: data[:512]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if model, err := Parse(tt.data); err == nil {
+				t.Errorf("Parse of %d bytes succeeded with %+v", len(tt.data), model)
+			}
+		})
+	}
+}
+
+// TestParseTruncatedDoesNotPanic parses every prefix length up to a few KiB, a broken model must fail with an error.
+func TestParseTruncatedDoesNotPanic(t *testing.T) {
+	data, err := os.ReadFile(yolov5nPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 4096; n++ {
+		Parse(data[:n])
+	}
+}
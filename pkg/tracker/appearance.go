@@ -0,0 +1,120 @@
+package tracker
+
+import "math"
+
+// Embedder produces an appearance embedding for a detection.
+// The tracker only works on the resulting vectors, so any implementation (larod model, fake vectors) can be used.
+type Embedder interface {
+	// Embed returns the appearance embedding of the box region in an interleaved RGB image.
+	// The box is in normalized coordinates of the image.
+	Embed(rgb []byte, width, height int, box Box) ([]float32, error)
+}
+
+// CropRGB cuts the box region out of an interleaved RGB image and resizes it with nearest neighbor sampling.
+// Args:
+//   - rgb: Interleaved RGB image data.
+//   - width, height: Dimensions of the image.
+//   - box: Region to crop in normalized coordinates, clamped to the image.
+//   - outWidth, outHeight: Dimensions of the returned crop.
+//
+// Returns:
+//
+//	[]byte: Interleaved RGB crop of size outWidth * outHeight * 3.
+func CropRGB(rgb []byte, width, height int, box Box, outWidth, outHeight int) []byte {
+	crop := make([]byte, outWidth*outHeight*3)
+	if width <= 0 || height <= 0 || len(rgb) < width*height*3 {
+		return crop
+	}
+
+	left := clampInt(int(box.Left*float32(width)), 0, width-1)
+	top := clampInt(int(box.Top*float32(height)), 0, height-1)
+	right := clampInt(int(box.Right*float32(width)), left+1, width)
+	bottom := clampInt(int(box.Bottom*float32(height)), top+1, height)
+	cropWidth := right - left
+	cropHeight := bottom - top
+
+	for y := 0; y < outHeight; y++ {
+		srcY := top + y*cropHeight/outHeight
+		for x := 0; x < outWidth; x++ {
+			srcX := left + x*cropWidth/outWidth
+			src := (srcY*width + srcX) * 3
+			dst := (y*outWidth + x) * 3
+			copy(crop[dst:dst+3], rgb[src:src+3])
+		}
+	}
+	return crop
+}
+
+// NormalizeEmbedding scales an embedding to unit length in place,
+// so the cosine distance reduces to a dot product.
+func NormalizeEmbedding(embedding []float32) {
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range embedding {
+		embedding[i] = float32(float64(embedding[i]) / norm)
+	}
+}
+
+// CosineDistance computes the cosine distance (1 - cosine similarity) between two embeddings.
+// Returns:
+//
+//	float32: The distance, ranging from 0 (same direction) to 2 (opposite direction).
+//	         Embeddings of different length or with zero length have a distance of 1.
+func CosineDistance(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 1
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return float32(1 - dot/(math.Sqrt(normA)*math.Sqrt(normB)))
+}
+
+// addEmbedding stores an embedding in the appearance gallery of the track.
+// The gallery keeps the last gallerySize embeddings.
+func (t *Track) addEmbedding(embedding []float32, gallerySize int) {
+	if embedding == nil || gallerySize <= 0 {
+		return
+	}
+	if len(t.gallery) < gallerySize {
+		t.gallery = append(t.gallery, embedding)
+		return
+	}
+	t.gallery[t.galleryNext%gallerySize] = embedding
+	t.galleryNext++
+}
+
+// appearanceDistance returns the smallest cosine distance between an embedding and the gallery of the track.
+// Returns false if the embedding is nil or the track has no gallery.
+func (t *Track) appearanceDistance(embedding []float32) (float32, bool) {
+	if embedding == nil || len(t.gallery) == 0 {
+		return 0, false
+	}
+	best := float32(math.MaxFloat32)
+	for _, g := range t.gallery {
+		best = min(best, CosineDistance(embedding, g))
+	}
+	return best, true
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package tracker
+
+import "testing"
+
+// colorEmbedder is a fake Embedder, the embedding is the mean color of the crop.
+type colorEmbedder struct{}
+
+func (colorEmbedder) Embed(rgb []byte, width, height int, box Box) ([]float32, error) {
+	crop := CropRGB(rgb, width, height, box, 4, 4)
+	embedding := make([]float32, 3)
+	for i, v := range crop {
+		embedding[i%3] += float32(v)
+	}
+	NormalizeEmbedding(embedding)
+	return embedding, nil
+}
+
+// testImage returns an RGB image with a red left half and a blue right half.
+func testImage(width, height int) []byte {
+	rgb := make([]byte, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			channel := 0
+			if x >= width/2 {
+				channel = 2
+			}
+			rgb[(y*width+x)*3+channel] = 255
+		}
+	}
+	return rgb
+}
+
+// embedded returns a detection with the embedding of its box in an image.
+func embedded(t *testing.T, e Embedder, rgb []byte, width, height int, d testDetection) testDetection {
+	t.Helper()
+	var err error
+	if d.embedding, err = e.Embed(rgb, width, height, d.box); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestSORTReIdentification(t *testing.T) {
+	const width, height = 100, 100
+	rgb, e := testImage(width, height), colorEmbedder{}
+	s := NewSORT[testDetection](2, 1, 0.3, 0.3)
+
+	red := embedded(t, e, rgb, width, height, testDetection{object: 1, box: boxAt(0.1, 0.1, 0.2), score: 0.9})
+	objects := s.Update([]testDetection{red})
+	if len(objects) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objects))
+	}
+	id := objects[0].ID
+
+	// Occluded for longer than MaxMissed, the track is only kept for re-identification
+	for frame := 0; frame < s.MaxMissed+3; frame++ {
+		s.Update(nil)
+	}
+	if len(s.Tracks) != 1 {
+		t.Fatalf("got %d tracks, want the lost track kept for re-identification", len(s.Tracks))
+	}
+
+	// The object comes back at another position without IOU, next to an object of another appearance
+	red = embedded(t, e, rgb, width, height, testDetection{object: 1, box: boxAt(0.1, 0.7, 0.2), score: 0.9})
+	blue := embedded(t, e, rgb, width, height, testDetection{object: 2, box: boxAt(0.7, 0.1, 0.2), score: 0.9})
+	objects = s.Update([]testDetection{blue, red})
+	if len(objects) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objects))
+	}
+	for _, obj := range objects {
+		switch obj.Detection.object {
+		case 1:
+			if obj.ID != id {
+				t.Errorf("red object got ID %d, want it re-identified as %d", obj.ID, id)
+			}
+		case 2:
+			if obj.ID == id {
+				t.Errorf("blue object took over ID %d of the red object", id)
+			}
+		}
+	}
+}
+
+func TestSORTMaxCosineDistance(t *testing.T) {
+	s := NewSORT[testDetection](2, 1, 0.3, 0.3)
+	box := boxAt(0.1, 0.1, 0.2)
+	objects := s.Update([]testDetection{{object: 1, box: box, score: 0.9, embedding: []float32{1, 0, 0}}})
+	id := objects[0].ID
+
+	tests := []struct {
+		name      string
+		embedding []float32
+		sameID    bool
+	}{
+		{name: "similar appearance matches", embedding: []float32{0.95, 0.3, 0}, sameID: true},
+		{name: "different appearance at the same box is gated", embedding: []float32{0, 1, 0}, sameID: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := CosineDistance(tt.embedding, []float32{1, 0, 0}); (d <= s.MaxCosineDistance) != tt.sameID {
+				t.Fatalf("distance %v does not fit the test case with gate %v", d, s.MaxCosineDistance)
+			}
+			objects := s.Update([]testDetection{{object: 1, box: box, score: 0.9, embedding: tt.embedding}})
+			got := -1
+			for _, obj := range objects {
+				if !obj.Coasting {
+					got = obj.ID
+				}
+			}
+			if (got == id) != tt.sameID {
+				t.Errorf("got ID %d, track ID %d, want same ID %v", got, id, tt.sameID)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package tracker
+
+// Box is a bounding box in normalized coordinates.
+// It has the same layout as axlarod.BoundingBox, so both types can be converted into each other directly.
+type Box struct {
+	Top    float32
+	Left   float32
+	Bottom float32
+	Right  float32
+}
+
+// IOU computes the Intersection over Union (IOU) between two bounding boxes.
+// Args:
+//   - box1: The first bounding box.
+//   - box2: The second bounding box.
+//
+// Returns:
+//
+//	float32: The IOU score, ranging from 0 (no overlap) to 1 (perfect overlap).
+//
+// Behavior:
+// - Returns 0 if the boxes do not overlap.
+// - Handles edge cases where areas are zero or overlap is invalid.
+func IOU(box1, box2 Box) float32 {
+	// Calculate intersection coordinates
+	interLeft := max(box1.Left, box2.Left)
+	interTop := max(box1.Top, box2.Top)
+	interRight := min(box1.Right, box2.Right)
+	interBottom := min(box1.Bottom, box2.Bottom)
+
+	// If no overlap, return 0 immediately
+	if interLeft >= interRight || interTop >= interBottom {
+		return 0
+	}
+
+	// Calculate intersection area
+	intersection := (interRight - interLeft) * (interBottom - interTop)
+
+	// Calculate areas of both boxes
+	area1 := (box1.Right - box1.Left) * (box1.Bottom - box1.Top)
+	area2 := (box2.Right - box2.Left) * (box2.Bottom - box2.Top)
+
+	// Union area
+	union := area1 + area2 - intersection
+
+	// Handle edge case where union area is zero (shouldn't occur if inputs are valid)
+	if union <= 0 {
+		return 0
+	}
+
+	// Return IOU as intersection over union
+	return intersection / union
+}
+
+// Center returns the center point of the box.
+func (b Box) Center() (float32, float32) {
+	return (b.Left + b.Right) / 2, (b.Top + b.Bottom) / 2
+}
@@ -0,0 +1,100 @@
+package tracker
+
+import "math"
+
+// HungarianAssignment solves the linear assignment problem for a rectangular cost matrix
+// with the Hungarian (Kuhn-Munkres) algorithm in O(n^3).
+//
+// Args:
+//   - cost: A rows x cols cost matrix, cost[i][j] is the cost of assigning row i to column j.
+//
+// Returns:
+//
+//	[]int: For each row the assigned column, or -1 if the row stays unassigned (only when rows > cols).
+//
+// The returned assignment minimizes the total cost over all rows, independent of the row order.
+func HungarianAssignment(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return []int{}
+	}
+	cols := len(cost[0])
+
+	// Pad to a square matrix, padding cells have zero cost
+	n := rows
+	if cols > n {
+		n = cols
+	}
+	at := func(i, j int) float64 {
+		if i < rows && j < cols {
+			return cost[i][j]
+		}
+		return 0
+	}
+
+	// Potentials and matching are 1-indexed, index 0 is used as a virtual start column
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)   // p[j] is the row matched to column j
+	way := make([]int, n+1) // way[j] is the previous column on the augmenting path
+	minv := make([]float64, n+1)
+	used := make([]bool, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		for j := range minv {
+			minv[j] = math.Inf(1)
+			used[j] = false
+		}
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := at(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		// Augment along the found path
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, rows)
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	for j := 1; j <= n; j++ {
+		if p[j] > 0 && p[j] <= rows && j <= cols {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
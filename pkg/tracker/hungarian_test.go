@@ -0,0 +1,92 @@
+package tracker
+
+import (
+	"reflect"
+	"testing"
+)
+
+// totalCost returns the cost of an assignment, unassigned rows cost nothing.
+func totalCost(cost [][]float64, assignment []int) float64 {
+	total := 0.0
+	for row, col := range assignment {
+		if col >= 0 {
+			total += cost[row][col]
+		}
+	}
+	return total
+}
+
+func TestHungarianAssignment(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]float64
+		want []int
+	}{
+		{
+			name: "empty",
+			cost: [][]float64{},
+			want: []int{},
+		},
+		{
+			name: "square, greedy would take the cheapest cell first",
+			cost: [][]float64{
+				{1, 2},
+				{2, 100},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "more columns than rows",
+			cost: [][]float64{
+				{5, 1, 9, 9},
+				{5, 2, 1, 9},
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "more rows than columns, the most expensive row stays unassigned",
+			cost: [][]float64{
+				{1, 9},
+				{9, 1},
+				{3, 3},
+			},
+			want: []int{0, 1, -1},
+		},
+		{
+			name: "gated row does not take a column from a valid pair",
+			cost: [][]float64{
+				{GATED_COST, GATED_COST},
+				{0.2, GATED_COST},
+				{GATED_COST, 0.4},
+			},
+			want: []int{-1, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HungarianAssignment(tt.cost)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HungarianAssignment() = %v, want %v (cost %v, want cost %v)", got, tt.want, totalCost(tt.cost, got), totalCost(tt.cost, tt.want))
+			}
+		})
+	}
+}
+
+func TestHungarianAssignmentAllGated(t *testing.T) {
+	cost := [][]float64{
+		{GATED_COST, GATED_COST, GATED_COST},
+		{GATED_COST, GATED_COST, GATED_COST},
+	}
+	// Gated rows may get any column, SORT skips assignments with GATED_COST, but no column is used twice
+	used := map[int]bool{}
+	for row, col := range HungarianAssignment(cost) {
+		if col < 0 {
+			t.Errorf("row %d unassigned although there are more columns than rows", row)
+			continue
+		}
+		if used[col] {
+			t.Errorf("column %d assigned twice", col)
+		}
+		used[col] = true
+	}
+}
@@ -0,0 +1,221 @@
+package tracker
+
+const (
+	KALMAN_STATE_SIZE       = 8 // cx, cy, w, h, vcx, vcy, vw, vh
+	KALMAN_MEASUREMENT_SIZE = 4 // cx, cy, w, h
+)
+
+var (
+	KALMAN_STD_WEIGHT_POSITION = 1.0 / 20 // Position noise relative to the box size
+	KALMAN_STD_WEIGHT_VELOCITY = 1.0 / 40 // Velocity noise relative to the box size, objects can move fast at low fps
+)
+
+// KalmanBoxFilter is a constant velocity Kalman filter for a single bounding box.
+// The state is the box center, width and height plus their velocities per frame.
+// Noise is scaled by the current box size, so it works on normalized coordinates
+// the same way as on pixel coordinates.
+type KalmanBoxFilter struct {
+	x [KALMAN_STATE_SIZE]float64                    // State mean
+	P [KALMAN_STATE_SIZE][KALMAN_STATE_SIZE]float64 // State covariance
+}
+
+// NewKalmanBoxFilter initializes a filter from the first observed bounding box.
+// Velocities start at zero with a high uncertainty.
+func NewKalmanBoxFilter(box Box) *KalmanBoxFilter {
+	kf := &KalmanBoxFilter{}
+	z := boxToMeasurement(box)
+	copy(kf.x[:KALMAN_MEASUREMENT_SIZE], z[:])
+
+	std := [KALMAN_STATE_SIZE]float64{
+		2 * KALMAN_STD_WEIGHT_POSITION * z[2],
+		2 * KALMAN_STD_WEIGHT_POSITION * z[3],
+		2 * KALMAN_STD_WEIGHT_POSITION * z[2],
+		2 * KALMAN_STD_WEIGHT_POSITION * z[3],
+		10 * KALMAN_STD_WEIGHT_VELOCITY * z[2],
+		10 * KALMAN_STD_WEIGHT_VELOCITY * z[3],
+		10 * KALMAN_STD_WEIGHT_VELOCITY * z[2],
+		10 * KALMAN_STD_WEIGHT_VELOCITY * z[3],
+	}
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		kf.P[i][i] = std[i] * std[i]
+	}
+	return kf
+}
+
+// Predict advances the state by one frame and returns the predicted bounding box.
+func (kf *KalmanBoxFilter) Predict() Box {
+	w, h := kf.x[2], kf.x[3]
+	std := [KALMAN_STATE_SIZE]float64{
+		KALMAN_STD_WEIGHT_POSITION * w,
+		KALMAN_STD_WEIGHT_POSITION * h,
+		KALMAN_STD_WEIGHT_POSITION * w,
+		KALMAN_STD_WEIGHT_POSITION * h,
+		KALMAN_STD_WEIGHT_VELOCITY * w,
+		KALMAN_STD_WEIGHT_VELOCITY * h,
+		KALMAN_STD_WEIGHT_VELOCITY * w,
+		KALMAN_STD_WEIGHT_VELOCITY * h,
+	}
+
+	// x = F * x, where F adds the velocity to each position component
+	for i := 0; i < KALMAN_MEASUREMENT_SIZE; i++ {
+		kf.x[i] += kf.x[i+KALMAN_MEASUREMENT_SIZE]
+	}
+
+	// Do not let the box collapse when it shrinks while coasting
+	if kf.x[2] <= 0 || kf.x[3] <= 0 {
+		kf.x[2], kf.x[3] = w, h
+		kf.x[6], kf.x[7] = 0, 0
+	}
+
+	// P = F * P * F^T + Q
+	var fp [KALMAN_STATE_SIZE][KALMAN_STATE_SIZE]float64
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		for j := 0; j < KALMAN_STATE_SIZE; j++ {
+			fp[i][j] = kf.P[i][j]
+			if i < KALMAN_MEASUREMENT_SIZE {
+				fp[i][j] += kf.P[i+KALMAN_MEASUREMENT_SIZE][j]
+			}
+		}
+	}
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		for j := 0; j < KALMAN_STATE_SIZE; j++ {
+			kf.P[i][j] = fp[i][j]
+			if j < KALMAN_MEASUREMENT_SIZE {
+				kf.P[i][j] += fp[i][j+KALMAN_MEASUREMENT_SIZE]
+			}
+		}
+		kf.P[i][i] += std[i] * std[i]
+	}
+
+	return kf.Box()
+}
+
+// Correct updates the state with an observed bounding box and returns the corrected bounding box.
+func (kf *KalmanBoxFilter) Correct(box Box) Box {
+	z := boxToMeasurement(box)
+	w, h := kf.x[2], kf.x[3]
+	std := [KALMAN_MEASUREMENT_SIZE]float64{
+		KALMAN_STD_WEIGHT_POSITION * w,
+		KALMAN_STD_WEIGHT_POSITION * h,
+		KALMAN_STD_WEIGHT_POSITION * w,
+		KALMAN_STD_WEIGHT_POSITION * h,
+	}
+
+	// S = H * P * H^T + R, H selects the measured part of the state
+	var s [KALMAN_MEASUREMENT_SIZE][KALMAN_MEASUREMENT_SIZE]float64
+	for i := 0; i < KALMAN_MEASUREMENT_SIZE; i++ {
+		for j := 0; j < KALMAN_MEASUREMENT_SIZE; j++ {
+			s[i][j] = kf.P[i][j]
+		}
+		s[i][i] += std[i] * std[i]
+	}
+	sInv, ok := invert4x4(s)
+	if !ok {
+		return kf.Box()
+	}
+
+	// K = P * H^T * S^-1
+	var k [KALMAN_STATE_SIZE][KALMAN_MEASUREMENT_SIZE]float64
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		for j := 0; j < KALMAN_MEASUREMENT_SIZE; j++ {
+			for n := 0; n < KALMAN_MEASUREMENT_SIZE; n++ {
+				k[i][j] += kf.P[i][n] * sInv[n][j]
+			}
+		}
+	}
+
+	// x = x + K * (z - H * x)
+	var y [KALMAN_MEASUREMENT_SIZE]float64
+	for i := 0; i < KALMAN_MEASUREMENT_SIZE; i++ {
+		y[i] = z[i] - kf.x[i]
+	}
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		for j := 0; j < KALMAN_MEASUREMENT_SIZE; j++ {
+			kf.x[i] += k[i][j] * y[j]
+		}
+	}
+
+	// P = (I - K * H) * P
+	var p [KALMAN_STATE_SIZE][KALMAN_STATE_SIZE]float64
+	for i := 0; i < KALMAN_STATE_SIZE; i++ {
+		for j := 0; j < KALMAN_STATE_SIZE; j++ {
+			p[i][j] = kf.P[i][j]
+			for n := 0; n < KALMAN_MEASUREMENT_SIZE; n++ {
+				p[i][j] -= k[i][n] * kf.P[n][j]
+			}
+		}
+	}
+	kf.P = p
+
+	return kf.Box()
+}
+
+// Box returns the current state as bounding box.
+func (kf *KalmanBoxFilter) Box() Box {
+	cx, cy, w, h := kf.x[0], kf.x[1], kf.x[2], kf.x[3]
+	return Box{
+		Top:    float32(cy - h/2),
+		Left:   float32(cx - w/2),
+		Bottom: float32(cy + h/2),
+		Right:  float32(cx + w/2),
+	}
+}
+
+// boxToMeasurement converts a bounding box into the center, width and height measurement.
+func boxToMeasurement(box Box) [KALMAN_MEASUREMENT_SIZE]float64 {
+	w := float64(box.Right - box.Left)
+	h := float64(box.Bottom - box.Top)
+	return [KALMAN_MEASUREMENT_SIZE]float64{
+		float64(box.Left) + w/2,
+		float64(box.Top) + h/2,
+		w,
+		h,
+	}
+}
+
+// invert4x4 inverts a 4x4 matrix using Gauss-Jordan elimination with partial pivoting.
+// Returns false if the matrix is singular.
+func invert4x4(m [KALMAN_MEASUREMENT_SIZE][KALMAN_MEASUREMENT_SIZE]float64) ([KALMAN_MEASUREMENT_SIZE][KALMAN_MEASUREMENT_SIZE]float64, bool) {
+	var inv [KALMAN_MEASUREMENT_SIZE][KALMAN_MEASUREMENT_SIZE]float64
+	for i := 0; i < KALMAN_MEASUREMENT_SIZE; i++ {
+		inv[i][i] = 1
+	}
+
+	for col := 0; col < KALMAN_MEASUREMENT_SIZE; col++ {
+		pivot := col
+		for row := col + 1; row < KALMAN_MEASUREMENT_SIZE; row++ {
+			if abs64(m[row][col]) > abs64(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if m[pivot][col] == 0 {
+			return inv, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+
+		d := m[col][col]
+		for j := 0; j < KALMAN_MEASUREMENT_SIZE; j++ {
+			m[col][j] /= d
+			inv[col][j] /= d
+		}
+		for row := 0; row < KALMAN_MEASUREMENT_SIZE; row++ {
+			if row == col {
+				continue
+			}
+			f := m[row][col]
+			for j := 0; j < KALMAN_MEASUREMENT_SIZE; j++ {
+				m[row][j] -= f * m[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, true
+}
+
+func abs64(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
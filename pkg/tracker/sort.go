@@ -0,0 +1,524 @@
+// Package tracker provides a SORT (Simple Online and Realtime Tracking) multi object tracker
+// with a Kalman motion model, optimal assignment, track lifecycle states and optional appearance re-identification.
+//
+// Any detection type can be tracked by implementing the Detection interface.
+package tracker
+
+import (
+	"sort"
+	"time"
+)
+
+// GATED_COST is the assignment cost for detection/track pairs that did not pass the IOU threshold.
+const GATED_COST = 1e5
+
+// TrackState describes the lifecycle state of a track.
+//
+// A track starts as TrackTentative and becomes TrackConfirmed after `MinHits` consecutive matched frames.
+// A confirmed track without a detection becomes TrackLost and coasts along its prediction until it is
+// matched again (back to TrackConfirmed) or exceeds `MaxMissed` (TrackRemoved).
+// A tentative track that misses a single frame is removed directly.
+type TrackState int
+
+const (
+	TrackTentative TrackState = iota // Not yet confirmed, not returned by Update
+	TrackConfirmed                   // Matched for at least MinHits consecutive frames
+	TrackLost                        // Confirmed track without a detection, coasting on its prediction
+	TrackRemoved                     // Track was deleted from the tracker
+)
+
+// String returns a readable name of the track state.
+func (ts TrackState) String() string {
+	switch ts {
+	case TrackTentative:
+		return "tentative"
+	case TrackConfirmed:
+		return "confirmed"
+	case TrackLost:
+		return "lost"
+	case TrackRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Detection is the interface a detection type has to implement to be tracked.
+type Detection interface {
+	GetBox() Box       // Bounding box in normalized coordinates
+	GetScore() float32 // Confidence score
+	GetClass() int     // Class index, detections are only matched to tracks of the same class
+}
+
+// AppearanceDetection is a Detection with an appearance embedding used for re-identification.
+// GetEmbedding may return nil if no embedding is available for the detection.
+type AppearanceDetection interface {
+	Detection
+	GetEmbedding() []float32
+}
+
+// TrackedObject is a confirmed or lost track returned by Update.
+// Fields:
+//   - Detection: The detection matched in this frame, the zero value for coasting tracks.
+//   - ID: Unique identifier of the track.
+//   - Class: Class index of the track.
+//   - Box: Detection box, or the predicted box for coasting tracks.
+//   - PredictedBox: Box predicted by the motion model of the track.
+//   - State: Lifecycle state of the track.
+//   - IsNew: Indicates if the track got confirmed in this frame.
+//   - Coasting: Indicates if the track had no detection in this frame and follows its prediction.
+//   - Age: Number of frames the track has been matched.
+//   - TrackingSince: Duration since the track was created.
+//   - Trajectory: Past box centers of the track, ordered from oldest to newest.
+type TrackedObject[D Detection] struct {
+	Detection     D
+	ID            int
+	Class         int
+	Box           Box
+	PredictedBox  Box
+	State         TrackState
+	IsNew         bool
+	Coasting      bool
+	Age           int
+	TrackingSince time.Duration
+	Trajectory    []TrajectoryPoint
+}
+
+// Track represents a single tracked object.
+// Fields:
+//   - ID: Unique identifier for the track, assigned when the track gets confirmed (0 while tentative).
+//   - Class: Class index of the detections assigned to this track.
+//   - State: Lifecycle state of the track.
+//   - Hits: Number of consecutive frames with a detection.
+//   - Box: Current bounding box of the tracked object, filtered by the motion model.
+//   - PredictedBox: Bounding box predicted by the motion model for the current frame.
+//   - Age: Number of frames the track has been active.
+//   - Missed: Number of consecutive frames without a detection.
+//   - SortScore: Average IOU score across all detections assigned to this track.
+//   - Trajectory: Bounded history of the past box centers with timestamps.
+//   - gallery: Last appearance embeddings of the track, used for re-identification.
+type Track struct {
+	ID           int              // Unique identifier
+	Class        int              // Class index
+	State        TrackState       // Lifecycle state
+	Hits         int              // Consecutive matched frames
+	Box          Box              // Bounding box
+	PredictedBox Box              // Predicted bounding box
+	Age          int              // Active frames
+	Missed       int              // Missed frames
+	SortScore    float32          // Average IOU
+	CreatedTime  time.Time        // Time the track was created
+	Trajectory   *Trajectory      // Past box centers
+	kf           *KalmanBoxFilter // Constant velocity motion model
+	gallery      [][]float32      // Appearance embeddings
+	galleryNext  int              // Next gallery slot to overwrite
+}
+
+// Predict advances the motion model of the track by one frame and stores the predicted box.
+func (t *Track) Predict() {
+	t.PredictedBox = t.kf.Predict()
+}
+
+// Correct updates the motion model of the track with the assigned detection box
+// and records the corrected box center in the trajectory.
+func (t *Track) Correct(box Box) {
+	t.Box = t.kf.Correct(box)
+	t.recordPosition()
+}
+
+// recordPosition adds the center of the current box to the trajectory.
+func (t *Track) recordPosition() {
+	x, y := t.Box.Center()
+	t.Trajectory.Add(TrajectoryPoint{X: x, Y: y, Time: time.Now()})
+}
+
+// ActiveTime returns the duration since the track was created.
+func (t *Track) ActiveTime() time.Duration {
+	return time.Since(t.CreatedTime)
+}
+
+// TrackStateChangeCallback is called when a track changes its lifecycle state.
+// The track already holds the new state, previous is the state before the transition.
+type TrackStateChangeCallback func(track *Track, previous TrackState)
+
+// SORT represents a simple online and realtime tracking system for detections of type D.
+// Fields:
+//   - Tracks: A map of all tracks (tentative, confirmed and lost) indexed by an internal track key.
+//   - NextTrackID: The next unique ID to assign to a confirmed track.
+//   - MaxMissed: Maximum frames to keep a track without detections before removal.
+//   - MinHits: Consecutive matched frames required before a track is confirmed.
+//   - MinScore: Minimum detection confidence score required for tracking.
+//   - IOUThreshold: Minimum IOU required to match a detection with a track.
+//   - OnTrackStateChange: Optional callback for lifecycle transitions of tracks.
+//   - AppearanceWeight: Weight of the appearance (cosine) distance in the matching cost, the IOU distance gets the remaining weight.
+//   - MaxCosineDistance: Maximum appearance distance for a detection to match a track.
+//   - GallerySize: Number of embeddings kept per track.
+//   - ReIDMaxAge: Frames a lost track with an appearance gallery is kept after `MaxMissed` to be re-identified.
+//   - TrajectoryLength: Number of past box centers kept per track.
+//
+// The appearance fields are only used for detections implementing AppearanceDetection, without embeddings
+// the tracker matches by IOU only.
+type SORT[D Detection] struct {
+	Tracks             map[int]*Track           // All tracks by internal key
+	NextTrackID        int                      // Next track ID
+	MaxMissed          int                      // Max allowed missed frames
+	MinHits            int                      // Hits required for confirmation
+	MinScore           float32                  // Minimum detection score
+	IOUThreshold       float32                  // IOU threshold for matching
+	OnTrackStateChange TrackStateChangeCallback // Lifecycle transition callback
+	AppearanceWeight   float32                  // Weight of the cosine distance
+	MaxCosineDistance  float32                  // Appearance gate
+	GallerySize        int                      // Embeddings per track
+	ReIDMaxAge         int                      // Extra frames to keep lost tracks for re-identification
+	TrajectoryLength   int                      // Trajectory points per track
+	nextTrackKey       int                      // Next internal track key
+}
+
+// NewSORT initializes a new SORT tracker.
+// Args:
+//   - maxMissed: Maximum frames a track can be unmatched before being removed.
+//   - minHits: Consecutive matched frames required before a track is confirmed and returned, values below 1 are treated as 1.
+//   - minScore: Minimum detection score required to consider a detection.
+//   - iouThreshold: IOU threshold to determine a match between a detection and a track.
+//
+// Returns:
+//
+//	*SORT[D]: A pointer to the initialized SORT instance.
+func NewSORT[D Detection](maxMissed int, minHits int, minScore, iouThreshold float32) *SORT[D] {
+	if minHits < 1 {
+		minHits = 1
+	}
+	return &SORT[D]{
+		Tracks:            make(map[int]*Track),
+		NextTrackID:       1,
+		MaxMissed:         maxMissed,
+		MinHits:           minHits,
+		MinScore:          minScore,
+		IOUThreshold:      iouThreshold,
+		AppearanceWeight:  0.5,
+		MaxCosineDistance: 0.3,
+		GallerySize:       30,
+		ReIDMaxAge:        150,
+		TrajectoryLength:  50,
+		nextTrackKey:      1,
+	}
+}
+
+// setState moves a track into a new lifecycle state and notifies the OnTrackStateChange callback.
+// A track that gets confirmed for the first time receives its unique ID here.
+func (s *SORT[D]) setState(track *Track, state TrackState) {
+	if track.State == state {
+		return
+	}
+	if state == TrackConfirmed && track.ID == 0 {
+		track.ID = s.NextTrackID
+		s.NextTrackID++
+	}
+	previous := track.State
+	track.State = state
+	if s.OnTrackStateChange != nil {
+		s.OnTrackStateChange(track, previous)
+	}
+}
+
+// Update processes the current frame's detections and updates the tracker's state.
+//
+// This function performs the following steps:
+//  0. Predicts the bounding box of every track for the current frame with its Kalman motion model.
+//  1. Matches the current detections to the predicted boxes of existing tracks using an optimal assignment on the Intersection over Union (IOU) metric,
+//     fused with the appearance distance to the track gallery if the detections carry embeddings.
+//     Tracks are corrected with the matched detection, their hits and ages are incremented and they get confirmed after `MinHits` hits.
+//  2. Creates new tentative tracks for unmatched detections above the `MinScore` threshold. These represent objects that have appeared for the first time.
+//  3. Handles unmatched tracks: tentative tracks are removed, confirmed tracks become lost and coast along their predicted path
+//     until their missed count exceeds the `MaxMissed` threshold. Lost tracks with an appearance gallery are kept for
+//     another `ReIDMaxAge` frames, so they can be re-identified by appearance only.
+//
+// Args:
+//
+//	detections []D: A slice of detections representing the detected objects in the current frame.
+//	  - Each detection has a confidence score, bounding box, and class information.
+//
+// Returns:
+//
+//	[]TrackedObject[D]: A slice of confirmed and lost tracks with their assigned track IDs.
+//	  - Tracks that got confirmed in this frame will have `IsNew` set to true.
+//	  - Each object includes the predicted box of its track (`PredictedBox`) and the track state (`State`).
+//	  - Lost tracks are returned with `Coasting` set to true and their predicted box as `Box`, as long as they
+//	    did not miss more than `MaxMissed` frames.
+//	  - Tentative tracks are not returned.
+//	  - Only confirmed tracks are drawn by the examples, lost tracks are still returned flagged `Coasting`, so
+//	    applications can keep per track state like zone dwell times over short occlusions. Skip objects with
+//	    `Coasting` set to get the confirmed tracks only.
+//
+// Behavior:
+//   - Detections with a confidence score below the `MinScore` threshold are ignored, they neither match nor create tracks.
+//   - Matching is solved as a global assignment (Hungarian algorithm) over the IOU between detections and predicted
+//     track boxes, so the order of the detections does not influence which track gets which detection.
+//     Pairs with an IOU below the `IOUThreshold` and pairs of different classes are never matched.
+//   - If both the detection and the track have appearance data, the cost is `AppearanceWeight * cosine distance +
+//     (1 - AppearanceWeight) * (1 - IOU)` and pairs above `MaxCosineDistance` are never matched.
+//   - Tracks missed for more than `MaxMissed` frames are matched by appearance only, their motion model is
+//     restarted from the detection.
+//   - Track IDs are only assigned on confirmation, so short false positives do not consume IDs.
+//   - Lifecycle transitions are reported synchronously through `OnTrackStateChange` during the update.
+func (s *SORT[D]) Update(detections []D) []TrackedObject[D] {
+	trackedObjects := make([]TrackedObject[D], 0, len(detections)) // Preallocate for speed
+
+	// Step 0: Predict the position of all tracks in the current frame
+	for _, track := range s.Tracks {
+		track.Predict()
+	}
+
+	// Track assignments
+	assignedTracks := make(map[int]struct{}, len(s.Tracks))
+	assignedDetections := make(map[int]struct{}, len(detections))
+
+	// Step 1: Match detections to existing tracks with a globally optimal assignment
+	trackKeys := s.sortedTrackKeys()
+	detIdxs := make([]int, 0, len(detections))
+	for detIdx, detection := range detections {
+		if detection.GetScore() < s.MinScore {
+			continue // Skip low-score detections
+		}
+		detIdxs = append(detIdxs, detIdx)
+	}
+
+	if len(detIdxs) > 0 && len(trackKeys) > 0 {
+		// Build the cost matrix (1 - IOU against the predicted position, fused with the appearance distance),
+		// pairs that do not pass the gates get a cost higher than any valid pair.
+		ious := make([][]float32, len(detIdxs))
+		cost := make([][]float64, len(detIdxs))
+		for row, detIdx := range detIdxs {
+			ious[row] = make([]float32, len(trackKeys))
+			cost[row] = make([]float64, len(trackKeys))
+			for col, trackKey := range trackKeys {
+				ious[row][col], cost[row][col] = s.matchCost(detections[detIdx], s.Tracks[trackKey])
+			}
+		}
+
+		for row, col := range HungarianAssignment(cost) {
+			// Skip unassigned rows and assignments that did not pass the gates
+			if col < 0 || cost[row][col] >= GATED_COST {
+				continue
+			}
+			detIdx := detIdxs[row]
+			detection := detections[detIdx]
+			box := detection.GetBox()
+			iou := ious[row][col]
+
+			// Update track with matched detection
+			trackKey := trackKeys[col]
+			track := s.Tracks[trackKey]
+			if track.Missed > s.MaxMissed {
+				// Re-identified by appearance, the old motion state is meaningless
+				track.kf = NewKalmanBoxFilter(box)
+				track.PredictedBox = box
+			}
+			track.Correct(box)
+			track.addEmbedding(embeddingOf(detection), s.GallerySize)
+			track.Age++
+			track.Hits++
+			track.Missed = 0
+
+			// Update SortScore as a running average of IOUs
+			track.SortScore = (track.SortScore*float32(track.Age-1) + iou) / float32(track.Age)
+
+			// Mark track and detection as assigned
+			assignedTracks[trackKey] = struct{}{}
+			assignedDetections[detIdx] = struct{}{}
+
+			// Confirm tracks with enough hits, lost tracks are confirmed again directly
+			isNew := false
+			switch track.State {
+			case TrackTentative:
+				if track.Hits >= s.MinHits {
+					s.setState(track, TrackConfirmed)
+					isNew = true
+				}
+			case TrackLost:
+				s.setState(track, TrackConfirmed)
+			}
+			if track.State != TrackConfirmed {
+				continue // Tentative tracks are not reported
+			}
+
+			trackedObjects = append(trackedObjects, s.trackedObject(track, detection, box, isNew))
+		}
+	}
+
+	// Step 2: Create new tentative tracks for unmatched detections
+	for detIdx, detection := range detections {
+		if _, assigned := assignedDetections[detIdx]; assigned {
+			continue // Skip already matched detections
+		}
+		if detection.GetScore() < s.MinScore {
+			continue // Low-score detections never start a track
+		}
+
+		// Create a new track
+		trackKey := s.nextTrackKey
+		s.nextTrackKey++
+
+		box := detection.GetBox()
+		track := &Track{
+			Class:        detection.GetClass(),
+			State:        TrackTentative,
+			Hits:         1,
+			Box:          box,
+			PredictedBox: box,
+			Age:          1,
+			Missed:       0,
+			SortScore:    0,
+			CreatedTime:  time.Now(),
+			kf:           NewKalmanBoxFilter(box),
+			Trajectory:   NewTrajectory(s.TrajectoryLength),
+		}
+		track.recordPosition()
+		track.addEmbedding(embeddingOf(detection), s.GallerySize)
+		s.Tracks[trackKey] = track
+		assignedTracks[trackKey] = struct{}{} // New tracks are not missed in their first frame
+
+		if track.Hits < s.MinHits {
+			continue // Wait for confirmation
+		}
+		s.setState(track, TrackConfirmed)
+		trackedObjects = append(trackedObjects, s.trackedObject(track, detection, box, true))
+	}
+
+	// Step 3: Clean up unmatched tracks
+	toDelete := make([]int, 0, len(s.Tracks)) // Preallocate potential deletions
+	for trackKey, track := range s.Tracks {
+		if _, assigned := assignedTracks[trackKey]; assigned {
+			continue // Skip matched tracks
+		}
+
+		// Increment missed count
+		track.Missed++
+		track.Hits = 0
+
+		// Tentative tracks must be matched in consecutive frames, otherwise they are dropped
+		maxMissed := s.MaxMissed
+		if len(track.gallery) > 0 {
+			maxMissed += s.ReIDMaxAge // Keep the track for re-identification by appearance
+		}
+		if track.State == TrackTentative || track.Missed > maxMissed {
+			toDelete = append(toDelete, trackKey) // Mark track for deletion
+			continue
+		}
+
+		s.setState(track, TrackLost)
+		if track.Missed > s.MaxMissed {
+			continue // Only waiting for re-identification, the prediction is not reliable anymore
+		}
+
+		// Keep coasting along the predicted path
+		track.Box = track.PredictedBox
+		var none D
+		coasting := s.trackedObject(track, none, track.PredictedBox, false)
+		coasting.Coasting = true
+		trackedObjects = append(trackedObjects, coasting)
+	}
+
+	// Delete stale tracks
+	sort.Ints(toDelete)
+	for _, trackKey := range toDelete {
+		s.setState(s.Tracks[trackKey], TrackRemoved)
+		delete(s.Tracks, trackKey)
+	}
+
+	return trackedObjects
+}
+
+// trackedObject creates the returned object of a track for the current frame.
+func (s *SORT[D]) trackedObject(track *Track, detection D, box Box, isNew bool) TrackedObject[D] {
+	return TrackedObject[D]{
+		Detection:     detection,
+		ID:            track.ID,
+		Class:         track.Class,
+		Box:           box,
+		PredictedBox:  track.PredictedBox,
+		State:         track.State,
+		IsNew:         isNew,
+		Age:           track.Age,
+		TrackingSince: track.ActiveTime(),
+		Trajectory:    track.Trajectory.Points(),
+	}
+}
+
+// matchCost computes the IOU and the assignment cost between a detection and the predicted box of a track.
+// Pairs of different classes or pairs that do not pass the IOU or appearance gate get `GATED_COST`.
+func (s *SORT[D]) matchCost(detection D, track *Track) (float32, float64) {
+	iou := IOU(detection.GetBox(), track.PredictedBox)
+	if detection.GetClass() != track.Class {
+		return iou, GATED_COST // A track never takes over a detection of another class
+	}
+	distance, hasAppearance := track.appearanceDistance(embeddingOf(detection))
+
+	switch {
+	case track.Missed > s.MaxMissed:
+		// Re-identification of a long lost track, only the appearance is meaningful
+		if hasAppearance && distance <= s.MaxCosineDistance {
+			return iou, float64(distance)
+		}
+	case hasAppearance:
+		if iou >= s.IOUThreshold && distance <= s.MaxCosineDistance {
+			return iou, float64(s.AppearanceWeight*distance + (1-s.AppearanceWeight)*(1-iou))
+		}
+	default:
+		if iou >= s.IOUThreshold {
+			return iou, 1 - float64(iou)
+		}
+	}
+	return iou, GATED_COST
+}
+
+// embeddingOf returns the appearance embedding of a detection, or nil if the detection has none.
+func embeddingOf(detection Detection) []float32 {
+	if ad, ok := detection.(AppearanceDetection); ok {
+		return ad.GetEmbedding()
+	}
+	return nil
+}
+
+// sortedTrackKeys returns the keys of all tracks in ascending order.
+// The map iteration order is random, a stable order keeps the assignment deterministic.
+func (s *SORT[D]) sortedTrackKeys() []int {
+	keys := make([]int, 0, len(s.Tracks))
+	for key := range s.Tracks {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// GetAverageSortScore calculates and returns the average SortScore for all active tracks.
+//
+// The SortScore is a metric that evaluates the quality of tracking for each individual track,
+// calculated as the running average of Intersection over Union (IOU) scores between a track
+// and its assigned detections. Tracks with higher SortScores indicate better tracking performance.
+//
+// This function only includes confirmed or lost tracks that have been active for more than one frame (`Age > 1`)
+// to ensure meaningful evaluation, as single-frame and tentative tracks may not have sufficient data for an accurate score.
+//
+// Returns:
+//
+//	float32 - The average SortScore of all active tracks. If no tracks meet the criteria, the function
+//	          returns 0 to indicate that no meaningful score could be calculated.
+func (s *SORT[D]) GetAverageSortScore() float32 {
+	var totalScore float32
+	var trackCount int
+
+	for _, track := range s.Tracks {
+		if track.State == TrackTentative {
+			continue // Tentative tracks may be false positives
+		}
+		if track.Age > 1 { // Only consider tracks that have been active for more than one frame
+			totalScore += track.SortScore
+			trackCount++
+		}
+	}
+
+	if trackCount == 0 {
+		return 0
+	}
+	return totalScore / float32(trackCount)
+}
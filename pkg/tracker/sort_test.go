@@ -0,0 +1,118 @@
+package tracker
+
+import (
+	"slices"
+	"testing"
+)
+
+// testDetection is a synthetic detection, object identifies the ground truth object it belongs to.
+type testDetection struct {
+	object    int
+	box       Box
+	score     float32
+	class     int
+	embedding []float32
+}
+
+func (d testDetection) GetBox() Box             { return d.box }
+func (d testDetection) GetScore() float32       { return d.score }
+func (d testDetection) GetClass() int           { return d.class }
+func (d testDetection) GetEmbedding() []float32 { return d.embedding }
+
+// boxAt returns a box of a size with its top left corner at x, y.
+func boxAt(x, y, size float32) Box {
+	return Box{Left: x, Top: y, Right: x + size, Bottom: y + size}
+}
+
+// assertIDs checks that every object keeps the track ID it got first.
+func assertIDs(t *testing.T, frame int, objects []TrackedObject[testDetection], ids map[int]int) {
+	t.Helper()
+	for _, obj := range objects {
+		if obj.Coasting {
+			continue
+		}
+		if id, found := ids[obj.Detection.object]; found && id != obj.ID {
+			t.Errorf("frame %d: object %d switched from ID %d to %d", frame, obj.Detection.object, id, obj.ID)
+		}
+		ids[obj.Detection.object] = obj.ID
+	}
+}
+
+func TestSORTCrossingObjects(t *testing.T) {
+	s := NewSORT[testDetection](3, 1, 0.3, 0.1)
+	ids := map[int]int{}
+	for frame := 0; frame < 16; frame++ {
+		// Object 1 moves right, object 2 moves left on an overlapping band, they cross in the middle
+		step := float32(frame) * 0.05
+		a := testDetection{object: 1, box: boxAt(0.05+step, 0.3, 0.2), score: 0.9}
+		b := testDetection{object: 2, box: boxAt(0.8-step, 0.35, 0.2), score: 0.9}
+
+		// The order of the detections changes every frame and must not matter
+		detections := []testDetection{a, b}
+		if frame%2 == 1 {
+			detections = []testDetection{b, a}
+		}
+		objects := s.Update(detections)
+		if len(objects) != 2 {
+			t.Fatalf("frame %d: got %d objects, want 2", frame, len(objects))
+		}
+		assertIDs(t, frame, objects, ids)
+	}
+	if ids[1] == ids[2] {
+		t.Errorf("both objects have ID %d", ids[1])
+	}
+}
+
+func TestSORTMinScore(t *testing.T) {
+	for _, minHits := range []int{1, 3} {
+		s := NewSORT[testDetection](3, minHits, 0.5, 0.3)
+		for frame := 0; frame < 5; frame++ {
+			objects := s.Update([]testDetection{{object: 1, box: boxAt(0.1, 0.1, 0.2), score: 0.4}})
+			if len(objects) != 0 {
+				t.Fatalf("minHits %d, frame %d: low-score detection returned as %+v", minHits, frame, objects)
+			}
+			if len(s.Tracks) != 0 {
+				t.Fatalf("minHits %d, frame %d: low-score detection created %d tracks", minHits, frame, len(s.Tracks))
+			}
+		}
+		if s.NextTrackID != 1 {
+			t.Errorf("minHits %d: low-score detections consumed IDs, next ID is %d", minHits, s.NextTrackID)
+		}
+	}
+}
+
+func TestSORTLifecycle(t *testing.T) {
+	s := NewSORT[testDetection](2, 3, 0.3, 0.3)
+	var transitions []TrackState
+	s.OnTrackStateChange = func(track *Track, previous TrackState) {
+		transitions = append(transitions, track.State)
+	}
+	detection := []testDetection{{object: 1, box: boxAt(0.1, 0.1, 0.2), score: 0.9}}
+
+	// Tentative until MinHits consecutive hits
+	for frame := 0; frame < 2; frame++ {
+		if objects := s.Update(detection); len(objects) != 0 {
+			t.Fatalf("frame %d: tentative track returned", frame)
+		}
+	}
+	objects := s.Update(detection)
+	if len(objects) != 1 || !objects[0].IsNew || objects[0].State != TrackConfirmed || objects[0].ID != 1 {
+		t.Fatalf("third hit: got %+v, want a new confirmed track with ID 1", objects)
+	}
+
+	// Lost tracks coast until they missed more than MaxMissed frames
+	for frame := 0; frame < 2; frame++ {
+		objects = s.Update(nil)
+		if len(objects) != 1 || !objects[0].Coasting || objects[0].State != TrackLost {
+			t.Fatalf("miss %d: got %+v, want a coasting lost track", frame, objects)
+		}
+	}
+	if objects = s.Update(nil); len(objects) != 0 || len(s.Tracks) != 0 {
+		t.Fatalf("after MaxMissed: got %+v and %d tracks, want the track removed", objects, len(s.Tracks))
+	}
+
+	want := []TrackState{TrackConfirmed, TrackLost, TrackRemoved}
+	if !slices.Equal(transitions, want) {
+		t.Errorf("transitions %v, want %v", transitions, want)
+	}
+}
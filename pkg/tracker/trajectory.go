@@ -0,0 +1,57 @@
+package tracker
+
+import "time"
+
+// TrajectoryPoint is a past box center of a track in normalized coordinates.
+type TrajectoryPoint struct {
+	X, Y float32   // Box center
+	Time time.Time // Time the position was observed
+}
+
+// Trajectory is a bounded ring buffer of the past box centers of a track.
+// When the buffer is full the oldest point is overwritten.
+type Trajectory struct {
+	points []TrajectoryPoint
+	next   int // Index of the slot written next
+	count  int // Number of valid points
+}
+
+// NewTrajectory creates a trajectory that keeps up to capacity points.
+func NewTrajectory(capacity int) *Trajectory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Trajectory{points: make([]TrajectoryPoint, capacity)}
+}
+
+// Add appends a point and drops the oldest one if the trajectory is full.
+func (t *Trajectory) Add(p TrajectoryPoint) {
+	t.points[t.next] = p
+	t.next = (t.next + 1) % len(t.points)
+	if t.count < len(t.points) {
+		t.count++
+	}
+}
+
+// Len returns the number of stored points.
+func (t *Trajectory) Len() int {
+	return t.count
+}
+
+// Last returns the newest point, false if the trajectory is empty.
+func (t *Trajectory) Last() (TrajectoryPoint, bool) {
+	if t.count == 0 {
+		return TrajectoryPoint{}, false
+	}
+	return t.points[(t.next-1+len(t.points))%len(t.points)], true
+}
+
+// Points returns a copy of the stored points ordered from oldest to newest.
+func (t *Trajectory) Points() []TrajectoryPoint {
+	points := make([]TrajectoryPoint, t.count)
+	start := (t.next - t.count + len(t.points)) % len(t.points)
+	for i := range points {
+		points[i] = t.points[(start+i)%len(t.points)]
+	}
+	return points
+}
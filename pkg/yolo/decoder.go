@@ -0,0 +1,283 @@
+// Package yolo decodes the quantized output tensor of YOLO object detection models.
+//
+// The decoder is configured from the output tensor shape and its quantization parameters, so models with a
+// custom class count work without code changes. Both the YOLOv5 anchor layout and the anchor free YOLOv8 layout
+// are supported. Boxes are returned in normalized coordinates like the boxes of the tracker package.
+package yolo
+
+import (
+	"fmt"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// Layout is the layout of the output tensor.
+type Layout int
+
+const (
+	LayoutAuto Layout = iota // Detect the layout from the tensor shape
+	LayoutV5                 // [1, predictions, 5 + classes]: x, y, w, h, objectness, class scores per prediction
+	LayoutV8                 // [1, 4 + classes, predictions]: transposed x, y, w, h, class scores without objectness
+)
+
+// String returns a readable name of the layout.
+func (l Layout) String() string {
+	switch l {
+	case LayoutV5:
+		return "yolov5"
+	case LayoutV8:
+		return "yolov8"
+	}
+	return "auto"
+}
+
+// DataType is the element type of the quantized output tensor.
+type DataType int
+
+const (
+	Uint8 DataType = iota
+	Int8
+)
+
+// Quantization holds the affine quantization parameters of the output tensor,
+// real value = Scale * (quantized value - ZeroPoint).
+type Quantization struct {
+	Scale     float32
+	ZeroPoint int
+}
+
+// Config configures a decoder.
+// Fields:
+//   - Shape: Shape of the output tensor, e.g. [1, 25200, 85] for yolov5n or [1, 84, 8400] for yolov8n.
+//   - Layout: Layout of the output tensor, LayoutAuto detects it from the shape.
+//   - DataType: Element type of the output tensor.
+//   - Quantization: Quantization parameters of the output tensor.
+//   - Threshold: Minimum confidence of a detection, the objectness (v5) and the final score must be above it.
+//   - InputWidth, InputHeight: Model input size, set them if the model outputs pixel instead of normalized coordinates.
+type Config struct {
+	Shape        []int
+	Layout       Layout
+	DataType     DataType
+	Quantization Quantization
+	Threshold    float32
+	InputWidth   int
+	InputHeight  int
+}
+
+// Detection is a single decoded detection, it implements tracker.Detection.
+type Detection struct {
+	Box        tracker.Box // Box in normalized coordinates
+	Confidence float32     // Final score, objectness multiplied by the class score for v5, the class score for v8
+	Objectness float32     // Objectness of the prediction, always 1 for v8 which has no objectness
+	Class      int         // Index of the best class
+	ClassScore float32     // Score of the best class
+}
+
+// GetBox, GetScore and GetClass implement tracker.Detection
+func (d Detection) GetBox() tracker.Box { return d.Box }
+func (d Detection) GetScore() float32   { return d.Confidence }
+func (d Detection) GetClass() int       { return d.Class }
+
+// Decoder decodes the output tensor of a YOLO model into detections.
+// Predictions are rejected in the quantized domain before anything is dequantized, dequantization is done
+// with a lookup table and all buffers are reused across frames, so decoding a frame does not allocate.
+type Decoder struct {
+	layout         Layout
+	numPredictions int
+	numClasses     int
+	threshold      float32
+	scaleX, scaleY float32
+
+	value    [256]int16   // Quantized value of each raw byte
+	real     [256]float32 // Dequantized value of each raw byte
+	minValue int16        // Smallest quantized value above the threshold, 256 if none
+
+	bestValue  []int16     // Best class value per prediction, v8 only
+	bestClass  []int       // Best class index per prediction, v8 only
+	detections []Detection // Reused detection buffer
+}
+
+// NewDecoder creates a decoder from the output tensor configuration.
+// Returns an error if the shape does not match a supported layout.
+func NewDecoder(cfg Config) (*Decoder, error) {
+	shape := squeeze(cfg.Shape)
+	if len(shape) != 2 {
+		return nil, fmt.Errorf("unsupported output tensor shape %v, expected [1, a, b]", cfg.Shape)
+	}
+	if cfg.Quantization.Scale <= 0 {
+		return nil, fmt.Errorf("invalid quantization scale %f", cfg.Quantization.Scale)
+	}
+
+	layout := cfg.Layout
+	if layout == LayoutAuto {
+		layout = DetectLayout(cfg.Shape)
+	}
+
+	d := &Decoder{layout: layout, threshold: cfg.Threshold, scaleX: 1, scaleY: 1, detections: make([]Detection, 0, 256)}
+	switch layout {
+	case LayoutV5:
+		d.numPredictions, d.numClasses = shape[0], shape[1]-5
+	case LayoutV8:
+		d.numPredictions, d.numClasses = shape[1], shape[0]-4
+		d.bestValue = make([]int16, d.numPredictions)
+		d.bestClass = make([]int, d.numPredictions)
+	default:
+		return nil, fmt.Errorf("unsupported layout %d", layout)
+	}
+	if d.numClasses < 1 {
+		return nil, fmt.Errorf("output tensor shape %v has no classes for layout %s", cfg.Shape, layout)
+	}
+	if cfg.InputWidth > 0 && cfg.InputHeight > 0 {
+		d.scaleX, d.scaleY = 1/float32(cfg.InputWidth), 1/float32(cfg.InputHeight)
+	}
+
+	d.minValue = 256
+	for b := 0; b < 256; b++ {
+		v := int16(b)
+		if cfg.DataType == Int8 {
+			v = int16(int8(b))
+		}
+		d.value[b] = v
+		d.real[b] = cfg.Quantization.Scale * float32(int(v)-cfg.Quantization.ZeroPoint)
+		if d.real[b] > cfg.Threshold && v < d.minValue {
+			d.minValue = v
+		}
+	}
+	return d, nil
+}
+
+// DetectLayout guesses the layout from the output tensor shape.
+// YOLOv5 has many more predictions than values per prediction, YOLOv8 is transposed.
+func DetectLayout(shape []int) Layout {
+	s := squeeze(shape)
+	if len(s) == 2 && s[0] < s[1] {
+		return LayoutV8
+	}
+	return LayoutV5
+}
+
+// Layout returns the layout of the decoder.
+func (d *Decoder) Layout() Layout { return d.layout }
+
+// NumClasses returns the number of classes of the model.
+func (d *Decoder) NumClasses() int { return d.numClasses }
+
+// NumPredictions returns the number of predictions of the model.
+func (d *Decoder) NumPredictions() int { return d.numPredictions }
+
+// TensorSize returns the expected size of the output tensor in bytes.
+func (d *Decoder) TensorSize() int {
+	if d.layout == LayoutV5 {
+		return d.numPredictions * (5 + d.numClasses)
+	}
+	return d.numPredictions * (4 + d.numClasses)
+}
+
+// Decode converts the raw output tensor into detections above the threshold.
+// Returns nil if the tensor is smaller than expected.
+// The returned slice is only valid until the next call of Decode.
+func (d *Decoder) Decode(t []byte) []Detection {
+	d.detections = d.detections[:0]
+	if len(t) < d.TensorSize() {
+		return nil
+	}
+	t = t[:d.TensorSize()]
+	if d.layout == LayoutV8 {
+		return d.decodeV8(t)
+	}
+	return d.decodeV5(t)
+}
+
+// decodeV5 decodes the row major YOLOv5 layout, one prediction after another.
+func (d *Decoder) decodeV5(t []byte) []Detection {
+	stride := 5 + d.numClasses
+	for offset := 0; offset+stride <= len(t); offset += stride {
+		p := t[offset : offset+stride : offset+stride]
+
+		// Early reject in the quantized domain
+		if d.value[p[4]] < d.minValue {
+			continue
+		}
+
+		// Best class in the quantized domain
+		best := 5
+		for j := 6; j < stride; j++ {
+			if d.value[p[j]] > d.value[p[best]] {
+				best = j
+			}
+		}
+
+		// Final score like upstream yolov5
+		objectness := d.real[p[4]]
+		classScore := d.real[p[best]]
+		confidence := objectness * classScore
+		if confidence <= d.threshold {
+			continue
+		}
+
+		d.detections = append(d.detections, Detection{
+			Box:        d.box(d.real[p[0]], d.real[p[1]], d.real[p[2]], d.real[p[3]]),
+			Confidence: confidence,
+			Objectness: objectness,
+			Class:      best - 5,
+			ClassScore: classScore,
+		})
+	}
+	return d.detections
+}
+
+// decodeV8 decodes the transposed YOLOv8 layout, one row per value.
+// The best class is searched row by row, so the tensor is read sequentially.
+func (d *Decoder) decodeV8(t []byte) []Detection {
+	n := d.numPredictions
+	first := t[4*n : 5*n]
+	for i, b := range first {
+		d.bestValue[i] = d.value[b]
+		d.bestClass[i] = 0
+	}
+	for c := 1; c < d.numClasses; c++ {
+		row := t[(4+c)*n : (5+c)*n]
+		for i, b := range row {
+			if v := d.value[b]; v > d.bestValue[i] {
+				d.bestValue[i] = v
+				d.bestClass[i] = c
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if d.bestValue[i] < d.minValue {
+			continue
+		}
+		class := d.bestClass[i]
+		classScore := d.real[t[(4+class)*n+i]]
+		d.detections = append(d.detections, Detection{
+			Box:        d.box(d.real[t[i]], d.real[t[n+i]], d.real[t[2*n+i]], d.real[t[3*n+i]]),
+			Confidence: classScore,
+			Objectness: 1,
+			Class:      class,
+			ClassScore: classScore,
+		})
+	}
+	return d.detections
+}
+
+// box converts a center box into a normalized corner box.
+func (d *Decoder) box(centerX, centerY, width, height float32) tracker.Box {
+	centerX, width = centerX*d.scaleX, width*d.scaleX
+	centerY, height = centerY*d.scaleY, height*d.scaleY
+	return tracker.Box{
+		Top:    centerY - height/2,
+		Left:   centerX - width/2,
+		Bottom: centerY + height/2,
+		Right:  centerX + width/2,
+	}
+}
+
+// squeeze removes the leading batch dimensions of size 1.
+func squeeze(shape []int) []int {
+	for len(shape) > 2 && shape[0] == 1 {
+		shape = shape[1:]
+	}
+	return shape
+}
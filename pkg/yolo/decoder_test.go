@@ -0,0 +1,164 @@
+package yolo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+const (
+	v5nPredictions  = 25200
+	v5nStride       = 85                   // 4 box coordinates, objectness and 80 class scores
+	v5nQuantization = 0.004190513398498297 // yolov5n.tflite
+	v5nThreshold    = 0.25
+)
+
+// v5nTensor returns a 1x25200x85 uint8 output tensor in the layout of axlarod/yolov5/yolov5n.tflite.
+// It is generated with a fixed seed, so decoding and NMS do realistic work: background predictions with low
+// objectness, some predictions which pass the objectness but not the final score, and clusters of overlapping
+// predictions around five objects. An output recorded on a camera with replay.Recorder, the .out0 file of a
+// frame, can be read instead.
+func v5nTensor(tb testing.TB) []byte {
+	tb.Helper()
+	rng := rand.New(rand.NewSource(1))
+	quantized := func(low, high int) byte { return byte(low + rng.Intn(high-low+1)) }
+	t := make([]byte, v5nPredictions*v5nStride)
+	for i := 0; i < v5nPredictions; i++ {
+		p := t[i*v5nStride : (i+1)*v5nStride]
+		p[0], p[1], p[2], p[3] = quantized(0, 238), quantized(0, 238), quantized(5, 60), quantized(5, 60)
+		p[4] = quantized(0, 40)
+		if i%50 == 0 {
+			p[4] = quantized(80, 150) // Passes the objectness, the class scores keep it below the threshold
+		}
+		for j := 5; j < v5nStride; j++ {
+			p[j] = quantized(0, 40)
+		}
+	}
+	for object := 0; object < 5; object++ {
+		cx, cy, w, h, class := quantized(30, 200), quantized(30, 200), quantized(20, 60), quantized(20, 60), 5+rng.Intn(80)
+		for n := 0; n < 20; n++ {
+			p := t[rng.Intn(v5nPredictions)*v5nStride:]
+			p[0], p[1] = cx+quantized(0, 6), cy+quantized(0, 6)
+			p[2], p[3] = w+quantized(0, 6), h+quantized(0, 6)
+			p[4], p[class] = quantized(200, 250), quantized(200, 250)
+		}
+	}
+	return t
+}
+
+// newV5nDecoder creates a decoder for the yolov5n output tensor.
+func newV5nDecoder(tb testing.TB) *Decoder {
+	tb.Helper()
+	d, err := NewDecoder(Config{
+		Shape:        []int{1, 25200, 85},
+		DataType:     Uint8,
+		Quantization: Quantization{Scale: v5nQuantization},
+		Threshold:    v5nThreshold,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return d
+}
+
+// referenceDecode is the previous decoding of the yolov5 example, every element is dequantized one by one
+// before the objectness is compared. The final score is objectness times class score like in Decode.
+func referenceDecode(t []byte, threshold float32) []Detection {
+	dequantize := func(q uint8) float32 { return v5nQuantization * float32(q) }
+	const predictions, stride = v5nPredictions, v5nStride
+	detections := make([]Detection, 0, predictions)
+	for i := 0; i < predictions; i++ {
+		offset := i * stride
+		centerX := dequantize(t[offset])
+		centerY := dequantize(t[offset+1])
+		width := dequantize(t[offset+2])
+		height := dequantize(t[offset+3])
+		objectness := dequantize(t[offset+4])
+		if objectness <= threshold {
+			continue
+		}
+		bestClass, bestScore := 0, dequantize(t[offset+5])
+		for j := 1; j < stride-5; j++ {
+			if score := dequantize(t[offset+5+j]); score > bestScore {
+				bestClass, bestScore = j, score
+			}
+		}
+		if objectness*bestScore <= threshold {
+			continue
+		}
+		detections = append(detections, Detection{
+			Box:        tracker.Box{Top: centerY - height/2, Left: centerX - width/2, Bottom: centerY + height/2, Right: centerX + width/2},
+			Confidence: objectness * bestScore,
+			Objectness: objectness,
+			Class:      bestClass,
+			ClassScore: bestScore,
+		})
+	}
+	return detections
+}
+
+func TestDecodeMatchesReference(t *testing.T) {
+	tensor := v5nTensor(t)
+	want := referenceDecode(tensor, v5nThreshold)
+	got := newV5nDecoder(t).Decode(tensor)
+	if len(want) == 0 {
+		t.Fatal("the tensor has no detections above the threshold")
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d detections, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("detection %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDecodeDoesNotAllocate(t *testing.T) {
+	tensor := v5nTensor(t)
+	d := newV5nDecoder(t)
+	d.Decode(tensor) // Grow the reused buffers
+	if allocs := testing.AllocsPerRun(10, func() { d.Decode(tensor) }); allocs != 0 {
+		t.Errorf("Decode allocates %v times per frame", allocs)
+	}
+}
+
+func TestDecodeShortTensor(t *testing.T) {
+	if got := newV5nDecoder(t).Decode(make([]byte, 100)); got != nil {
+		t.Errorf("got %d detections from a short tensor, want nil", len(got))
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	tensor := v5nTensor(b)
+	d := newV5nDecoder(b)
+	b.SetBytes(int64(len(tensor)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Decode(tensor)
+	}
+}
+
+func BenchmarkDecodeNMS(b *testing.B) {
+	tensor := v5nTensor(b)
+	d := newV5nDecoder(b)
+	nms := NewNMS(0.5, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nms.Apply(d.Decode(tensor))
+	}
+}
+
+// BenchmarkReferenceDecode is the baseline the decoder is compared to.
+func BenchmarkReferenceDecode(b *testing.B) {
+	tensor := v5nTensor(b)
+	b.SetBytes(int64(len(tensor)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		referenceDecode(tensor, v5nThreshold)
+	}
+}
@@ -0,0 +1,143 @@
+package yolo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Cacsjep/goxis_examples/pkg/tracker"
+)
+
+// NMSMethod selects how overlapping detections are suppressed.
+type NMSMethod int
+
+const (
+	NMSHard NMSMethod = iota // Drop detections overlapping a better one by more than the IoU threshold
+	NMSDIoU                  // Like NMSHard, but the overlap is the distance IoU, so close but separate objects survive
+	NMSSoft                  // Decay the score of overlapping detections instead of dropping them (gaussian Soft-NMS)
+)
+
+// NMS is a class-aware non-maximum suppression, detections only suppress detections of the same class.
+// Fields:
+//   - Method: Suppression method, NMSHard by default.
+//   - IOUThreshold: Overlap above which a detection is suppressed, used by NMSHard and NMSDIoU.
+//   - SoftSigma: Sigma of the gaussian score decay, used by NMSSoft.
+//   - ScoreThreshold: Detections with a decayed score below the threshold are dropped, used by NMSSoft.
+//   - MaxDetections: Maximum number of kept detections, 0 keeps all.
+//   - ClassAgnostic: Suppress across classes instead of per class.
+type NMS struct {
+	Method         NMSMethod
+	IOUThreshold   float32
+	SoftSigma      float32
+	ScoreThreshold float32
+	MaxDetections  int
+	ClassAgnostic  bool
+	keep           []Detection // Reused result buffer
+}
+
+// NewNMS creates a class-aware hard NMS with the given IoU threshold and detection cap.
+func NewNMS(iouThreshold float32, maxDetections int) *NMS {
+	return &NMS{
+		Method:        NMSHard,
+		IOUThreshold:  iouThreshold,
+		SoftSigma:     0.5,
+		MaxDetections: maxDetections,
+	}
+}
+
+// Apply suppresses overlapping detections.
+// Args:
+//   - detections: The decoded detections, the slice is reordered and, for NMSSoft, the scores are decayed in place.
+//
+// Returns:
+//
+//	[]Detection: The kept detections sorted by confidence, only valid until the next call of Apply.
+func (n *NMS) Apply(detections []Detection) []Detection {
+	n.keep = n.keep[:0]
+	if n.Method == NMSSoft {
+		return n.applySoft(detections)
+	}
+
+	sort.Slice(detections, func(i, j int) bool {
+		return detections[i].Confidence > detections[j].Confidence
+	})
+
+	for _, det := range detections {
+		if n.MaxDetections > 0 && len(n.keep) >= n.MaxDetections {
+			break
+		}
+		suppressed := false
+		for _, kept := range n.keep {
+			if !n.ClassAgnostic && kept.Class != det.Class {
+				continue
+			}
+			if n.overlap(det, kept) > n.IOUThreshold {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			n.keep = append(n.keep, det)
+		}
+	}
+	return n.keep
+}
+
+// applySoft repeatedly keeps the best remaining detection and decays the scores of the ones overlapping it.
+func (n *NMS) applySoft(detections []Detection) []Detection {
+	remaining := detections
+	for len(remaining) > 0 {
+		if n.MaxDetections > 0 && len(n.keep) >= n.MaxDetections {
+			break
+		}
+
+		best := 0
+		for i := range remaining {
+			if remaining[i].Confidence > remaining[best].Confidence {
+				best = i
+			}
+		}
+		remaining[0], remaining[best] = remaining[best], remaining[0]
+		kept := remaining[0]
+		n.keep = append(n.keep, kept)
+		remaining = remaining[1:]
+
+		// Decay and drop in place
+		alive := remaining[:0]
+		for _, det := range remaining {
+			if n.ClassAgnostic || det.Class == kept.Class {
+				iou := tracker.IOU(det.Box, kept.Box)
+				det.Confidence *= float32(math.Exp(-float64(iou*iou) / float64(n.SoftSigma)))
+			}
+			if det.Confidence >= n.ScoreThreshold {
+				alive = append(alive, det)
+			}
+		}
+		remaining = alive
+	}
+	return n.keep
+}
+
+// overlap returns the IoU, or the distance IoU for NMSDIoU, of two detections.
+func (n *NMS) overlap(a, b Detection) float32 {
+	iou := tracker.IOU(a.Box, b.Box)
+	if n.Method != NMSDIoU {
+		return iou
+	}
+	return iou - centerDistancePenalty(a.Box, b.Box)
+}
+
+// centerDistancePenalty returns the squared center distance of two boxes divided by the squared diagonal
+// of their enclosing box, the penalty term of the distance IoU.
+func centerDistancePenalty(a, b tracker.Box) float32 {
+	ax, ay := a.Center()
+	bx, by := b.Center()
+	dist := (ax-bx)*(ax-bx) + (ay-by)*(ay-by)
+
+	w := max(a.Right, b.Right) - min(a.Left, b.Left)
+	h := max(a.Bottom, b.Bottom) - min(a.Top, b.Top)
+	diag := w*w + h*h
+	if diag <= 0 {
+		return 0
+	}
+	return dist / diag
+}
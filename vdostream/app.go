@@ -20,6 +20,17 @@ func main() {
 	// AcapApplication initializes the ACAP application with there name, eventloop, and syslog etc..
 	app := acapapp.NewAcapApplication()
 
+	// Storage provider with channel events, the recording starts once the disk is set up.
+	// The callbacks of axstorage need the running event loop.
+	rec := &recorder{app: app}
+	if RECORD_DISK != "" {
+		app.NewStorageProvider(true)
+		if err = app.StorageProvider.Open(); err != nil {
+			app.Syslog.Crit(err.Error())
+		}
+		app.RunInBackground()
+	}
+
 	// FrameProvider for easy go channeld based frame receiving
 	if err = app.NewFrameProvider(stream_cfg); err != nil {
 		app.Syslog.Crit(err.Error())
@@ -34,6 +45,8 @@ func main() {
 	// * Expected errors are detected automatically in the Frameprovider and force a stream restart.
 	for {
 		select {
+		case disk := <-diskEvents(app):
+			rec.OnDiskEvent(disk)
 		case frame := <-app.FrameProvider.FrameStreamChannel:
 			if frame.Error != nil {
 				app.Syslog.Errorf("Unexpected Vdo Error: %s", frame.Error.Error())
@@ -41,7 +54,8 @@ func main() {
 			}
 			app.Syslog.Info(frame.String())
 
-			// Do something with the frame like frame.Data()
+			// Do something with the frame like frame.Data(), here it is recorded
+			rec.Write(frame)
 		}
 	}
 }
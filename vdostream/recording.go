@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/Cacsjep/goxis/pkg/acapapp"
+	"github.com/Cacsjep/goxis/pkg/axstorage"
+	"github.com/Cacsjep/goxis/pkg/axvdo"
+	"github.com/Cacsjep/goxis_examples/pkg/recording"
+)
+
+// The H.265 stream is recorded as .h265 segments, set vdo_format to axvdo.VdoFormatYUV with a Width and Height
+// in stream_cfg to record the raw frames for the replay package instead.
+var (
+	RECORD_DISK             = "SD_DISK"        // Storage id of the recording disk, e.g. SD_DISK or NetworkShare, empty disables the recording
+	RECORD_SEGMENT_DURATION = time.Minute      // Duration of a .h265 segment, a segment is continued until the next key frame
+	RECORD_MAX_SEGMENTS     = 10               // Number of kept .h265 segments, the oldest is removed, .yuv frames are all kept
+	RECORD_MAX_FRAMES       = 0                // Stop the recording after n frames, 0 records until the app stops
+	RECORD_MAX_DURATION     = time.Duration(0) // Stop the recording after this duration, 0 records until the app stops
+)
+
+// recorder records the frames of the stream on a disk of the storage provider.
+// The recording starts once the disk is set up and stops when the disk disappears or a limit is reached.
+// After the disk is set up again, e.g. a reinserted SD card, a new recording starts until a limit was reached.
+type recorder struct {
+	app    *acapapp.AcapApplication
+	writer *recording.Writer
+	done   bool
+}
+
+// OnDiskEvent starts the recording on RECORD_DISK once it is set up and stops it when the disk is exiting.
+// Each start records into a new directory named by the start time, e.g. <StoragePath>/20240101_120000.
+func (r *recorder) OnDiskEvent(disk *axstorage.DiskItem) {
+	if string(disk.StorageId) != RECORD_DISK {
+		return
+	}
+	if disk.Exiting || disk.Full {
+		r.stop(false, "disk %s", disk)
+		return
+	}
+	if r.writer != nil || r.done || !disk.Setup || !disk.Writable {
+		return
+	}
+
+	// A YUV segment is a single frame, keeping only the last segments would keep only the last frames
+	format, maxSegments := recording.H265, RECORD_MAX_SEGMENTS
+	if vdo_format == axvdo.VdoFormatYUV {
+		format, maxSegments = recording.YUV, 0
+	}
+	dir := filepath.Join(disk.StoragePath, time.Now().Format("20060102_150405"))
+	if r.writer, err = recording.NewWriter(recording.Config{
+		Dir:             dir,
+		Format:          format,
+		SegmentDuration: RECORD_SEGMENT_DURATION,
+		MaxSegments:     maxSegments,
+		MaxFrames:       RECORD_MAX_FRAMES,
+		MaxDuration:     RECORD_MAX_DURATION,
+	}); err != nil {
+		r.app.Syslog.Errorf("Unable to start recording: %s", err.Error())
+		return
+	}
+	r.app.Syslog.Infof("Recording into %s", dir)
+}
+
+// diskEvents returns the disk events of the storage provider, nil if the recording is disabled.
+func diskEvents(app *acapapp.AcapApplication) chan *axstorage.DiskItem {
+	if app.StorageProvider == nil {
+		return nil
+	}
+	return app.StorageProvider.DiskItemsEvents
+}
+
+// Write records a frame if the recording is running.
+func (r *recorder) Write(frame *axvdo.VideoFrame) {
+	if r.writer == nil {
+		return
+	}
+	err := r.writer.Write(frame.Data, frame.Type == axvdo.VdoFrameTypeH265IDR, frame.Timestamp)
+	switch {
+	case errors.Is(err, recording.ErrDone):
+		r.stop(true, "limit reached")
+	case err != nil:
+		r.stop(false, "write failed: %s", err.Error())
+	}
+}
+
+// stop closes the recording, done prevents a restart.
+func (r *recorder) stop(done bool, reason string, args ...any) {
+	if r.writer == nil {
+		return
+	}
+	if err := r.writer.Close(); err != nil {
+		r.app.Syslog.Errorf("Unable to close recording: %s", err.Error())
+	}
+	r.app.Syslog.Infof("Recording stopped after %d frames, "+reason, append([]any{r.writer.Frames()}, args...)...)
+	r.writer = nil
+	r.done = done
+}
@@ -40,6 +40,9 @@ func main() {
 		app.Syslog.Crit(err.Error())
 	}
 
+	// Overlay preview rendered without a camera, see preview.go
+	fapp.Get(baseUri+"/preview.png", previewHandler(previewScene()))
+
 	// Index.html hosting
 	fapp.Use(baseUri, filesystem.New(filesystem.Config{
 		Root: http.FS(f),
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+
+	"github.com/Cacsjep/goxis_examples/pkg/raster"
+	"github.com/Cacsjep/goxis_examples/pkg/scene"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	PREVIEW_WIDTH      = 640  // Default width of the preview image
+	PREVIEW_HEIGHT     = 360  // Default height of the preview image
+	PREVIEW_MAX_PIXELS = 1920 // Maximum width or height a request can ask for
+)
+
+// previewScene returns a demo overlay scene, the same scene could be drawn on a stream with pkg/overlay.
+// The sizes are meant for the default preview size and scale with the requested size.
+func previewScene() *scene.Scene {
+	s := scene.New()
+	s.Reference = scene.Point{X: float64(PREVIEW_WIDTH), Y: float64(PREVIEW_HEIGHT)}
+	s.Set("title", scene.Text{
+		Pos:       scene.Point{X: 0.02, Y: 0.03},
+		Text:      "Overlay preview",
+		TextStyle: scene.TextStyle{Size: 24, Color: color.RGBA{255, 255, 255, 255}, Background: color.RGBA{0, 0, 0, 160}, Padding: 6},
+	})
+	s.Set("zone", scene.Polygon{
+		Points: []scene.Point{{X: 0.55, Y: 0.55}, {X: 0.9, Y: 0.5}, {X: 0.95, Y: 0.9}, {X: 0.6, Y: 0.95}},
+		Style:  scene.Style{Stroke: color.RGBA{76, 175, 80, 255}, Fill: color.RGBA{76, 175, 80, 70}, LineWidth: 2},
+	})
+	s.Set("box", scene.Box(
+		scene.Point{X: 0.15, Y: 0.3},
+		scene.Point{X: 0.3, Y: 0.5},
+		color.RGBA{33, 150, 243, 255},
+		"ID-1 PERSON 87%",
+		scene.TextStyle{Size: 17, Color: color.RGBA{255, 255, 255, 255}, Padding: 3},
+	))
+	return s
+}
+
+// previewHandler renders the scene with the pure Go rasterizer and responds with a PNG,
+// the size can be changed with the width and height query parameters.
+func previewHandler(s *scene.Scene) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		width, height := c.QueryInt("width", PREVIEW_WIDTH), c.QueryInt("height", PREVIEW_HEIGHT)
+		if width <= 0 || height <= 0 || width > PREVIEW_MAX_PIXELS || height > PREVIEW_MAX_PIXELS {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("width and height must be between 1 and %d", PREVIEW_MAX_PIXELS))
+		}
+		canvas := raster.New(width, height)
+		if err := s.Render(canvas, width, height); err != nil {
+			return err
+		}
+		c.Type("png")
+		return png.Encode(c, canvas.Img)
+	}
+}